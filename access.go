@@ -0,0 +1,29 @@
+package toniebox
+
+// AccessLevel describes the role of the current user within a household
+type AccessLevel string
+
+// Access levels returned by the Toniebox API in Household.Access
+const (
+	// AccessOwner is the creator of the household with full control
+	AccessOwner AccessLevel = "owner"
+	// AccessMember is an invited member of the household
+	AccessMember AccessLevel = "member"
+)
+
+// IsOwner reports whether the current user owns this household
+func (h *Household) IsOwner() bool {
+	return h.Access == AccessOwner
+}
+
+// CanManageTonies reports whether the current user may rename Creative-Tonies
+// and change their content in this household
+func (h *Household) CanManageTonies() bool {
+	return h.Access == AccessOwner || h.Access == AccessMember
+}
+
+// CanManageMembers reports whether the current user may invite and remove
+// members of this household
+func (h *Household) CanManageMembers() bool {
+	return h.IsOwner()
+}
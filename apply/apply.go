@@ -0,0 +1,313 @@
+// Package apply implements a declarative apply engine for Creative-Tonies.
+//
+// Callers describe the desired state of a tonie (name and ordered chapters)
+// in a Spec. The Engine compares it with the current state, produces a Plan
+// of actions that can be reviewed, and applies the plan on approval:
+//
+//	engine := &apply.Engine{}
+//	plan, err := engine.Plan(tonie, spec)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Print(plan)
+//	if confirmed {
+//	    err = engine.Apply(tonie, plan)
+//	}
+package apply
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/eventlog"
+	"github.com/mikeboe/toniebox-api-go/policy"
+	"github.com/mikeboe/toniebox-api-go/window"
+)
+
+// DefaultUploadBandwidth is the upload bandwidth in bytes per second assumed
+// for time estimates when Engine.UploadBandwidth is not set
+const DefaultUploadBandwidth = 1 << 20
+
+// Spec describes the desired state of a Creative-Tonie
+type Spec struct {
+	// Name is the desired tonie name; empty keeps the current name
+	Name string `json:"name,omitempty"`
+	// Chapters are the desired chapters in playback order
+	Chapters []ChapterSpec `json:"chapters"`
+}
+
+// ChapterSpec describes a desired chapter. Existing chapters are matched by title.
+type ChapterSpec struct {
+	// Title is the chapter title on the tonie
+	Title string `json:"title"`
+	// File is the local audio file uploaded if the chapter does not exist yet
+	File string `json:"file,omitempty"`
+}
+
+// Engine computes and applies plans. The zero value is ready to use.
+type Engine struct {
+	// UploadBandwidth is the expected upload bandwidth in bytes per second,
+	// used to estimate upload times. Defaults to DefaultUploadBandwidth.
+	UploadBandwidth int64
+	// Windows restrict uploads to certain hours (e.g. only at night).
+	// Apply waits for an open window before each upload. Nil allows any time.
+	Windows window.Windows
+	// Events receives a structured event for every applied action; nil disables it
+	Events eventlog.Logger
+	// Policy rejects plans that delete protected chapters, rename protected
+	// tonies or exceed the deletion limit; nil allows everything.
+	// Deletions are counted over the lifetime of the Engine, so use a new
+	// Engine for every run.
+	Policy *policy.Policy
+
+	deletedMu sync.Mutex
+	deleted   int
+}
+
+// Plan computes the actions needed to bring tonie into the state described by spec.
+// The tonie itself is not modified. Planning a rename fails with a
+// *toniebox.DuplicateNameError if another tonie in the household already uses
+// the desired name. Plans violating the engine's Policy fail with a
+// *policy.ViolationError.
+func (e *Engine) Plan(tonie *toniebox.CreativeTonie, spec Spec) (*Plan, error) {
+	plan := &Plan{
+		TonieID:         tonie.ID,
+		TonieName:       tonie.Name,
+		UploadBandwidth: e.UploadBandwidth,
+	}
+	if plan.UploadBandwidth <= 0 {
+		plan.UploadBandwidth = DefaultUploadBandwidth
+	}
+
+	if spec.Name != "" && spec.Name != tonie.Name {
+		if err := tonie.CheckName(spec.Name); err != nil {
+			return nil, err
+		}
+		plan.Actions = append(plan.Actions, Action{
+			Type:  ActionRename,
+			Title: spec.Name,
+			From:  tonie.Name,
+		})
+	}
+
+	// Match desired chapters against existing chapters by title, in order
+	matched := make(map[string]bool, len(tonie.Chapters))
+	var creates []Action
+	for _, want := range spec.Chapters {
+		var existing *toniebox.Chapter
+		for i := range tonie.Chapters {
+			ch := &tonie.Chapters[i]
+			if !matched[ch.ID] && ch.Title == want.Title {
+				existing = ch
+				break
+			}
+		}
+
+		if existing != nil {
+			matched[existing.ID] = true
+			plan.Order = append(plan.Order, Slot{ChapterID: existing.ID})
+			continue
+		}
+
+		if want.File == "" {
+			return nil, fmt.Errorf("chapter %q does not exist and has no file to upload", want.Title)
+		}
+		info, err := os.Stat(want.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", want.File, err)
+		}
+		plan.Order = append(plan.Order, Slot{Create: len(creates)})
+		creates = append(creates, Action{
+			Type:  ActionCreate,
+			Title: want.Title,
+			File:  want.File,
+			Size:  info.Size(),
+		})
+		plan.UploadBytes += info.Size()
+	}
+
+	// Everything that was not matched is deleted
+	var remaining []string
+	for _, ch := range tonie.Chapters {
+		if matched[ch.ID] {
+			remaining = append(remaining, ch.ID)
+			continue
+		}
+		plan.Actions = append(plan.Actions, Action{
+			Type:      ActionDelete,
+			Title:     ch.Title,
+			ChapterID: ch.ID,
+		})
+	}
+	plan.Actions = append(plan.Actions, creates...)
+
+	// Uploads are appended, so a reorder is needed whenever the desired
+	// order differs from "remaining chapters followed by new ones"
+	if !isNaturalOrder(plan.Order, remaining) {
+		plan.Actions = append(plan.Actions, Action{Type: ActionReorder})
+	}
+
+	if err := e.checkPolicy(tonie, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Apply executes plan against tonie and commits the result.
+// The plan must have been computed for the same tonie.
+func (e *Engine) Apply(tonie *toniebox.CreativeTonie, plan *Plan) error {
+	return e.ApplyContext(context.Background(), tonie, plan)
+}
+
+// ApplyContext is like Apply, but stops waiting for an upload window when
+// ctx is done. Chapters uploaded so far are not committed in that case.
+//
+// If the plan fails, the name and chapters of tonie are reset to their state
+// before ApplyContext, so that the tonie does not carry a half-applied plan
+// into the next Commit. A failed commit may still have been applied by the
+// API; call RefreshContext to find out.
+func (e *Engine) ApplyContext(ctx context.Context, tonie *toniebox.CreativeTonie, plan *Plan) error {
+	if plan.TonieID != tonie.ID {
+		return fmt.Errorf("plan was computed for tonie %s, not %s", plan.TonieID, tonie.ID)
+	}
+	if plan.Empty() {
+		return nil
+	}
+	// Check again, as other plans may have been applied since planning
+	if err := e.checkPolicy(tonie, plan); err != nil {
+		return err
+	}
+
+	name, chapters := tonie.Name, append([]toniebox.Chapter(nil), tonie.Chapters...)
+	abort := func(action Action, err error) error {
+		tonie.Name, tonie.Chapters = name, chapters
+		return e.fail(tonie, action, err)
+	}
+
+	var uploaded []string
+	for _, action := range plan.Actions {
+		switch action.Type {
+		case ActionRename:
+			tonie.Name = action.Title
+		case ActionDelete:
+			tonie.DeleteChapter(&toniebox.Chapter{ID: action.ChapterID})
+		case ActionCreate:
+			if err := e.Windows.Wait(ctx); err != nil {
+				return abort(action, fmt.Errorf("waiting for upload window: %w", err))
+			}
+			chapter, err := tonie.UploadFileWithContext(ctx, action.Title, action.File, toniebox.UploadOptions{})
+			if err != nil {
+				return abort(action, fmt.Errorf("failed to upload %q: %w", action.Title, err))
+			}
+			uploaded = append(uploaded, chapter.ID)
+		case ActionReorder:
+			order := make([]string, 0, len(plan.Order))
+			for _, s := range plan.Order {
+				switch {
+				case s.ChapterID != "":
+					order = append(order, s.ChapterID)
+				case s.Create >= 0 && s.Create < len(uploaded):
+					order = append(order, uploaded[s.Create])
+				default:
+					return abort(action, fmt.Errorf("order refers to upload %d, but the plan has %d", s.Create, len(uploaded)))
+				}
+			}
+			if err := tonie.ReorderChapters(order); err != nil {
+				return abort(action, fmt.Errorf("failed to reorder chapters: %w", err))
+			}
+		}
+		e.event(eventlog.LevelInfo, tonie, action, nil)
+	}
+
+	if err := tonie.CommitContext(ctx); err != nil {
+		return abort(Action{}, err)
+	}
+	e.event(eventlog.LevelInfo, tonie, Action{}, map[string]interface{}{"actions": len(plan.Actions)})
+
+	e.deletedMu.Lock()
+	e.deleted += plan.Count(ActionDelete)
+	e.deletedMu.Unlock()
+	return nil
+}
+
+// checkPolicy evaluates the plan's deletions and renames against the policy
+func (e *Engine) checkPolicy(tonie *toniebox.CreativeTonie, plan *Plan) error {
+	if e.Policy == nil {
+		return nil
+	}
+
+	var changes []policy.Change
+	for _, action := range plan.Actions {
+		switch action.Type {
+		case ActionDelete:
+			changes = append(changes, policy.Change{
+				Kind:         policy.ChangeDelete,
+				TonieID:      tonie.ID,
+				TonieName:    tonie.Name,
+				ChapterTitle: action.Title,
+			})
+		case ActionRename:
+			changes = append(changes, policy.Change{
+				Kind:      policy.ChangeRename,
+				TonieID:   tonie.ID,
+				TonieName: action.From,
+				NewName:   action.Title,
+			})
+		}
+	}
+
+	e.deletedMu.Lock()
+	deleted := e.deleted
+	e.deletedMu.Unlock()
+	return e.Policy.Check(changes, deleted)
+}
+
+// fail records a failed action in the event log and returns err
+func (e *Engine) fail(tonie *toniebox.CreativeTonie, action Action, err error) error {
+	e.event(eventlog.LevelError, tonie, action, map[string]interface{}{"error": err.Error()})
+	return err
+}
+
+// event records an applied action, or the final commit if action is empty,
+// if an event log is configured
+func (e *Engine) event(level eventlog.Level, tonie *toniebox.CreativeTonie, action Action, fields map[string]interface{}) {
+	if e.Events == nil {
+		return
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["tonie_id"] = tonie.ID
+	name := "commit"
+	if action.Type != "" {
+		name = string(action.Type)
+		if action.Title != "" {
+			fields["title"] = action.Title
+		}
+		if action.File != "" {
+			fields["file"] = action.File
+		}
+		if action.ChapterID != "" {
+			fields["chapter_id"] = action.ChapterID
+		}
+	}
+	e.Events.Log(eventlog.Entry{Level: level, Source: "apply", Event: name, Fields: fields})
+}
+
+// isNaturalOrder reports whether order equals the remaining chapters followed
+// by the created ones in creation order
+func isNaturalOrder(order []Slot, remaining []string) bool {
+	for i, s := range order {
+		if i < len(remaining) {
+			if s.ChapterID != remaining[i] {
+				return false
+			}
+		} else if s.ChapterID != "" || s.Create != i-len(remaining) {
+			return false
+		}
+	}
+	return true
+}
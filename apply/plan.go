@@ -0,0 +1,133 @@
+package apply
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ActionType identifies the kind of change an Action performs
+type ActionType string
+
+const (
+	// ActionRename renames the tonie
+	ActionRename ActionType = "rename"
+	// ActionCreate uploads a new chapter
+	ActionCreate ActionType = "create"
+	// ActionDelete removes an existing chapter
+	ActionDelete ActionType = "delete"
+	// ActionReorder rearranges the chapters into the desired order
+	ActionReorder ActionType = "reorder"
+)
+
+// Action is a single change within a Plan
+type Action struct {
+	Type ActionType `json:"type"`
+	// Title is the chapter title, or the new tonie name for ActionRename
+	Title string `json:"title,omitempty"`
+	// From is the previous tonie name for ActionRename
+	From string `json:"from,omitempty"`
+	// ChapterID identifies the affected chapter for ActionDelete
+	ChapterID string `json:"chapterId,omitempty"`
+	// File and Size describe the upload for ActionCreate
+	File string `json:"file,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// String returns a terraform-style, one-line description of the action
+func (a Action) String() string {
+	switch a.Type {
+	case ActionRename:
+		return fmt.Sprintf("~ rename %q -> %q", a.From, a.Title)
+	case ActionCreate:
+		return fmt.Sprintf("+ create chapter %q (%s)", a.Title, formatBytes(a.Size))
+	case ActionDelete:
+		return fmt.Sprintf("- delete chapter %q", a.Title)
+	case ActionReorder:
+		return "~ reorder chapters"
+	default:
+		return string(a.Type)
+	}
+}
+
+// Plan is the set of actions needed to reach the desired state of a tonie.
+// It can be stored as JSON for approval and applied after decoding it.
+type Plan struct {
+	TonieID   string   `json:"tonieId"`
+	TonieName string   `json:"tonieName"`
+	Actions   []Action `json:"actions"`
+	// UploadBytes is the total size of all files to upload
+	UploadBytes int64 `json:"uploadBytes"`
+	// UploadBandwidth is the bandwidth in bytes per second the upload time
+	// is estimated with
+	UploadBandwidth int64 `json:"uploadBandwidth,omitempty"`
+	// Order is the desired chapter order, applied by ActionReorder
+	Order []Slot `json:"order,omitempty"`
+}
+
+// Slot is a position in the desired chapter order: the existing chapter
+// ChapterID or, if ChapterID is empty, the chapter uploaded by the plan's
+// Create-th ActionCreate (counting from zero)
+type Slot struct {
+	ChapterID string `json:"chapterId,omitempty"`
+	Create    int    `json:"create,omitempty"`
+}
+
+// Empty reports whether the plan contains no actions
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// Count returns the number of actions of type t
+func (p *Plan) Count(t ActionType) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+// EstimatedUploadTime estimates how long the uploads of the plan will take
+func (p *Plan) EstimatedUploadTime() time.Duration {
+	if p.UploadBandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(float64(p.UploadBytes) / float64(p.UploadBandwidth) * float64(time.Second))
+}
+
+// String renders the plan in a human-readable, terraform-style format
+func (p *Plan) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Creative-Tonie %q:\n", p.TonieName)
+	if p.Empty() {
+		sb.WriteString("  No changes.\n")
+		return sb.String()
+	}
+	for _, a := range p.Actions {
+		fmt.Fprintf(&sb, "  %s\n", a)
+	}
+	fmt.Fprintf(&sb, "Plan: %d to create, %d to delete, %d to rename, %d to reorder.",
+		p.Count(ActionCreate), p.Count(ActionDelete), p.Count(ActionRename), p.Count(ActionReorder))
+	if p.UploadBytes > 0 {
+		fmt.Fprintf(&sb, " Estimated upload: %s (~%s).",
+			formatBytes(p.UploadBytes), p.EstimatedUploadTime().Round(time.Second))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// formatBytes formats a byte count for humans
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,125 @@
+package apply
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/tonieboxtest"
+)
+
+// newTonie returns a tonie with the given chapters, served by a fake API
+func newTonie(t *testing.T, titles ...string) (*tonieboxtest.Server, *toniebox.CreativeTonie) {
+	t.Helper()
+	tonie := toniebox.CreativeTonie{ID: "tonie-1", Name: "Stories"}
+	for i, title := range titles {
+		id := "chapter-" + string(rune('a'+i))
+		tonie.Chapters = append(tonie.Chapters, toniebox.Chapter{
+			ID:      id,
+			File:    "file-" + id,
+			Title:   title,
+			Seconds: 60,
+		})
+	}
+	srv := tonieboxtest.NewServer(&toniebox.State{Households: []toniebox.HouseholdState{{
+		Household: toniebox.Household{ID: "household-1", Name: "Home", Access: toniebox.AccessOwner},
+		Tonies:    []toniebox.CreativeTonie{tonie},
+	}}})
+
+	client := srv.Client()
+	households, err := client.GetHouseholds()
+	if err != nil {
+		t.Fatalf("GetHouseholds failed: %v", err)
+	}
+	tonies, err := client.GetCreativeTonies(&households[0])
+	if err != nil {
+		t.Fatalf("GetCreativeTonies failed: %v", err)
+	}
+	return srv, &tonies[0]
+}
+
+// TestPlanRoundTrip checks that a plan stored as JSON for approval applies
+// the same changes as the plan it was stored from
+func TestPlanRoundTrip(t *testing.T) {
+	srv, tonie := newTonie(t, "Intro", "Old story", "Outro")
+
+	dir := t.TempDir()
+	story, song := filepath.Join(dir, "story.mp3"), filepath.Join(dir, "song.mp3")
+	for _, file := range []string{story, song} {
+		if err := os.WriteFile(file, make([]byte, 4096), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spec := Spec{
+		Name: "Bedtime",
+		Chapters: []ChapterSpec{
+			{Title: "Song", File: song},
+			{Title: "Outro"},
+			{Title: "New story", File: story},
+			{Title: "Intro"},
+		},
+	}
+
+	engine := &Engine{}
+	plan, err := engine.Plan(tonie, spec)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	for typ, want := range map[ActionType]int{ActionRename: 1, ActionDelete: 1, ActionCreate: 2, ActionReorder: 1} {
+		if n := plan.Count(typ); n != want {
+			t.Errorf("plan has %d %s actions, want %d:\n%s", n, typ, want, plan)
+		}
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("failed to encode plan: %v", err)
+	}
+	var decoded Plan
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode plan: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, plan) {
+		t.Fatalf("decoded plan differs:\ngot  %+v\nwant %+v", decoded, *plan)
+	}
+
+	if err := engine.Apply(tonie, &decoded); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	state := srv.State()
+	got := state.Households[0].Tonies[0]
+	if got.Name != spec.Name {
+		t.Errorf("tonie is named %q, want %q", got.Name, spec.Name)
+	}
+	var titles []string
+	for _, ch := range got.Chapters {
+		titles = append(titles, ch.Title)
+	}
+	want := []string{"Song", "Outro", "New story", "Intro"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("tonie has chapters %q, want %q", titles, want)
+	}
+
+	// Applying the spec again must not change anything
+	again, err := (&Engine{}).Plan(tonie, spec)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if !again.Empty() {
+		t.Errorf("plan after apply is not empty:\n%s", again)
+	}
+}
+
+// TestApplyRejectsOtherTonie checks that a plan is not applied to a tonie
+// it was not computed for
+func TestApplyRejectsOtherTonie(t *testing.T) {
+	_, tonie := newTonie(t, "Intro")
+	plan := &Plan{TonieID: "tonie-2", Actions: []Action{{Type: ActionRename, Title: "Other"}}}
+	if err := (&Engine{}).Apply(tonie, plan); err == nil {
+		t.Fatal("Apply succeeded for a plan of another tonie")
+	}
+}
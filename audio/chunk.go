@@ -0,0 +1,184 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MP3Chunker splits a continuous MP3 stream into consecutive chunks of at
+// most a given duration. Chunks are cut on frame boundaries, so each one is
+// a playable MP3 file on its own, and are read on the fly: the stream is
+// never held in memory as a whole.
+//
+// A leading ID3v2 tag and the Xing/Info header frame, which describe the
+// whole stream, are dropped, as is anything between frames.
+//
+// Example:
+//
+//	chunker := audio.NewMP3Chunker(recording, 20*time.Minute)
+//	for {
+//	    chunk, err := chunker.Next()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    io.Copy(w, chunk)
+//	}
+type MP3Chunker struct {
+	br      *bufio.Reader
+	max     time.Duration
+	started bool
+	current *MP3Chunk
+	err     error
+}
+
+// NewMP3Chunker returns a chunker reading the MP3 stream from r. A max of
+// zero or less puts the whole stream into one chunk.
+func NewMP3Chunker(r io.Reader, max time.Duration) *MP3Chunker {
+	return &MP3Chunker{br: bufio.NewReaderSize(r, 64*1024), max: max}
+}
+
+// Next returns the next chunk, or io.EOF after the last one. The rest of
+// the previous chunk is skipped if it was not read to the end.
+// Returns an error wrapping ErrUnsupportedFormat if the stream contains no
+// MPEG audio frames.
+func (c *MP3Chunker) Next() (*MP3Chunk, error) {
+	if c.current != nil {
+		if _, err := io.Copy(io.Discard, c.current); err != nil {
+			return nil, err
+		}
+		c.current = nil
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	first := !c.started
+	frame, err := c.sync()
+	if err == io.EOF && first {
+		err = fmt.Errorf("no MPEG audio frames found: %w", ErrUnsupportedFormat)
+	}
+	if err != nil {
+		c.err = err
+		return nil, err
+	}
+
+	chunk := &MP3Chunk{chunker: c, sampleRate: frame.sampleRate, maxSamples: -1}
+	if c.max > 0 {
+		chunk.maxSamples = int64(c.max.Seconds() * float64(frame.sampleRate))
+	}
+	chunk.start(frame)
+	c.current = chunk
+	return chunk, nil
+}
+
+// sync skips to the header of the next frame without consuming it
+func (c *MP3Chunker) sync() (mp3Frame, error) {
+	if !c.started {
+		c.started = true
+		if head, err := c.br.Peek(10); err == nil && string(head[:3]) == "ID3" {
+			size := syncsafe(head[6:10]) + 10
+			if head[5]&0x10 != 0 {
+				size += 10 // footer
+			}
+			if _, err := c.br.Discard(size); err != nil {
+				return mp3Frame{}, fmt.Errorf("truncated ID3v2 tag: %w", err)
+			}
+		}
+		frame, err := c.sync()
+		if err != nil {
+			return frame, err
+		}
+		if data, err := c.br.Peek(frame.length); err == nil {
+			if _, ok := vbrFrameCount(frame, data); ok {
+				c.br.Discard(frame.length)
+				return c.sync()
+			}
+		}
+		return frame, nil
+	}
+
+	for {
+		header, err := c.br.Peek(4)
+		if err != nil {
+			// Also io.EOF for a few trailing bytes
+			return mp3Frame{}, err
+		}
+		if frame, ok := parseMP3Frame(header); ok {
+			return frame, nil
+		}
+		// Resynchronise on the next byte
+		if _, err := c.br.Discard(1); err != nil {
+			return mp3Frame{}, err
+		}
+	}
+}
+
+// MP3Chunk is one chunk of a stream split by MP3Chunker. It is only valid
+// until the next call to MP3Chunker.Next.
+type MP3Chunk struct {
+	chunker    *MP3Chunker
+	sampleRate int
+	maxSamples int64
+	samples    int64
+	// remaining counts the bytes left of the current frame
+	remaining int
+	done      bool
+}
+
+// start accounts for frame, whose header is at the start of the buffer
+func (ch *MP3Chunk) start(frame mp3Frame) {
+	ch.samples += int64(frame.samples)
+	ch.remaining = frame.length
+}
+
+// Read implements io.Reader
+func (ch *MP3Chunk) Read(p []byte) (int, error) {
+	c := ch.chunker
+	if ch.remaining == 0 {
+		if ch.done {
+			return 0, io.EOF
+		}
+		frame, err := c.sync()
+		if err != nil {
+			ch.done = true
+			c.err = err
+			if err == io.EOF {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		if ch.maxSamples >= 0 && ch.samples+int64(frame.samples) > ch.maxSamples {
+			// The frame starts the next chunk
+			ch.done = true
+			return 0, io.EOF
+		}
+		ch.start(frame)
+	}
+
+	if len(p) > ch.remaining {
+		p = p[:ch.remaining]
+	}
+	n, err := c.br.Read(p)
+	ch.remaining -= n
+	if err == io.EOF {
+		// The last frame is truncated
+		ch.remaining = 0
+		ch.done = true
+		c.err = io.EOF
+		if n > 0 {
+			err = nil
+		}
+	}
+	return n, err
+}
+
+// Duration returns the duration of the frames read from the chunk so far,
+// which is the duration of the chunk once it has been read to the end
+func (ch *MP3Chunk) Duration() time.Duration {
+	return samplesToDuration(ch.samples, ch.sampleRate)
+}
@@ -0,0 +1,242 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Converter normalizes audio files into a format accepted by the Toniecloud.
+// It uses ffmpeg when available and otherwise falls back to the pure-Go
+// NativeConverter, which rejects most inputs with ErrUnsupportedFormat.
+type Converter struct {
+	// Format is the target file extension used with ffmpeg, e.g. "mp3".
+	// Defaults to "mp3".
+	Format string
+	// FFmpeg is the ffmpeg executable; defaults to "ffmpeg" on $PATH
+	FFmpeg string
+}
+
+// Process implements Processor
+func (c *Converter) Process(ctx context.Context, src, dstDir string) (string, error) {
+	bin := c.FFmpeg
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return NativeConverter{}.Process(ctx, src, dstDir)
+	}
+
+	format := strings.TrimPrefix(c.Format, ".")
+	if format == "" {
+		format = "mp3"
+	}
+	dst := outputPath(src, dstDir, "."+format)
+	if err := runFFmpeg(ctx, bin, "-i", src, "-vn", dst); err != nil {
+		return "", fmt.Errorf("failed to convert %s: %w", src, err)
+	}
+	return dst, nil
+}
+
+// NativeConverter is the pure-Go fallback for environments without ffmpeg.
+// It has no compressed encoder, so the only conversion it can do without
+// growing the file is reducing WAV files with 24-bit, 32-bit or
+// floating-point samples to 16-bit PCM. Any other input, including FLAC and
+// WAV files that already use 8- or 16-bit samples, is rejected with an
+// error wrapping ErrUnsupportedFormat, so that the caller can upload the
+// original file instead. Encoding to compressed formats requires ffmpeg
+// (see Converter).
+type NativeConverter struct{}
+
+// Process implements Processor
+func (NativeConverter) Process(ctx context.Context, src, dstDir string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer in.Close()
+
+	var magic [4]byte
+	if _, err := io.ReadFull(in, magic[:]); err != nil {
+		return "", ErrUnsupportedFormat
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	if string(magic[:]) != "RIFF" {
+		return "", fmt.Errorf("%s: %w", filepath.Base(src), ErrUnsupportedFormat)
+	}
+
+	dst := outputPath(src, dstDir, ".wav")
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+
+	if err := convertWAV(ctx, in, out); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", fmt.Errorf("%s: %w", filepath.Base(src), err)
+	}
+	return dst, out.Close()
+}
+
+// pcmWriter writes interleaved 16-bit PCM samples into a WAV file and
+// patches the header sizes when finished
+type pcmWriter struct {
+	f        *os.File
+	w        *bufio.Writer
+	channels int
+	bytes    int64
+}
+
+// newPCMWriter writes a provisional WAV header and returns a writer for the samples
+func newPCMWriter(f *os.File, sampleRate, channels int) (*pcmWriter, error) {
+	pw := &pcmWriter{f: f, w: bufio.NewWriter(f), channels: channels}
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*channels*2))
+	binary.LittleEndian.PutUint16(header[32:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	_, err := pw.w.Write(header)
+	return pw, err
+}
+
+// writeSample writes a single sample normalised to [-1, 1]
+func (pw *pcmWriter) writeSample(v float64) error {
+	if v > 1 {
+		v = 1
+	} else if v < -1 {
+		v = -1
+	}
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], uint16(int16(math.Round(v*math.MaxInt16))))
+	pw.bytes += 2
+	_, err := pw.w.Write(b[:])
+	return err
+}
+
+// close flushes the samples and patches the RIFF and data chunk sizes
+func (pw *pcmWriter) close() error {
+	if err := pw.w.Flush(); err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(36+pw.bytes))
+	if _, err := pw.f.WriteAt(size[:], 4); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(size[:], uint32(pw.bytes))
+	_, err := pw.f.WriteAt(size[:], 40)
+	return err
+}
+
+// convertWAV converts a PCM or IEEE float WAV stream with samples wider than
+// 16 bits to 16-bit PCM
+func convertWAV(ctx context.Context, in io.Reader, out *os.File) error {
+	r := bufio.NewReader(in)
+	if _, err := io.CopyN(io.Discard, r, 12); err != nil {
+		return err
+	}
+
+	var format, channels, bits int
+	var sampleRate int
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("missing WAV data chunk: %w", ErrUnsupportedFormat)
+		}
+		id := string(header[:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+
+		if id == "fmt " {
+			chunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, chunk); err != nil || size < 16 {
+				return fmt.Errorf("truncated WAV fmt chunk: %w", ErrUnsupportedFormat)
+			}
+			format = int(binary.LittleEndian.Uint16(chunk[0:]))
+			channels = int(binary.LittleEndian.Uint16(chunk[2:]))
+			sampleRate = int(binary.LittleEndian.Uint32(chunk[4:]))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:]))
+			// WAVE_FORMAT_EXTENSIBLE stores the real format in the sub-format GUID
+			if format == 0xfffe && size >= 26 {
+				format = int(binary.LittleEndian.Uint16(chunk[24:]))
+			}
+			continue
+		}
+		if id != "data" {
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if channels == 0 || (format != 1 && format != 3) {
+			return fmt.Errorf("WAV format %d: %w", format, ErrUnsupportedFormat)
+		}
+		if format == 1 && bits <= 16 {
+			return fmt.Errorf("WAV is already %d-bit PCM: %w", bits, ErrUnsupportedFormat)
+		}
+		decode, err := sampleDecoder(format, bits)
+		if err != nil {
+			return err
+		}
+
+		pw, err := newPCMWriter(out, sampleRate, channels)
+		if err != nil {
+			return err
+		}
+		width := bits / 8
+		buf := make([]byte, width)
+		for n := int64(0); n+int64(width) <= size; n += int64(width) {
+			if n%(1<<20) == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if _, err := io.ReadFull(r, buf); err != nil {
+				if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			if err := pw.writeSample(decode(buf)); err != nil {
+				return err
+			}
+		}
+		return pw.close()
+	}
+}
+
+// sampleDecoder returns a function decoding one little-endian sample to [-1, 1]
+func sampleDecoder(format, bits int) (func([]byte) float64, error) {
+	switch {
+	case format == 1 && bits == 24:
+		return func(b []byte) float64 {
+			v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+			return float64(v) / (1 << 23)
+		}, nil
+	case format == 1 && bits == 32:
+		return func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }, nil
+	case format == 3 && bits == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }, nil
+	case format == 3 && bits == 64:
+		return func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }, nil
+	default:
+		return nil, fmt.Errorf("%d-bit WAV samples: %w", bits, ErrUnsupportedFormat)
+	}
+}
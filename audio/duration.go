@@ -0,0 +1,347 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrUnsupportedFormat is returned when a file's format is not recognised
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// NativeProber determines durations of MP3, Ogg (Vorbis/Opus), FLAC and WAV
+// files in pure Go without external tools
+type NativeProber struct{}
+
+// Duration implements Prober
+func (NativeProber) Duration(path string) (time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	return DurationOf(f)
+}
+
+// ChainProber tries several probers in order and returns the first success
+type ChainProber []Prober
+
+// Duration implements Prober
+func (cp ChainProber) Duration(path string) (time.Duration, error) {
+	var errs []error
+	for _, p := range cp {
+		d, err := p.Duration(path)
+		if err == nil {
+			return d, nil
+		}
+		errs = append(errs, err)
+	}
+	return 0, errors.Join(errs...)
+}
+
+// DurationOf determines the duration of the audio stream in r
+func DurationOf(r io.ReadSeeker) (time.Duration, error) {
+	var magic [12]byte
+	n, _ := io.ReadFull(r, magic[:])
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case n >= 4 && string(magic[:4]) == "OggS":
+		return oggDuration(r)
+	case n >= 4 && string(magic[:4]) == "fLaC":
+		return flacDuration(r)
+	case n >= 12 && string(magic[:4]) == "RIFF" && string(magic[8:12]) == "WAVE":
+		return wavDuration(r)
+	case n >= 3 && string(magic[:3]) == "ID3", n >= 2 && magic[0] == 0xff && magic[1]&0xe0 == 0xe0:
+		return mp3Duration(r)
+	default:
+		return 0, ErrUnsupportedFormat
+	}
+}
+
+// mp3Bitrates holds bitrates in kbit/s indexed by [version is MPEG1][layer-1][index]
+var mp3Bitrates = [2][3][16]int{
+	{ // MPEG2/2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+	{ // MPEG1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+}
+
+// mp3SampleRates holds sample rates indexed by [version bits][index]
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},  // MPEG2.5
+	{0, 0, 0},             // reserved
+	{22050, 24000, 16000}, // MPEG2
+	{44100, 48000, 32000}, // MPEG1
+}
+
+// mp3Frame is a decoded MPEG audio frame header
+type mp3Frame struct {
+	mpeg1      bool
+	layer      int
+	sampleRate int
+	samples    int
+	length     int
+	mono       bool
+}
+
+// parseMP3Frame decodes a 4-byte MPEG audio frame header
+func parseMP3Frame(h []byte) (mp3Frame, bool) {
+	if h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return mp3Frame{}, false
+	}
+	versionBits := (h[1] >> 3) & 0x03
+	layerBits := (h[1] >> 1) & 0x03
+	bitrateIndex := h[2] >> 4
+	rateIndex := (h[2] >> 2) & 0x03
+	padding := int((h[2] >> 1) & 0x01)
+	if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	f := mp3Frame{
+		mpeg1:      versionBits == 3,
+		layer:      4 - int(layerBits),
+		sampleRate: mp3SampleRates[versionBits][rateIndex],
+		mono:       h[3]>>6 == 3,
+	}
+	version := 0
+	if f.mpeg1 {
+		version = 1
+	}
+	bitrate := mp3Bitrates[version][f.layer-1][bitrateIndex] * 1000
+
+	switch {
+	case f.layer == 1:
+		f.samples = 384
+		f.length = (12*bitrate/f.sampleRate + padding) * 4
+	case f.layer == 3 && !f.mpeg1:
+		f.samples = 576
+		f.length = 72*bitrate/f.sampleRate + padding
+	default:
+		f.samples = 1152
+		f.length = 144*bitrate/f.sampleRate + padding
+	}
+	return f, f.length > 4
+}
+
+// mp3Duration sums the duration of all MPEG audio frames, using a Xing/Info
+// or VBRI header when present
+func mp3Duration(r io.Reader) (time.Duration, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	// Skip an ID3v2 tag
+	if head, err := br.Peek(10); err == nil && string(head[:3]) == "ID3" {
+		size := syncsafe(head[6:10]) + 10
+		if head[5]&0x10 != 0 {
+			size += 10 // footer
+		}
+		if _, err := br.Discard(size); err != nil {
+			return 0, fmt.Errorf("truncated ID3v2 tag: %w", err)
+		}
+	}
+
+	var samples, frames int64
+	sampleRate := 0
+	first := true
+	for {
+		header, err := br.Peek(4)
+		if err != nil {
+			break
+		}
+		frame, ok := parseMP3Frame(header)
+		if !ok {
+			// Resynchronise on the next byte
+			if _, err := br.Discard(1); err != nil {
+				break
+			}
+			continue
+		}
+
+		if first {
+			first = false
+			sampleRate = frame.sampleRate
+			if data, err := br.Peek(frame.length); err == nil {
+				if n, ok := vbrFrameCount(frame, data); ok {
+					return samplesToDuration(int64(n)*int64(frame.samples), sampleRate), nil
+				}
+			}
+		}
+
+		samples += int64(frame.samples)
+		frames++
+		if _, err := br.Discard(frame.length); err != nil {
+			break
+		}
+	}
+
+	if frames == 0 {
+		return 0, fmt.Errorf("no MPEG audio frames found: %w", ErrUnsupportedFormat)
+	}
+	return samplesToDuration(samples, sampleRate), nil
+}
+
+// vbrFrameCount reads the frame count from a Xing/Info or VBRI header
+// stored in the first frame
+func vbrFrameCount(f mp3Frame, data []byte) (int, bool) {
+	// The Xing header follows the side information
+	offset := 4
+	switch {
+	case f.mpeg1 && !f.mono:
+		offset += 32
+	case f.mpeg1 && f.mono, !f.mpeg1 && !f.mono:
+		offset += 17
+	default:
+		offset += 9
+	}
+	if len(data) >= offset+12 {
+		tag := string(data[offset : offset+4])
+		if tag == "Xing" || tag == "Info" {
+			flags := binary.BigEndian.Uint32(data[offset+4:])
+			if flags&0x01 != 0 {
+				return int(binary.BigEndian.Uint32(data[offset+8:])), true
+			}
+		}
+	}
+
+	// The VBRI header is always 32 bytes after the frame header
+	if len(data) >= 36+18 && string(data[36:40]) == "VBRI" {
+		return int(binary.BigEndian.Uint32(data[36+14:])), true
+	}
+	return 0, false
+}
+
+// oggDuration reads the codec sample rate from the first page and the final
+// granule position from the last page of an Ogg stream
+func oggDuration(r io.ReadSeeker) (time.Duration, error) {
+	// The identification header is contained in the first page
+	head := make([]byte, 28+255)
+	n, _ := io.ReadFull(r, head)
+	head = head[:n]
+	if len(head) < 27 {
+		return 0, ErrUnsupportedFormat
+	}
+	packet := head[27+int(head[26]):]
+
+	var sampleRate int
+	var preSkip int64
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")) && len(packet) >= 16:
+		sampleRate = int(binary.LittleEndian.Uint32(packet[12:16]))
+	case bytes.HasPrefix(packet, []byte("OpusHead")) && len(packet) >= 12:
+		// Opus granule positions always count 48 kHz samples
+		sampleRate = 48000
+		preSkip = int64(binary.LittleEndian.Uint16(packet[10:12]))
+	default:
+		return 0, fmt.Errorf("unsupported Ogg codec: %w", ErrUnsupportedFormat)
+	}
+	if sampleRate == 0 {
+		return 0, ErrUnsupportedFormat
+	}
+
+	// Search backwards for the last page header
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	const chunk = 64 * 1024
+	for end := size; end > 0; end -= chunk - 27 {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		buf := make([]byte, end-start)
+		if _, err := r.Seek(start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndex(buf, []byte("OggS")); i >= 0 && len(buf)-i >= 14 {
+			granule := int64(binary.LittleEndian.Uint64(buf[i+6 : i+14]))
+			return samplesToDuration(granule-preSkip, sampleRate), nil
+		}
+		if start == 0 {
+			break
+		}
+	}
+	return 0, fmt.Errorf("no Ogg pages found: %w", ErrUnsupportedFormat)
+}
+
+// flacDuration reads the total sample count from the STREAMINFO block
+func flacDuration(r io.Reader) (time.Duration, error) {
+	// "fLaC" + block header + STREAMINFO (34 bytes)
+	var buf [4 + 4 + 34]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, fmt.Errorf("truncated FLAC header: %w", err)
+	}
+	if buf[4]&0x7f != 0 {
+		return 0, fmt.Errorf("missing FLAC STREAMINFO: %w", ErrUnsupportedFormat)
+	}
+	info := buf[8:]
+	sampleRate := int(info[10])<<12 | int(info[11])<<4 | int(info[12])>>4
+	totalSamples := int64(info[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(info[14:18]))
+	if sampleRate == 0 {
+		return 0, ErrUnsupportedFormat
+	}
+	return samplesToDuration(totalSamples, sampleRate), nil
+}
+
+// wavDuration divides the size of the data chunk by the byte rate
+func wavDuration(r io.Reader) (time.Duration, error) {
+	if _, err := io.CopyN(io.Discard, r, 12); err != nil {
+		return 0, err
+	}
+
+	byteRate := 0
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return 0, fmt.Errorf("missing WAV data chunk: %w", ErrUnsupportedFormat)
+		}
+		id := string(header[:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+
+		switch id {
+		case "fmt ":
+			fmtChunk := make([]byte, size)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil || size < 12 {
+				return 0, fmt.Errorf("truncated WAV fmt chunk: %w", ErrUnsupportedFormat)
+			}
+			byteRate = int(binary.LittleEndian.Uint32(fmtChunk[8:12]))
+		case "data":
+			if byteRate == 0 {
+				return 0, fmt.Errorf("WAV data before fmt chunk: %w", ErrUnsupportedFormat)
+			}
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second)), nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return 0, err
+			}
+		}
+		if id == "fmt " && size%2 == 1 {
+			io.CopyN(io.Discard, r, 1)
+		}
+	}
+}
+
+// samplesToDuration converts a sample count at sampleRate to a duration
+func samplesToDuration(samples int64, sampleRate int) time.Duration {
+	if samples < 0 || sampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+}
@@ -0,0 +1,191 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PCMFormat describes raw PCM audio without a container, such as the output
+// of "arecord -t raw" or "parec"
+type PCMFormat struct {
+	// SampleRate in Hz, e.g. 44100
+	SampleRate int
+	// Channels is 1 for mono, 2 for stereo
+	Channels int
+	// BitDepth is the size of a sample: 8 (unsigned), 16, 24 or 32 (signed
+	// little-endian integers)
+	BitDepth int
+}
+
+// validate checks that the format can be encoded
+func (f PCMFormat) validate() error {
+	if f.SampleRate <= 0 || f.Channels <= 0 {
+		return fmt.Errorf("invalid PCM format: %d Hz, %d channels", f.SampleRate, f.Channels)
+	}
+	switch f.BitDepth {
+	case 8, 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("%d-bit PCM samples: %w", f.BitDepth, ErrUnsupportedFormat)
+}
+
+// ffmpegFormat returns the name of the format for ffmpeg's -f option
+func (f PCMFormat) ffmpegFormat() string {
+	if f.BitDepth == 8 {
+		return "u8"
+	}
+	return "s" + strconv.Itoa(f.BitDepth) + "le"
+}
+
+// StreamEncoder encodes a live audio stream, e.g. a recording piped from a
+// microphone, to MP3 while it is being read. It uses ffmpeg; without ffmpeg,
+// raw PCM is buffered and wrapped into a WAV file and any other input is
+// passed through unchanged.
+//
+// Example:
+//
+//	enc := &audio.StreamEncoder{PCM: &audio.PCMFormat{SampleRate: 44100, Channels: 2, BitDepth: 16}}
+//	mp3, err := enc.Encode(ctx, os.Stdin)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer mp3.Close()
+//	err = tonie.UploadReader("Good Night", mp3)
+type StreamEncoder struct {
+	// PCM describes the input if it is raw PCM. If nil, the format is
+	// detected from the stream (WAV, MP3, FLAC, ...).
+	PCM *PCMFormat
+	// Bitrate of the MP3, e.g. "96k". Defaults to "128k".
+	Bitrate string
+	// FFmpeg is the ffmpeg executable; defaults to "ffmpeg" on $PATH
+	FFmpeg string
+}
+
+// Encode starts encoding r and returns the encoded stream. Closing it stops
+// the encoder; read errors include the messages of ffmpeg.
+func (e *StreamEncoder) Encode(ctx context.Context, r io.Reader) (io.ReadCloser, error) {
+	if e.PCM != nil {
+		if err := e.PCM.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	bin := e.FFmpeg
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		if e.PCM == nil {
+			return io.NopCloser(r), nil
+		}
+		wav, err := EncodeWAV(r, *e.PCM)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(wav), nil
+	}
+
+	bitrate := e.Bitrate
+	if bitrate == "" {
+		bitrate = "128k"
+	}
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	if e.PCM != nil {
+		args = append(args,
+			"-f", e.PCM.ffmpegFormat(),
+			"-ar", strconv.Itoa(e.PCM.SampleRate),
+			"-ac", strconv.Itoa(e.PCM.Channels))
+	}
+	args = append(args, "-i", "pipe:0", "-vn", "-f", "mp3", "-b:a", bitrate, "pipe:1")
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = r
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	return &encoderOutput{ReadCloser: stdout, cmd: cmd, stderr: stderr}, nil
+}
+
+// encoderOutput is the output of a running ffmpeg process
+type encoderOutput struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	waited bool
+	err    error
+}
+
+// Read implements io.Reader and reports a failure of ffmpeg at the end of
+// the stream
+func (o *encoderOutput) Read(p []byte) (int, error) {
+	n, err := o.ReadCloser.Read(p)
+	if err == io.EOF {
+		if werr := o.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close stops ffmpeg if it is still running
+func (o *encoderOutput) Close() error {
+	o.ReadCloser.Close()
+	if !o.waited && o.cmd.Process != nil {
+		o.cmd.Process.Kill()
+	}
+	o.wait()
+	return nil
+}
+
+// wait waits for ffmpeg to exit and returns its error
+func (o *encoderOutput) wait() error {
+	if !o.waited {
+		o.waited = true
+		if err := o.cmd.Wait(); err != nil {
+			o.err = fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(o.stderr.String()))
+		}
+	}
+	return o.err
+}
+
+// EncodeWAV reads raw PCM audio from r until EOF and returns it as a WAV
+// file. The audio is buffered in memory, as the WAV header needs its size.
+func EncodeWAV(r io.Reader, format PCMFormat) (io.Reader, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if _, err := data.ReadFrom(r); err != nil {
+		return nil, fmt.Errorf("failed to read PCM audio: %w", err)
+	}
+	// Drop an incomplete trailing sample frame
+	frameSize := format.Channels * format.BitDepth / 8
+	data.Truncate(data.Len() - data.Len()%frameSize)
+
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+data.Len()))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[22:], uint16(format.Channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(format.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(format.SampleRate*frameSize))
+	binary.LittleEndian.PutUint16(header[32:], uint16(frameSize))
+	binary.LittleEndian.PutUint16(header[34:], uint16(format.BitDepth))
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(data.Len()))
+	return io.MultiReader(bytes.NewReader(header), &data), nil
+}
@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// contentTypes maps audio file extensions to their MIME types. The system
+// MIME tables often lack audio types, so they are not consulted.
+var contentTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/ogg",
+	".flac": "audio/flac",
+	".wav":  "audio/wav",
+	".wma":  "audio/x-ms-wma",
+	".aiff": "audio/aiff",
+}
+
+// DetectContentType returns the MIME type of the audio data starting with
+// head, which should hold at least the first 12 bytes. If the data is not
+// recognised, the type is derived from the extension of name, falling back
+// to "application/octet-stream".
+func DetectContentType(head []byte, name string) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "audio/mpeg"
+	case len(head) >= 2 && head[0] == 0xff && head[1]&0xf6 == 0xf0:
+		// ADTS AAC has a layer of zero, which is reserved in MPEG audio
+		return "audio/aac"
+	case len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0:
+		return "audio/mpeg"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return "audio/ogg"
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return "audio/flac"
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return "audio/wav"
+	case len(head) >= 12 && string(head[:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return "audio/aiff"
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return "audio/mp4"
+	}
+	if contentType, ok := contentTypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Prober determines the playback duration of audio files.
+// Implementations must be safe for concurrent use.
+type Prober interface {
+	Duration(path string) (time.Duration, error)
+}
+
+// ProberFunc adapts an ordinary function to the Prober interface
+type ProberFunc func(path string) (time.Duration, error)
+
+// Duration implements Prober
+func (f ProberFunc) Duration(path string) (time.Duration, error) {
+	return f(path)
+}
+
+// DefaultProber is the prober used when none is specified. It determines
+// durations in pure Go and only falls back to ffprobe for other formats.
+var DefaultProber Prober = ChainProber{NativeProber{}, &FFProbe{}}
+
+// FFProbe determines durations by running the ffprobe command-line tool
+type FFProbe struct {
+	// Path is the ffprobe executable; defaults to "ffprobe" on $PATH
+	Path string
+	// Timeout bounds each ffprobe invocation; defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// ffprobeOutput is the subset of ffprobe's JSON output that is used
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+// Duration implements Prober
+func (fp *FFProbe) Duration(path string) (time.Duration, error) {
+	bin := fp.Path
+	if bin == "" {
+		bin = "ffprobe"
+	}
+	timeout := fp.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, bin, "-v", "error", "-show_entries", "format=duration", "-of", "json", path).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed for %s: %w", path, err)
+	}
+
+	var result ffprobeOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		return 0, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+	seconds, err := strconv.ParseFloat(result.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe reported no duration for %s", path)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Processor transforms an audio file before it is uploaded
+type Processor interface {
+	// Process reads the file at src, writes the result to a new file inside
+	// dstDir and returns the path of that file
+	Process(ctx context.Context, src, dstDir string) (string, error)
+}
+
+// Process runs src through all processors in order. The returned cleanup
+// function removes all intermediate files and must be called once the
+// result is no longer needed. Without processors, src is returned unchanged.
+//
+// Example:
+//
+//	path, cleanup, err := audio.Process(ctx, "story.mp3", &audio.SilenceTrimmer{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer cleanup()
+func Process(ctx context.Context, src string, processors ...Processor) (string, func(), error) {
+	noop := func() {}
+	if len(processors) == 0 {
+		return src, noop, nil
+	}
+
+	dir, err := os.MkdirTemp("", "toniebox-audio-")
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	path := src
+	for i, p := range processors {
+		stageDir := filepath.Join(dir, fmt.Sprintf("%02d", i))
+		if err := os.Mkdir(stageDir, 0o700); err != nil {
+			cleanup()
+			return "", noop, fmt.Errorf("failed to create stage dir: %w", err)
+		}
+		path, err = p.Process(ctx, path, stageDir)
+		if err != nil {
+			cleanup()
+			return "", noop, err
+		}
+	}
+	return path, cleanup, nil
+}
+
+// runFFmpeg runs ffmpeg (or the given binary) with args
+func runFFmpeg(ctx context.Context, bin string, args ...string) error {
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	args = append([]string{"-hide_banner", "-loglevel", "error", "-y"}, args...)
+	out, err := exec.CommandContext(ctx, bin, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// outputPath returns the path of the processed copy of src inside dstDir
+func outputPath(src, dstDir, ext string) string {
+	base := filepath.Base(src)
+	if ext == "" {
+		ext = filepath.Ext(base)
+	}
+	return filepath.Join(dstDir, strings.TrimSuffix(base, filepath.Ext(base))+ext)
+}
@@ -0,0 +1,48 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SilenceTrimmer shortens long leading and trailing silences using ffmpeg,
+// reclaiming tonie capacity from sloppily edited recordings. Only silence is
+// removed: trimming stops at the first sample above the threshold, however
+// short the sound is.
+type SilenceTrimmer struct {
+	// Threshold is the level in dBFS below which audio counts as silence.
+	// Defaults to -50.
+	Threshold float64
+	// MinDuration is the length of leading/trailing silence that is kept.
+	// Longer silences are cut down to it; shorter pauses are left as they
+	// are. Defaults to one second.
+	MinDuration time.Duration
+	// FFmpeg is the ffmpeg executable; defaults to "ffmpeg" on $PATH
+	FFmpeg string
+}
+
+// Process implements Processor
+func (st *SilenceTrimmer) Process(ctx context.Context, src, dstDir string) (string, error) {
+	threshold := st.Threshold
+	if threshold == 0 {
+		threshold = -50
+	}
+	minDuration := st.MinDuration
+	if minDuration <= 0 {
+		minDuration = time.Second
+	}
+
+	// Trim the start, reverse, trim the (former) end and reverse back.
+	// start_silence keeps up to minDuration of the removed silence; the
+	// default start_duration of 0 ends the trim at the first sound.
+	trim := fmt.Sprintf("silenceremove=start_periods=1:start_threshold=%.1fdB:start_silence=%.3f",
+		threshold, minDuration.Seconds())
+	filter := trim + ",areverse," + trim + ",areverse"
+
+	dst := outputPath(src, dstDir, "")
+	if err := runFFmpeg(ctx, st.FFmpeg, "-i", src, "-af", filter, dst); err != nil {
+		return "", fmt.Errorf("failed to trim silence of %s: %w", src, err)
+	}
+	return dst, nil
+}
@@ -0,0 +1,276 @@
+// Package audio contains helpers for inspecting local audio files before
+// they are uploaded to a Creative-Tonie.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Tags holds the metadata tags relevant for uploads
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+	// TrackNumber is the track number within the album, or 0 if unknown
+	TrackNumber int
+	// DiscNumber is the disc number within a multi-disc album, or 0 if unknown
+	DiscNumber int
+}
+
+// ErrNoTags is returned when a file does not contain any supported tags
+var ErrNoTags = errors.New("no supported tags found")
+
+// ReadTags reads metadata tags from the audio file at path.
+// ID3v2, ID3v1 (MP3) and Vorbis comments (FLAC) are supported.
+func ReadTags(path string) (Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Tags{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	return ReadTagsFrom(f)
+}
+
+// ReadTagsFrom reads metadata tags from r. See ReadTags for the supported formats.
+func ReadTagsFrom(r io.ReadSeeker) (Tags, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return Tags{}, ErrNoTags
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Tags{}, err
+	}
+
+	switch {
+	case bytes.Equal(magic[:3], []byte("ID3")):
+		return readID3v2(r)
+	case bytes.Equal(magic[:], []byte("fLaC")):
+		return readFLAC(r)
+	default:
+		return readID3v1(r)
+	}
+}
+
+// readID3v2 parses an ID3v2.3/2.4 tag at the start of r
+func readID3v2(r io.Reader) (Tags, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Tags{}, ErrNoTags
+	}
+	version := header[3]
+	if version < 3 || version > 4 {
+		return Tags{}, fmt.Errorf("unsupported ID3v2 version 2.%d", version)
+	}
+
+	body := make([]byte, syncsafe(header[6:10]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Tags{}, fmt.Errorf("truncated ID3v2 tag: %w", err)
+	}
+
+	// Skip the extended header
+	if header[5]&0x40 != 0 && len(body) >= 4 {
+		size := int(binary.BigEndian.Uint32(body[:4]))
+		if version == 4 {
+			size = syncsafe(body[:4])
+		} else {
+			size += 4
+		}
+		if size > len(body) {
+			return Tags{}, ErrNoTags
+		}
+		body = body[size:]
+	}
+
+	var tags Tags
+	for len(body) >= 10 && body[0] != 0 {
+		id := string(body[:4])
+		size := int(binary.BigEndian.Uint32(body[4:8]))
+		if version == 4 {
+			size = syncsafe(body[4:8])
+		}
+		if size < 0 || 10+size > len(body) {
+			break
+		}
+		frame := body[10 : 10+size]
+		body = body[10+size:]
+
+		switch id {
+		case "TIT2":
+			tags.Title = decodeID3Text(frame)
+		case "TPE1":
+			tags.Artist = decodeID3Text(frame)
+		case "TALB":
+			tags.Album = decodeID3Text(frame)
+		case "TRCK":
+			tags.TrackNumber = parseNumber(decodeID3Text(frame))
+		case "TPOS":
+			tags.DiscNumber = parseNumber(decodeID3Text(frame))
+		}
+	}
+	return tags, nil
+}
+
+// readID3v1 parses an ID3v1 tag from the last 128 bytes of r
+func readID3v1(r io.ReadSeeker) (Tags, error) {
+	if _, err := r.Seek(-128, io.SeekEnd); err != nil {
+		return Tags{}, ErrNoTags
+	}
+	var tag [128]byte
+	if _, err := io.ReadFull(r, tag[:]); err != nil || string(tag[:3]) != "TAG" {
+		return Tags{}, ErrNoTags
+	}
+
+	tags := Tags{
+		Title:  trimID3v1(tag[3:33]),
+		Artist: trimID3v1(tag[33:63]),
+		Album:  trimID3v1(tag[63:93]),
+	}
+	// ID3v1.1 stores the track number in the last byte of the comment
+	if tag[125] == 0 && tag[126] != 0 {
+		tags.TrackNumber = int(tag[126])
+	}
+	return tags, nil
+}
+
+// readFLAC parses the VORBIS_COMMENT metadata block of a FLAC stream
+func readFLAC(r io.Reader) (Tags, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return Tags{}, ErrNoTags
+	}
+
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return Tags{}, ErrNoTags
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		block := make([]byte, size)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return Tags{}, fmt.Errorf("truncated FLAC metadata: %w", err)
+		}
+
+		// Block type 4 is VORBIS_COMMENT
+		if blockType == 4 {
+			return ParseVorbisComment(block)
+		}
+		if last {
+			return Tags{}, ErrNoTags
+		}
+	}
+}
+
+// ParseVorbisComment parses a Vorbis comment structure (as found in FLAC and
+// Ogg streams, without the Ogg packet type prefix)
+func ParseVorbisComment(data []byte) (Tags, error) {
+	readLen := func() (int, bool) {
+		if len(data) < 4 {
+			return 0, false
+		}
+		n := int(binary.LittleEndian.Uint32(data[:4]))
+		data = data[4:]
+		return n, n >= 0 && n <= len(data)
+	}
+
+	vendorLen, ok := readLen()
+	if !ok {
+		return Tags{}, ErrNoTags
+	}
+	data = data[vendorLen:]
+
+	if len(data) < 4 {
+		return Tags{}, ErrNoTags
+	}
+	count := int(binary.LittleEndian.Uint32(data[:4]))
+	data = data[4:]
+
+	var tags Tags
+	for i := 0; i < count; i++ {
+		n, ok := readLen()
+		if !ok {
+			break
+		}
+		key, value, found := strings.Cut(string(data[:n]), "=")
+		data = data[n:]
+		if !found {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			tags.Title = value
+		case "ARTIST":
+			tags.Artist = value
+		case "ALBUM":
+			tags.Album = value
+		case "TRACKNUMBER":
+			tags.TrackNumber = parseNumber(value)
+		case "DISCNUMBER":
+			tags.DiscNumber = parseNumber(value)
+		}
+	}
+	return tags, nil
+}
+
+// syncsafe decodes a 28-bit synchsafe integer
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// decodeID3Text decodes the payload of an ID3v2 text frame
+func decodeID3Text(frame []byte) string {
+	if len(frame) == 0 {
+		return ""
+	}
+	encoding, text := frame[0], frame[1:]
+	switch encoding {
+	case 1, 2:
+		// UTF-16 with BOM (1) or UTF-16BE without BOM (2)
+		order := binary.ByteOrder(binary.BigEndian)
+		if encoding == 1 && len(text) >= 2 {
+			if text[0] == 0xff && text[1] == 0xfe {
+				order = binary.LittleEndian
+			}
+			text = text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			units = append(units, order.Uint16(text[i:]))
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 0:
+		// ISO-8859-1
+		runes := make([]rune, 0, len(text))
+		for _, b := range text {
+			runes = append(runes, rune(b))
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	default:
+		return strings.TrimRight(string(text), "\x00")
+	}
+}
+
+// trimID3v1 trims padding from a fixed-size ID3v1 field
+func trimID3v1(b []byte) string {
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+// parseNumber parses numbers such as "3" or "3/12"
+func parseNumber(s string) int {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
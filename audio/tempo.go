@@ -0,0 +1,50 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TempoAdjuster time-stretches audio without changing its pitch using
+// ffmpeg, e.g. by 1.1x for long audiobooks so more content fits on a tonie
+type TempoAdjuster struct {
+	// Factor is the playback speed; values above 1 shorten the audio.
+	// Must be between 0.5 and 4.
+	Factor float64
+	// FFmpeg is the ffmpeg executable; defaults to "ffmpeg" on $PATH
+	FFmpeg string
+}
+
+// Process implements Processor
+func (ta *TempoAdjuster) Process(ctx context.Context, src, dstDir string) (string, error) {
+	if ta.Factor < 0.5 || ta.Factor > 4 {
+		return "", fmt.Errorf("tempo factor %.2f out of range [0.5, 4]", ta.Factor)
+	}
+
+	dst := outputPath(src, dstDir, "")
+	if err := runFFmpeg(ctx, ta.FFmpeg, "-i", src, "-af", atempoFilter(ta.Factor), dst); err != nil {
+		return "", fmt.Errorf("failed to adjust tempo of %s: %w", src, err)
+	}
+	return dst, nil
+}
+
+// ScaleDuration returns the duration of audio of length seconds after processing
+func (ta *TempoAdjuster) ScaleDuration(seconds float64) float64 {
+	if ta.Factor <= 0 {
+		return seconds
+	}
+	return seconds / ta.Factor
+}
+
+// atempoFilter builds an atempo filter chain; older ffmpeg versions only
+// accept factors up to 2 per atempo instance
+func atempoFilter(factor float64) string {
+	var stages []string
+	for factor > 2 {
+		stages = append(stages, "atempo=2.0")
+		factor /= 2
+	}
+	stages = append(stages, fmt.Sprintf("atempo=%.4f", factor))
+	return strings.Join(stages, ",")
+}
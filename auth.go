@@ -0,0 +1,62 @@
+package toniebox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// authState is the access token together with its prepared header value
+type authState struct {
+	token *JWTToken
+	// header is the value of the Authorization header. It is shared by all
+	// requests and never modified: its capacity equals its length, so an
+	// append on a request's header copies it.
+	header []string
+	// expiresAt is when the token expires; zero if the lifetime is unknown
+	expiresAt time.Time
+}
+
+// setToken stores token for subsequent requests; nil logs out
+func (rh *requestHandler) setToken(token *JWTToken) {
+	rh.storeToken(token)
+	rh.resetVerification()
+}
+
+// storeToken stores token without resetting the account state, e.g. when
+// the same session is renewed
+func (rh *requestHandler) storeToken(token *JWTToken) {
+	if token == nil {
+		rh.auth.Store(nil)
+		return
+	}
+	header := "Bearer " + token.AccessToken
+	state := &authState{token: token, header: []string{header}[:1:1]}
+	if token.ExpiresIn > 0 {
+		state.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	rh.auth.Store(state)
+}
+
+// token returns the current access token, or nil if not logged in
+func (rh *requestHandler) token() *JWTToken {
+	if state := rh.auth.Load(); state != nil {
+		return state.token
+	}
+	return nil
+}
+
+// newAuthenticatedRequest creates a request to the Toniecloud carrying the
+// access token, if one is set. buildRequest uses it for every request that
+// is not anonymous.
+func (rh *requestHandler) newAuthenticatedRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if state := rh.auth.Load(); state != nil {
+		req.Header["Authorization"] = state.header
+	}
+	return req, nil
+}
@@ -0,0 +1,240 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// DefaultAudioExtensions lists the file extensions picked up by ScanDir
+// when BatchOptions.Extensions is empty
+var DefaultAudioExtensions = []string{".mp3", ".m4a", ".aac", ".ogg", ".oga", ".opus", ".flac", ".wav", ".wma", ".aiff"}
+
+// BatchOptions configures how files are selected and ordered for batch uploads
+type BatchOptions struct {
+	// Extensions restricts the files picked up from a directory (case-insensitive).
+	// Defaults to DefaultAudioExtensions.
+	Extensions []string
+	// UseTrackTags orders files by their disc and track-number tags first;
+	// files without a track number follow in natural filename order.
+	UseTrackTags bool
+	// TitleTemplate derives chapter titles from file names and tags, e.g.
+	// "{{.TrackNo}} – {{.Title}}" (see ParseTitleTemplate and TitleData).
+	// Defaults to the file name without extension.
+	TitleTemplate string
+	// Processors are applied to every file before it is uploaded, e.g.
+	// &audio.SilenceTrimmer{}
+	Processors []audio.Processor
+	// Prober, if set, checks every file before it is processed, so that
+	// unreadable files fail without being transcoded
+	Prober audio.Prober
+	// Workers is the number of files processed concurrently. Defaults to
+	// the number of CPUs.
+	Workers int
+	// Prefetch is the number of files that may be processed ahead of the
+	// file being uploaded, which bounds the processed files on disk to
+	// Prefetch+1. Defaults to DefaultBatchPrefetch.
+	Prefetch int
+}
+
+// BatchFile is an audio file selected for a batch upload
+type BatchFile struct {
+	// Path is the path of the file on disk
+	Path string
+	// Tags holds the metadata tags read from the file, if any
+	Tags audio.Tags
+	// Title is the chapter title; the file name without extension is used if empty
+	Title string
+}
+
+// Name returns the file name without directory and extension
+func (bf BatchFile) Name() string {
+	base := filepath.Base(bf.Path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// ChapterTitle returns the chapter title to use for the file
+func (bf BatchFile) ChapterTitle() string {
+	if bf.Title != "" {
+		return bf.Title
+	}
+	return bf.Name()
+}
+
+// ScanDir lists the audio files in dir (non-recursively) in listening order.
+// Files are sorted naturally by name (01, 02, ..., 10 instead of 1, 10, 2)
+// and, with UseTrackTags, by their track-number tags. If a TitleTemplate is
+// set, the chapter titles are rendered as well.
+//
+// Example:
+//
+//	files, err := toniebox.ScanDir("/music/album", toniebox.BatchOptions{UseTrackTags: true})
+func ScanDir(dir string, opts BatchOptions) ([]BatchFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	extensions := opts.Extensions
+	if len(extensions) == 0 {
+		extensions = DefaultAudioExtensions
+	}
+
+	var files []BatchFile
+	for _, entry := range entries {
+		if entry.IsDir() || !hasExtension(entry.Name(), extensions) {
+			continue
+		}
+		file := BatchFile{Path: filepath.Join(dir, entry.Name())}
+		if tags, err := audio.ReadTags(file.Path); err == nil {
+			file.Tags = tags
+		}
+		files = append(files, file)
+	}
+
+	SortBatchFiles(files, opts.UseTrackTags)
+
+	if opts.TitleTemplate != "" {
+		tmpl, err := ParseTitleTemplate(opts.TitleTemplate)
+		if err != nil {
+			return nil, err
+		}
+		if err := ApplyTitleTemplate(files, tmpl); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// SortBatchFiles sorts files into listening order: naturally by file name,
+// or by disc and track-number tags first if useTrackTags is set
+func SortBatchFiles(files []BatchFile, useTrackTags bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if useTrackTags {
+			aTagged, bTagged := a.Tags.TrackNumber > 0, b.Tags.TrackNumber > 0
+			if aTagged != bTagged {
+				return aTagged
+			}
+			if aTagged {
+				if a.Tags.DiscNumber != b.Tags.DiscNumber {
+					return a.Tags.DiscNumber < b.Tags.DiscNumber
+				}
+				if a.Tags.TrackNumber != b.Tags.TrackNumber {
+					return a.Tags.TrackNumber < b.Tags.TrackNumber
+				}
+			}
+		}
+		return NaturalLess(filepath.Base(a.Path), filepath.Base(b.Path))
+	})
+}
+
+// UploadDir uploads all audio files in dir to this Creative-Tonie in listening
+// order (see ScanDir). Chapter titles are derived from the file names or
+// opts.TitleTemplate.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Parameters:
+//   - dir: The directory containing the audio files
+//   - opts: Selection and ordering options
+//
+// Returns an error if scanning the directory or any upload fails.
+//
+// Example:
+//
+//	err := tonie.UploadDir("/music/album", toniebox.BatchOptions{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = tonie.Commit()
+func (ct *CreativeTonie) UploadDir(dir string, opts BatchOptions) error {
+	files, err := ScanDir(dir, opts)
+	if err != nil {
+		return err
+	}
+	return ct.UploadBatchWith(context.Background(), files, opts)
+}
+
+// UploadBatch uploads files to this Creative-Tonie in the given order,
+// using each file's ChapterTitle. Each file is run through the given
+// processors before upload; intermediate files are removed afterwards.
+// Note: You must call Commit() after this to persist the changes.
+//
+// The next files are processed while the current one is uploaded, see
+// UploadBatchWith.
+//
+// A failing file does not stop the batch. All failures are returned as a
+// *MultiError, and the chapters of the successful uploads stay in place.
+func (ct *CreativeTonie) UploadBatch(files []BatchFile, processors ...audio.Processor) error {
+	return ct.UploadBatchWith(context.Background(), files, BatchOptions{Processors: processors})
+}
+
+// NaturalLess compares strings so that embedded numbers are ordered by value
+// ("2" before "10") and letters case-insensitively
+func NaturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aDigit, bDigit := isDigit(a[0]), isDigit(b[0])
+		switch {
+		case aDigit && bDigit:
+			aNum, aRest := splitDigits(a)
+			bNum, bRest := splitDigits(b)
+			aTrim, bTrim := strings.TrimLeft(aNum, "0"), strings.TrimLeft(bNum, "0")
+			if len(aTrim) != len(bTrim) {
+				return len(aTrim) < len(bTrim)
+			}
+			if aTrim != bTrim {
+				return aTrim < bTrim
+			}
+			if len(aNum) != len(bNum) {
+				// Same value: fewer leading zeros first
+				return len(aNum) < len(bNum)
+			}
+			a, b = aRest, bRest
+		case aDigit != bDigit:
+			return aDigit
+		default:
+			ac, bc := toLower(a[0]), toLower(b[0])
+			if ac != bc {
+				return ac < bc
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// hasExtension reports whether name has one of the given extensions
+func hasExtension(name string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// splitDigits splits s into its leading run of digits and the rest
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,180 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// DefaultBatchPrefetch is the number of files that may be processed ahead of
+// the upload when BatchOptions.Prefetch is zero
+const DefaultBatchPrefetch = 2
+
+// batchItem is a file passing through the stages of a batch upload
+type batchItem struct {
+	file BatchFile
+	// path is the processed file to upload; cleanup removes it
+	path    string
+	cleanup func()
+	err     error
+	// ready is closed once the file has been processed or has failed
+	ready chan struct{}
+}
+
+// fail marks item as failed without processing it
+func (item *batchItem) fail(err error) {
+	item.err = err
+	item.cleanup = func() {}
+	close(item.ready)
+}
+
+// UploadBatchWith uploads files like UploadBatch, using opts.Processors,
+// opts.Prober, opts.Workers and opts.Prefetch. The selection options of opts
+// are ignored, as the files are given.
+//
+// Files pass through three stages that run concurrently: they are probed,
+// processed by up to Workers goroutines, and uploaded one by one in the given
+// order. The stages are connected by bounded queues, so that transcoding the
+// next files overlaps with the upload of the current one. Only the Prefetch
+// files following the one being uploaded are processed ahead, so at most
+// Prefetch+1 processed files are on disk at a time, however many Workers
+// there are. Workers only limits how many of them are processed at once.
+// Note: You must call Commit() after this to persist the changes.
+//
+// When ctx is done, no further files are started; processed files that were
+// not uploaded are removed. The returned *MultiError then also wraps the
+// error of ctx.
+//
+// Example:
+//
+//	err := tonie.UploadBatchWith(ctx, files, toniebox.BatchOptions{
+//	    Processors: []audio.Processor{&audio.SilenceTrimmer{}},
+//	    Workers:    4,
+//	})
+func (ct *CreativeTonie) UploadBatchWith(ctx context.Context, files []BatchFile, opts BatchOptions) error {
+	if ct.requestHandler == nil {
+		return fmt.Errorf("tonie not properly initialized")
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	prefetch := opts.Prefetch
+	if prefetch <= 0 {
+		prefetch = DefaultBatchPrefetch
+	}
+
+	stageCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	probed := make(chan *batchItem, prefetch)
+	go probeBatch(stageCtx, opts.Prober, files, probed)
+
+	queue := make(chan *batchItem, prefetch)
+	go processBatch(stageCtx, opts.Processors, workers, probed, queue)
+
+	errs := ct.uploadQueue(stageCtx, queue)
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("batch upload aborted: %w", err))
+	}
+	return newMultiError(errs)
+}
+
+// probeBatch checks every file before it is processed and sends it to out
+// in order. Files that fail are passed on with their error.
+func probeBatch(ctx context.Context, prober audio.Prober, files []BatchFile, out chan<- *batchItem) {
+	defer close(out)
+	for _, file := range files {
+		item := &batchItem{file: file, ready: make(chan struct{})}
+		item.err = safeCall(func() error { return probeBatchFile(prober, file) })
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probeBatchFile makes sure that file is a readable audio file
+func probeBatchFile(prober audio.Prober, file BatchFile) error {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", file.Path)
+	}
+	if prober != nil {
+		if _, err := prober.Duration(file.Path); err != nil {
+			return fmt.Errorf("failed to probe file: %w", err)
+		}
+	}
+	return nil
+}
+
+// processBatch runs the files received from in through processors using up
+// to workers goroutines. Files are queued to out in the order received, before
+// they are processed; their ready channel tells when processing is done.
+func processBatch(ctx context.Context, processors []audio.Processor, workers int, in <-chan *batchItem, out chan<- *batchItem) {
+	defer close(out)
+	slots := make(chan struct{}, workers)
+	for item := range in {
+		if item.err != nil {
+			item.fail(item.err)
+		} else {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// A full queue holds up processing until the upload catches up
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			if item.err == nil {
+				<-slots
+			}
+			return
+		}
+		if item.err != nil {
+			continue
+		}
+
+		go func() {
+			defer func() { <-slots }()
+			defer close(item.ready)
+			item.cleanup = func() {}
+			item.err = safeCall(func() error {
+				path, cleanup, err := audio.Process(ctx, item.file.Path, processors...)
+				item.path, item.cleanup = path, cleanup
+				return err
+			})
+		}()
+	}
+}
+
+// uploadQueue uploads the files received from queue in order and returns
+// their failures. Once ctx is done, the remaining files are only cleaned up.
+func (ct *CreativeTonie) uploadQueue(ctx context.Context, queue <-chan *batchItem) []error {
+	var errs []error
+	for item := range queue {
+		<-item.ready
+		err := item.err
+		if err == nil && ctx.Err() == nil {
+			err = safeCall(func() error {
+				_, err := ct.uploadPath(ctx, item.file.ChapterTitle(), item.path, item.file.Path, UploadOptions{})
+				return err
+			})
+		}
+		item.cleanup()
+		if err != nil && ctx.Err() == nil {
+			errs = append(errs, fmt.Errorf("failed to upload %s: %w", item.file.Path, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,182 @@
+// Package bind provides a simplified, gomobile-compatible API on top of the
+// toniebox package so that iOS and Android companion apps can embed the
+// library via "gomobile bind".
+//
+// gomobile only supports a limited set of types across the language
+// boundary, so this package avoids slices of structs and exposes list types
+// with Len and Get accessors instead. Resources are addressed by their IDs.
+package bind
+
+import (
+	"bytes"
+	"fmt"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// Client is the gomobile-friendly entry point for the Toniebox API
+type Client struct {
+	client *toniebox.Client
+}
+
+// NewClient creates a new client with default settings
+func NewClient() *Client {
+	return &Client{
+		client: toniebox.NewClient(),
+	}
+}
+
+// Login authenticates the user with their Toniebox account credentials
+func (c *Client) Login(email, password string) error {
+	_, err := c.client.Login(email, password)
+	return err
+}
+
+// SetTokens restores a previously stored session
+func (c *Client) SetTokens(accessToken, refreshToken string) {
+	c.client.SetToken(&toniebox.JWTToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Disconnect logs out, revoking the refresh token at the login server.
+// The stored session is forgotten even if an error is returned.
+func (c *Client) Disconnect() error {
+	return c.client.Disconnect()
+}
+
+// AccessToken returns the current access token, or an empty string if not logged in
+func (c *Client) AccessToken() string {
+	token := c.client.Token()
+	if token == nil {
+		return ""
+	}
+	return token.AccessToken
+}
+
+// RefreshToken returns the current refresh token, or an empty string if not logged in
+func (c *Client) RefreshToken() string {
+	token := c.client.Token()
+	if token == nil {
+		return ""
+	}
+	return token.RefreshToken
+}
+
+// Me returns information about the authenticated user
+func (c *Client) Me() (*Me, error) {
+	me, err := c.client.GetMe()
+	if err != nil {
+		return nil, err
+	}
+	return &Me{
+		Email:     me.Email,
+		UUID:      me.UUID,
+		FirstName: me.FirstName,
+		LastName:  me.LastName,
+		Verified:  me.Verified,
+	}, nil
+}
+
+// Households returns all households the user belongs to
+func (c *Client) Households() (*HouseholdList, error) {
+	households, err := c.client.GetHouseholds()
+	if err != nil {
+		return nil, err
+	}
+	return &HouseholdList{items: households}, nil
+}
+
+// CreativeTonies returns all Creative-Tonies in the household with the given ID
+func (c *Client) CreativeTonies(householdID string) (*TonieList, error) {
+	tonies, err := c.creativeTonies(householdID)
+	if err != nil {
+		return nil, err
+	}
+	return &TonieList{items: tonies}, nil
+}
+
+// Chapters returns the chapters of the Creative-Tonie with the given ID
+func (c *Client) Chapters(householdID, tonieID string) (*ChapterList, error) {
+	tonie, err := c.creativeTonie(householdID, tonieID)
+	if err != nil {
+		return nil, err
+	}
+	return &ChapterList{items: tonie.Chapters}, nil
+}
+
+// UploadFile uploads an audio file as a new chapter and commits the change
+func (c *Client) UploadFile(householdID, tonieID, title, filePath string) error {
+	tonie, err := c.creativeTonie(householdID, tonieID)
+	if err != nil {
+		return err
+	}
+	if err := tonie.UploadFile(title, filePath); err != nil {
+		return err
+	}
+	return tonie.Commit()
+}
+
+// UploadBytes uploads in-memory audio data as a new chapter and commits the change
+func (c *Client) UploadBytes(householdID, tonieID, title string, data []byte) error {
+	tonie, err := c.creativeTonie(householdID, tonieID)
+	if err != nil {
+		return err
+	}
+	if err := tonie.UploadReader(title, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return tonie.Commit()
+}
+
+// DeleteChapter removes the chapter with the given ID and commits the change
+func (c *Client) DeleteChapter(householdID, tonieID, chapterID string) error {
+	tonie, err := c.creativeTonie(householdID, tonieID)
+	if err != nil {
+		return err
+	}
+	tonie.DeleteChapter(&toniebox.Chapter{ID: chapterID})
+	return tonie.Commit()
+}
+
+// RenameTonie changes the name of a Creative-Tonie and commits the change.
+// It fails if another tonie in the household already uses the name.
+func (c *Client) RenameTonie(householdID, tonieID, name string) error {
+	tonie, err := c.creativeTonie(householdID, tonieID)
+	if err != nil {
+		return err
+	}
+	if err := tonie.Rename(name); err != nil {
+		return err
+	}
+	return tonie.Commit()
+}
+
+// creativeTonies looks up a household by ID and retrieves its Creative-Tonies
+func (c *Client) creativeTonies(householdID string) ([]toniebox.CreativeTonie, error) {
+	households, err := c.client.GetHouseholds()
+	if err != nil {
+		return nil, err
+	}
+	for i := range households {
+		if households[i].ID == householdID {
+			return c.client.GetCreativeTonies(&households[i])
+		}
+	}
+	return nil, fmt.Errorf("household %s not found", householdID)
+}
+
+// creativeTonie looks up a single Creative-Tonie by household and tonie ID
+func (c *Client) creativeTonie(householdID, tonieID string) (*toniebox.CreativeTonie, error) {
+	tonies, err := c.creativeTonies(householdID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tonies {
+		if tonies[i].ID == tonieID {
+			return &tonies[i], nil
+		}
+	}
+	return nil, fmt.Errorf("creative tonie %s not found", tonieID)
+}
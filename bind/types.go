@@ -0,0 +1,126 @@
+package bind
+
+import (
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// Me represents personal information about the authenticated user
+type Me struct {
+	Email     string
+	UUID      string
+	FirstName string
+	LastName  string
+	Verified  bool
+}
+
+// Household represents a Toniebox household
+type Household struct {
+	ID        string
+	Name      string
+	Image     string
+	Access    string
+	OwnerName string
+}
+
+// Tonie represents a Creative-Tonie figurine
+type Tonie struct {
+	ID                string
+	HouseholdID       string
+	Name              string
+	ImageURL          string
+	Live              bool
+	Private           bool
+	Transcoding       bool
+	SecondsPresent    float64
+	SecondsRemaining  float64
+	ChaptersPresent   int
+	ChaptersRemaining int
+}
+
+// Chapter represents a chapter/track on a Creative-Tonie
+type Chapter struct {
+	ID          string
+	Title       string
+	Seconds     float64
+	Transcoding bool
+}
+
+// HouseholdList is a read-only list of households
+type HouseholdList struct {
+	items []toniebox.Household
+}
+
+// Len returns the number of households in the list
+func (l *HouseholdList) Len() int {
+	return len(l.items)
+}
+
+// Get returns the household at index i, or nil if i is out of range
+func (l *HouseholdList) Get(i int) *Household {
+	if i < 0 || i >= len(l.items) {
+		return nil
+	}
+	h := l.items[i]
+	return &Household{
+		ID:        h.ID,
+		Name:      h.Name,
+		Image:     h.Image,
+		Access:    string(h.Access),
+		OwnerName: h.OwnerName,
+	}
+}
+
+// TonieList is a read-only list of Creative-Tonies
+type TonieList struct {
+	items []toniebox.CreativeTonie
+}
+
+// Len returns the number of tonies in the list
+func (l *TonieList) Len() int {
+	return len(l.items)
+}
+
+// Get returns the tonie at index i, or nil if i is out of range
+func (l *TonieList) Get(i int) *Tonie {
+	if i < 0 || i >= len(l.items) {
+		return nil
+	}
+	t := l.items[i]
+	return &Tonie{
+		ID:                t.ID,
+		HouseholdID:       t.HouseholdID,
+		Name:              t.Name,
+		ImageURL:          t.ImageURL,
+		Live:              t.Live,
+		Private:           t.Private,
+		Transcoding:       t.Transcoding,
+		SecondsPresent:    t.SecondsPresent,
+		SecondsRemaining:  t.SecondsRemaining,
+		ChaptersPresent:   t.ChaptersPresent,
+		ChaptersRemaining: t.ChaptersRemaining,
+	}
+}
+
+// ChapterList is a read-only list of chapters
+type ChapterList struct {
+	items []toniebox.Chapter
+}
+
+// Len returns the number of chapters in the list
+func (l *ChapterList) Len() int {
+	return len(l.items)
+}
+
+// Get returns the chapter at index i, or nil if i is out of range
+func (l *ChapterList) Get(i int) *Chapter {
+	if i < 0 || i >= len(l.items) {
+		return nil
+	}
+	ch := l.items[i]
+	return &Chapter{
+		ID:          ch.ID,
+		Title:       ch.Title,
+		Seconds:     ch.Seconds,
+		Transcoding: ch.Transcoding,
+	}
+}
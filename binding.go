@@ -0,0 +1,143 @@
+package toniebox
+
+import (
+	"fmt"
+)
+
+// Household returns the household this Creative-Tonie belongs to, or nil if
+// the tonie was not returned by a client or bound with Bind
+func (ct *CreativeTonie) Household() *Household {
+	return ct.household
+}
+
+// Client returns the client this Creative-Tonie operates through, or nil if
+// the tonie was not returned by a client or bound with Bind
+func (ct *CreativeTonie) Client() *Client {
+	if ct.requestHandler == nil {
+		return nil
+	}
+	return &Client{requestHandler: ct.requestHandler}
+}
+
+// Bind attaches a Creative-Tonie that was not returned by client, e.g. one
+// decoded from cached JSON, to client and household, so that methods such
+// as UploadFile and Commit can be used on it. A nil household stands for
+// the household with the tonie's HouseholdID. The current chapters are
+// taken as the committed state.
+//
+// Example:
+//
+//	var tonie toniebox.CreativeTonie
+//	if err := json.Unmarshal(cached, &tonie); err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := tonie.Bind(client, nil); err != nil {
+//	    log.Fatal(err)
+//	}
+//	err := tonie.Refresh()
+func (ct *CreativeTonie) Bind(client *Client, household *Household) error {
+	if client == nil || client.requestHandler == nil {
+		return fmt.Errorf("tonie %s: client not properly initialized", ct.ID)
+	}
+	if household == nil {
+		if ct.HouseholdID == "" {
+			return fmt.Errorf("tonie %s has no household ID", ct.ID)
+		}
+		household = &Household{ID: ct.HouseholdID}
+	}
+	if ct.HouseholdID != "" && ct.HouseholdID != household.ID {
+		return fmt.Errorf("tonie %s belongs to household %s, not %s", ct.ID, ct.HouseholdID, household.ID)
+	}
+	if household.requestHandler == nil {
+		household.requestHandler = client.requestHandler
+	}
+
+	ct.HouseholdID = household.ID
+	ct.household = household
+	ct.requestHandler = client.requestHandler
+	ct.committedChapters = ct.Chapters
+	return nil
+}
+
+// Bind attaches a household that was not returned by client, e.g. one
+// decoded from cached JSON, to client, so that methods such as
+// DownloadImage can be used on it.
+func (h *Household) Bind(client *Client) error {
+	if client == nil || client.requestHandler == nil {
+		return fmt.Errorf("household %s: client not properly initialized", h.ID)
+	}
+	h.requestHandler = client.requestHandler
+	return nil
+}
+
+// DetachedTonie is the serializable form of a bound Creative-Tonie. Besides
+// the tonie it holds its household and the chapters as of the last commit,
+// so that uncommitted changes survive a round trip through disk and are
+// sent by the next Commit, e.g. by an offline queue.
+//
+// Example:
+//
+//	data, err := json.Marshal(tonie.Detach())
+//	// ... later, possibly in another process
+//	var detached toniebox.DetachedTonie
+//	if err := json.Unmarshal(data, &detached); err != nil {
+//	    log.Fatal(err)
+//	}
+//	tonie, err := detached.Bind(client)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = tonie.Commit()
+type DetachedTonie struct {
+	Tonie CreativeTonie `json:"tonie"`
+	// Household is nil if the tonie was not bound to a household
+	Household *Household `json:"household,omitempty"`
+	// CommittedChapters are the chapters as of the last commit
+	CommittedChapters []Chapter `json:"committedChapters"`
+}
+
+// Detach returns a copy of the tonie in a form that can be marshaled to JSON
+// without losing its household or uncommitted changes
+func (ct *CreativeTonie) Detach() *DetachedTonie {
+	detached := &DetachedTonie{
+		Tonie:             *ct,
+		CommittedChapters: cloneChapters(ct.committedChapters),
+	}
+	detached.Tonie.Chapters = cloneChapters(ct.Chapters)
+	detached.Tonie.household = nil
+	detached.Tonie.requestHandler = nil
+	detached.Tonie.committedChapters = nil
+	if ct.household != nil {
+		household := *ct.household
+		household.requestHandler = nil
+		detached.Household = &household
+	}
+	return detached
+}
+
+// Bind returns the detached tonie bound to client, see CreativeTonie.Bind.
+// The chapters recorded as committed are restored, so the next Commit sends
+// the changes made before the tonie was detached.
+func (d *DetachedTonie) Bind(client *Client) (*CreativeTonie, error) {
+	tonie := d.Tonie
+	var household *Household
+	if d.Household != nil {
+		h := *d.Household
+		household = &h
+	}
+	if err := tonie.Bind(client, household); err != nil {
+		return nil, err
+	}
+	tonie.Chapters = cloneChapters(tonie.Chapters)
+	tonie.committedChapters = cloneChapters(d.CommittedChapters)
+	return &tonie, nil
+}
+
+// cloneChapters copies chapters, keeping the difference between nil and
+// empty, which marshal to null and []
+func cloneChapters(chapters []Chapter) []Chapter {
+	if chapters == nil {
+		return nil
+	}
+	return append(make([]Chapter, 0, len(chapters)), chapters...)
+}
@@ -0,0 +1,111 @@
+package toniebox
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// uploadBuffers recycles the buffers holding multipart upload bodies. A
+// batch sync uploads hundreds of files of several megabytes each; without
+// reuse every upload allocates its body anew and leaves it to the GC.
+var uploadBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// copyBuffers recycles the buffers used to copy audio data into upload bodies
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 32<<10)
+		return &buf
+	},
+}
+
+// maxPooledBuffer keeps exceptionally large bodies out of the pool
+const maxPooledBuffer = 256 << 20
+
+// pooledBody is a request body backed by a pooled buffer. The transport may
+// still read a body after RoundTrip returned, so the buffer only goes back
+// to the pool once the owner has called release and every reader handed out
+// (including rewound copies for retries) has been closed.
+type pooledBody struct {
+	buf  *bytes.Buffer
+	refs int32
+}
+
+// newPooledBody takes an empty buffer from the pool. sizeHint is the
+// expected size of the audio data, or zero if unknown.
+func newPooledBody(sizeHint int64) *pooledBody {
+	buf := uploadBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if sizeHint > 0 && sizeHint <= maxPooledBuffer {
+		// Room for the form fields in front of the file
+		buf.Grow(int(sizeHint) + 4<<10)
+	}
+	return &pooledBody{buf: buf, refs: 1}
+}
+
+// attach makes req send the body, rewindable for retries. req must have
+// been created with a reader of the body.
+func (b *pooledBody) attach(req *http.Request) {
+	req.ContentLength = int64(b.buf.Len())
+	req.GetBody = func() (io.ReadCloser, error) {
+		return b.reader(), nil
+	}
+}
+
+// reader returns a new reader over the body that must be closed
+func (b *pooledBody) reader() io.ReadCloser {
+	atomic.AddInt32(&b.refs, 1)
+	return &pooledReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// release drops one reference and returns the buffer to the pool after the last
+func (b *pooledBody) release() {
+	if atomic.AddInt32(&b.refs, -1) != 0 {
+		return
+	}
+	if b.buf.Cap() <= maxPooledBuffer {
+		uploadBuffers.Put(b.buf)
+	}
+	b.buf = nil
+}
+
+// pooledReader reads a pooledBody and releases it when closed
+type pooledReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+// Close implements io.Closer
+func (r *pooledReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
+
+// copyPooled copies src to dst using a pooled buffer
+func copyPooled(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+// readerSize returns the number of bytes r will yield, or zero if unknown
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		if info, err := v.Stat(); err == nil && info.Mode().IsRegular() {
+			offset, err := v.Seek(0, io.SeekCurrent)
+			if err == nil {
+				return info.Size() - offset
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package toniebox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrCacheMiss is returned by a Cache when it holds no data for a request
+var ErrCacheMiss = errors.New("cache miss")
+
+// ErrStaleTonie is returned when changing a Creative-Tonie that was served
+// from the cache of WithStaleCache. Its chapters may be outdated, so
+// committing them could overwrite changes made in the cloud. Refresh the
+// tonie once the API is reachable again.
+var ErrStaleTonie = errors.New("tonie was served from the stale cache")
+
+// Cache stores the last successfully fetched households and Creative-Tonies
+// so they can be served while the Toniebox API is unreachable.
+type Cache interface {
+	// PutHouseholds stores the households fetched at fetchedAt
+	PutHouseholds(households []Household, fetchedAt time.Time) error
+	// Households returns the cached households, or ErrCacheMiss
+	Households() ([]Household, time.Time, error)
+	// PutCreativeTonies stores the Creative-Tonies of a household fetched at fetchedAt
+	PutCreativeTonies(householdID string, tonies []CreativeTonie, fetchedAt time.Time) error
+	// CreativeTonies returns the cached Creative-Tonies of a household, or ErrCacheMiss
+	CreativeTonies(householdID string) ([]CreativeTonie, time.Time, error)
+}
+
+// WithStaleCache enables graceful degradation: successful reads of households
+// and Creative-Tonies are stored in cache, and when the API is unreachable the
+// cached data is returned together with a *StaleError instead of a hard error.
+// Only network failures, timeouts and server errors count as unreachable.
+// Cached Creative-Tonies are read-only: uploading, committing or updating
+// chapters fails with ErrStaleTonie until the tonie has been refreshed.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithStaleCache(toniebox.NewMemoryCache()))
+func WithStaleCache(cache Cache) Option {
+	return func(rh *requestHandler) {
+		rh.cache = cache
+	}
+}
+
+// memoryCache is an in-memory Cache implementation
+type memoryCache struct {
+	mu                  sync.RWMutex
+	households          []Household
+	householdsFetchedAt time.Time
+	tonies              map[string][]CreativeTonie
+	toniesFetchedAt     map[string]time.Time
+}
+
+// NewMemoryCache creates a Cache that keeps the data in memory for the
+// lifetime of the process
+func NewMemoryCache() Cache {
+	return &memoryCache{
+		tonies:          make(map[string][]CreativeTonie),
+		toniesFetchedAt: make(map[string]time.Time),
+	}
+}
+
+// PutHouseholds implements Cache
+func (mc *memoryCache) PutHouseholds(households []Household, fetchedAt time.Time) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.households = append([]Household(nil), households...)
+	mc.householdsFetchedAt = fetchedAt
+	return nil
+}
+
+// Households implements Cache
+func (mc *memoryCache) Households() ([]Household, time.Time, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	if mc.householdsFetchedAt.IsZero() {
+		return nil, time.Time{}, ErrCacheMiss
+	}
+	return append([]Household(nil), mc.households...), mc.householdsFetchedAt, nil
+}
+
+// PutCreativeTonies implements Cache
+func (mc *memoryCache) PutCreativeTonies(householdID string, tonies []CreativeTonie, fetchedAt time.Time) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.tonies[householdID] = append([]CreativeTonie(nil), tonies...)
+	mc.toniesFetchedAt[householdID] = fetchedAt
+	return nil
+}
+
+// CreativeTonies implements Cache
+func (mc *memoryCache) CreativeTonies(householdID string) ([]CreativeTonie, time.Time, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	fetchedAt, ok := mc.toniesFetchedAt[householdID]
+	if !ok {
+		return nil, time.Time{}, ErrCacheMiss
+	}
+	return append([]CreativeTonie(nil), mc.tonies[householdID]...), fetchedAt, nil
+}
+
+// isUnreachable reports whether err indicates that the API could not be
+// reached: a network failure, a timeout or a server error. Rejected or
+// malformed responses, expired sessions and closed clients are not.
+func isUnreachable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCertificatePinMismatch) || errors.Is(err, ErrCertificatePinsUnenforced) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	// The retries of failed requests ran out
+	if errors.Is(err, ErrRetryBudgetExhausted) {
+		return true
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+// checkFresh returns ErrStaleTonie if tonie was served from the cache
+func (ct *CreativeTonie) checkFresh() error {
+	if ct.stale {
+		return ErrStaleTonie
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package toniebox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// MarshalCanonical returns a canonical JSON encoding of v. Object keys are
+// sorted, numbers are written in their shortest form (integral values without
+// a fraction, no negative zero) and HTML characters are not escaped, so equal
+// values always produce identical bytes. It is used for snapshots and diffs
+// that are compared byte by byte.
+//
+// Example:
+//
+//	a, _ := toniebox.MarshalCanonical(before)
+//	b, _ := toniebox.MarshalCanonical(after)
+//	changed := !bytes.Equal(a, b)
+func MarshalCanonical(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, generic); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CanonicalJSON returns the canonical JSON encoding of this household
+func (h *Household) CanonicalJSON() ([]byte, error) {
+	return MarshalCanonical(h)
+}
+
+// CanonicalJSON returns the canonical JSON encoding of this Creative-Tonie
+func (ct *CreativeTonie) CanonicalJSON() ([]byte, error) {
+	return MarshalCanonical(ct)
+}
+
+// writeCanonical encodes a value produced by decoding JSON with UseNumber
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		// encoding/json sorts map keys, so only the values need normalizing
+		normalized := make(map[string]json.RawMessage, len(v))
+		for key, value := range v {
+			var field bytes.Buffer
+			if err := writeCanonical(&field, value); err != nil {
+				return err
+			}
+			normalized[key] = field.Bytes()
+		}
+		return encodePlain(buf, normalized)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, value := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %s: %w", v, err)
+		}
+		buf.WriteString(formatCanonicalFloat(f))
+		return nil
+	default:
+		return encodePlain(buf, v)
+	}
+}
+
+// formatCanonicalFloat formats f in its shortest exact representation
+func formatCanonicalFloat(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodePlain encodes v compactly without HTML escaping
+func encodePlain(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	// Drop the newline added by Encode
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
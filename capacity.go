@@ -0,0 +1,103 @@
+package toniebox
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// PlannedFile is a batch file together with its probed duration
+type PlannedFile struct {
+	BatchFile
+	Duration time.Duration
+}
+
+// UploadPlan splits a set of files into those that fit onto a Creative-Tonie
+// and those that overflow its capacity
+type UploadPlan struct {
+	// Fits are the files that fit, in their original order
+	Fits []PlannedFile
+	// Overflow are the remaining files, in their original order
+	Overflow []PlannedFile
+	// FitsDuration is the total duration of Fits
+	FitsDuration time.Duration
+	// OverflowDuration is the total duration of Overflow
+	OverflowDuration time.Duration
+	// SecondsRemaining and ChaptersRemaining describe the free capacity of
+	// the tonie before the upload
+	SecondsRemaining  float64
+	ChaptersRemaining int
+}
+
+// PlanUpload probes the duration of each file with audio.DefaultProber and
+// determines which files fit within the tonie's SecondsRemaining and
+// ChaptersRemaining. See PlanUploadWith.
+//
+// Example:
+//
+//	files, _ := toniebox.ScanDir("/music/album", toniebox.BatchOptions{})
+//	plan, err := toniebox.PlanUpload(tonie, files)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d files fit, %d overflow\n", len(plan.Fits), len(plan.Overflow))
+func PlanUpload(tonie *CreativeTonie, files []BatchFile) (*UploadPlan, error) {
+	return PlanUploadWith(audio.DefaultProber, tonie, files)
+}
+
+// PlanUploadWith is like PlanUpload but uses the given prober.
+//
+// Files are taken in order as long as they fit, so the result preserves the
+// listening order: once a file does not fit, it and all following files are
+// reported as overflow.
+func PlanUploadWith(prober audio.Prober, tonie *CreativeTonie, files []BatchFile) (*UploadPlan, error) {
+	probed, err := probeFiles(prober, files)
+	if err != nil {
+		return nil, err
+	}
+	return planCapacity(probed, tonie.SecondsRemaining, tonie.ChaptersRemaining), nil
+}
+
+// probeFiles determines the duration of every file
+func probeFiles(prober audio.Prober, files []BatchFile) ([]PlannedFile, error) {
+	probed := make([]PlannedFile, len(files))
+	err := runParallel(len(files), runtime.GOMAXPROCS(0), func(i int) error {
+		duration, err := prober.Duration(files[i].Path)
+		if err != nil {
+			return fmt.Errorf("failed to probe %s: %w", files[i].Path, err)
+		}
+		probed[i] = PlannedFile{BatchFile: files[i], Duration: duration}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return probed, nil
+}
+
+// planCapacity splits probed files at the first file exceeding the capacity
+func planCapacity(files []PlannedFile, secondsRemaining float64, chaptersRemaining int) *UploadPlan {
+	plan := &UploadPlan{
+		SecondsRemaining:  secondsRemaining,
+		ChaptersRemaining: chaptersRemaining,
+	}
+
+	seconds := secondsRemaining
+	chapters := chaptersRemaining
+	overflowing := false
+	for _, file := range files {
+		if !overflowing && chapters > 0 && file.Duration.Seconds() <= seconds {
+			plan.Fits = append(plan.Fits, file)
+			plan.FitsDuration += file.Duration
+			seconds -= file.Duration.Seconds()
+			chapters--
+			continue
+		}
+		overflowing = true
+		plan.Overflow = append(plan.Overflow, file)
+		plan.OverflowDuration += file.Duration
+	}
+	return plan
+}
@@ -1,7 +1,9 @@
 package toniebox
 
 import (
+	"context"
 	"fmt"
+	"io"
 )
 
 // Client is the main interface for interacting with the Toniebox API.
@@ -11,14 +13,15 @@ type Client struct {
 }
 
 // NewClient creates a new Toniebox API client with default settings.
+// Optional settings can be applied by passing one or more Option values.
 //
 // Example:
 //
 //	client := toniebox.NewClient()
 //	err := client.Login("user@example.com", "password")
-func NewClient() *Client {
+func NewClient(opts ...Option) *Client {
 	return &Client{
-		requestHandler: newRequestHandler(),
+		requestHandler: newRequestHandler(opts...),
 	}
 }
 
@@ -28,8 +31,8 @@ func NewClient() *Client {
 // Example:
 //
 //	client, err := toniebox.NewClientWithProxy("http://proxy.example.com:8080")
-func NewClientWithProxy(proxyURL string) (*Client, error) {
-	handler, err := newRequestHandlerWithProxy(proxyURL)
+func NewClientWithProxy(proxyURL string, opts ...Option) (*Client, error) {
+	handler, err := newRequestHandlerWithProxy(proxyURL, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -38,22 +41,6 @@ func NewClientWithProxy(proxyURL string) (*Client, error) {
 	}, nil
 }
 
-// Login authenticates the user with their Toniebox account credentials.
-// This must be called before any other API methods.
-//
-// Parameters:
-//   - username: The email address for your Toniebox account
-//   - password: The password for your Toniebox account
-//
-// Returns an error if authentication fails.
-//
-// Example:
-//
-//	err := client.Login("user@example.com", "password")
-//	if err != nil {
-//	    log.Fatal(err)
-//	}
-//
 // Login authenticates the user with their Toniebox account credentials.
 // This must be called before any other API methods.
 //
@@ -62,6 +49,7 @@ func NewClientWithProxy(proxyURL string) (*Client, error) {
 //   - password: The password for your Toniebox account
 //
 // Returns the authentication token (including refresh token) or an error if authentication fails.
+// The identity of the user can be decoded from the token with JWTToken.Identity.
 //
 // Example:
 //
@@ -70,12 +58,26 @@ func NewClientWithProxy(proxyURL string) (*Client, error) {
 //	    log.Fatal(err)
 //	}
 //	fmt.Printf("Refresh Token: %s\n", token.RefreshToken)
+//	if identity, err := token.Identity(); err == nil {
+//	    fmt.Printf("Logged in as %s\n", identity.Email)
+//	}
 func (c *Client) Login(username, password string) (*JWTToken, error) {
+	return c.LoginContext(context.Background(), username, password)
+}
+
+// LoginContext is like Login, but the request is aborted when ctx is done.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	token, err := client.LoginContext(ctx, "user@example.com", "password")
+func (c *Client) LoginContext(ctx context.Context, username, password string) (*JWTToken, error) {
 	login := &Login{
 		Email:    username,
 		Password: password,
 	}
-	return c.requestHandler.login(login)
+	return c.requestHandler.login(ctx, login)
 }
 
 // SetToken sets the authentication token directly, bypassing the login process.
@@ -92,7 +94,27 @@ func (c *Client) Login(username, password string) (*JWTToken, error) {
 //	}
 //	client.SetToken(token)
 func (c *Client) SetToken(token *JWTToken) {
-	c.requestHandler.jwtToken = token
+	c.requestHandler.setToken(token)
+}
+
+// Token returns a copy of the current authentication token, or nil if the
+// client is not logged in. Together with SetToken it lets applications keep
+// sessions in their own storage, e.g. a database, instead of logging in
+// again. Tokens renewed by WithAutoRefresh replace the previous
+// one, so store the token again after using the client.
+//
+// Example:
+//
+//	if token := client.Token(); token != nil {
+//	    db.SaveToken(userID, token.AccessToken, token.RefreshToken)
+//	}
+func (c *Client) Token() *JWTToken {
+	token := c.requestHandler.token()
+	if token == nil {
+		return nil
+	}
+	copied := *token
+	return &copied
 }
 
 // GetMe retrieves personal information about the authenticated user.
@@ -107,13 +129,37 @@ func (c *Client) SetToken(token *JWTToken) {
 //	}
 //	fmt.Printf("User: %s %s\n", me.FirstName, me.LastName)
 func (c *Client) GetMe() (*Me, error) {
-	return c.requestHandler.getMe()
+	return c.GetMeContext(context.Background())
+}
+
+// GetMeContext is like GetMe, but the request is aborted when ctx is done.
+func (c *Client) GetMeContext(ctx context.Context) (*Me, error) {
+	return c.requestHandler.getMe(ctx)
+}
+
+// Ping performs a cheap authenticated call against the Toniebox API.
+// It is intended for health checks of services that integrate with Toniebox.
+//
+// An error is only returned if the API is unreachable or misbehaves. A
+// rejected token is reported via PingResult.Authenticated instead.
+//
+// Example:
+//
+//	result, err := client.Ping(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Latency: %s, authenticated: %t\n", result.Latency, result.Authenticated)
+func (c *Client) Ping(ctx context.Context) (*PingResult, error) {
+	return c.requestHandler.ping(ctx)
 }
 
 // GetHouseholds retrieves all households that the user belongs to.
 // A household represents a family or group that shares Tonieboxes.
 //
 // Returns a slice of households or an error if the request fails.
+// If the client was created with WithStaleCache and the API is unreachable,
+// the last cached households are returned together with a *StaleError.
 //
 // Example:
 //
@@ -125,7 +171,19 @@ func (c *Client) GetMe() (*Me, error) {
 //	    fmt.Printf("Household: %s (ID: %s)\n", household.Name, household.ID)
 //	}
 func (c *Client) GetHouseholds() ([]Household, error) {
-	return c.requestHandler.getHouseholds()
+	return c.GetHouseholdsContext(context.Background())
+}
+
+// GetHouseholdsContext is like GetHouseholds, but the request is aborted
+// when ctx is done.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	households, err := client.GetHouseholdsContext(ctx)
+func (c *Client) GetHouseholdsContext(ctx context.Context) ([]Household, error) {
+	return c.requestHandler.getHouseholds(ctx)
 }
 
 // GetCreativeTonies retrieves all Creative-Tonies in a specific household.
@@ -135,6 +193,8 @@ func (c *Client) GetHouseholds() ([]Household, error) {
 //   - household: The household to retrieve Creative-Tonies from
 //
 // Returns a slice of Creative-Tonies or an error if the request fails.
+// If the client was created with WithStaleCache and the API is unreachable,
+// the last cached Creative-Tonies are returned together with a *StaleError.
 //
 // Example:
 //
@@ -147,7 +207,13 @@ func (c *Client) GetHouseholds() ([]Household, error) {
 //	    fmt.Printf("Tonie: %s (Chapters: %d)\n", tonie.Name, tonie.ChaptersPresent)
 //	}
 func (c *Client) GetCreativeTonies(household *Household) ([]CreativeTonie, error) {
-	return c.requestHandler.getCreativeTonies(household)
+	return c.GetCreativeToniesContext(context.Background(), household)
+}
+
+// GetCreativeToniesContext is like GetCreativeTonies, but the request is
+// aborted when ctx is done.
+func (c *Client) GetCreativeToniesContext(ctx context.Context, household *Household) ([]CreativeTonie, error) {
+	return c.requestHandler.getCreativeTonies(ctx, household)
 }
 
 // FindChapterByTitle searches for a chapter with the given title on this Creative-Tonie.
@@ -195,6 +261,124 @@ func (ct *CreativeTonie) DeleteChapter(chapter *Chapter) {
 	ct.Chapters = newChapters
 }
 
+// ChapterPosition returns the zero-based playback position of the chapter
+// with the given ID, or -1 if the tonie has no such chapter. Positions are
+// defined by the order of Chapters, which is sent as-is on Commit.
+func (ct *CreativeTonie) ChapterPosition(chapterID string) int {
+	for i := range ct.Chapters {
+		if ct.Chapters[i].ID == chapterID {
+			return i
+		}
+	}
+	return -1
+}
+
+// ChapterFields holds the chapter attributes changed by UpdateChapter.
+// Empty fields are left unchanged.
+type ChapterFields struct {
+	Title string
+}
+
+// apply copies the non-empty fields onto chapter
+func (f ChapterFields) apply(chapter *Chapter) {
+	if f.Title != "" {
+		chapter.Title = f.Title
+	}
+}
+
+// UpdateChapter changes a single chapter and saves it immediately, without
+// committing any other pending changes of this Creative-Tonie.
+// The API has no per-chapter endpoint, so the chapter is updated within the
+// latest chapter list fetched from the cloud and only that list is sent. This
+// keeps the payload small and avoids overwriting changes made elsewhere.
+// The updated list is validated and passes the BeforeCommit and AfterCommit
+// hooks and the history like a Commit of the latest state of the tonie.
+//
+// Parameters:
+//   - chapterID: The ID of the chapter to update
+//   - fields: The attributes to change
+//
+// Returns an error if the chapter does not exist or the update fails.
+//
+// Example:
+//
+//	err := tonie.UpdateChapter(chapter.ID, toniebox.ChapterFields{Title: "Chapter 1"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (ct *CreativeTonie) UpdateChapter(chapterID string, fields ChapterFields) error {
+	return ct.UpdateChapterContext(context.Background(), chapterID, fields)
+}
+
+// UpdateChapterContext is like UpdateChapter, but the requests are aborted
+// when ctx is done. A cancelled update may or may not have been applied by
+// the API; call RefreshContext to find out.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	err := tonie.UpdateChapterContext(ctx, chapter.ID, toniebox.ChapterFields{Title: "Chapter 1"})
+func (ct *CreativeTonie) UpdateChapterContext(ctx context.Context, chapterID string, fields ChapterFields) error {
+	if ct.requestHandler == nil {
+		return fmt.Errorf("tonie not properly initialized")
+	}
+	if err := ct.requestHandler.updateChapter(ctx, ct, chapterID, fields); err != nil {
+		return err
+	}
+
+	// Mirror the change locally without discarding pending edits
+	if i := ct.ChapterPosition(chapterID); i >= 0 {
+		fields.apply(&ct.Chapters[i])
+	}
+	for i := range ct.committedChapters {
+		if ct.committedChapters[i].ID == chapterID {
+			fields.apply(&ct.committedChapters[i])
+		}
+	}
+	return nil
+}
+
+// ReorderChapters rearranges the chapters of this Creative-Tonie.
+// chapterIDs must contain the ID of every chapter exactly once, in the desired order.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Parameters:
+//   - chapterIDs: The chapter IDs in their new order
+//
+// Returns an error if chapterIDs does not match the current chapters.
+//
+// Example:
+//
+//	err := tonie.ReorderChapters([]string{second.ID, first.ID})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = tonie.Commit()
+func (ct *CreativeTonie) ReorderChapters(chapterIDs []string) error {
+	if len(chapterIDs) != len(ct.Chapters) {
+		return fmt.Errorf("expected %d chapter IDs, got %d", len(ct.Chapters), len(chapterIDs))
+	}
+
+	byID := make(map[string]Chapter, len(ct.Chapters))
+	for _, chapter := range ct.Chapters {
+		byID[chapter.ID] = chapter
+	}
+
+	reordered := make([]Chapter, 0, len(chapterIDs))
+	for _, id := range chapterIDs {
+		chapter, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("unknown or duplicate chapter ID %q", id)
+		}
+		delete(byID, id)
+		reordered = append(reordered, chapter)
+	}
+
+	ct.Chapters = reordered
+	return nil
+}
+
 // UploadFile uploads an audio file to this Creative-Tonie.
 // The file will be added as a new chapter with the specified title.
 // Note: You must call Commit() after this to persist the changes.
@@ -213,16 +397,61 @@ func (ct *CreativeTonie) DeleteChapter(chapter *Chapter) {
 //	}
 //	err = tonie.Commit()
 func (ct *CreativeTonie) UploadFile(title, filePath string) error {
+	return ct.UploadFileContext(context.Background(), title, filePath)
+}
+
+// UploadFileContext is like UploadFile, but the upload is aborted when ctx
+// is done, e.g. to give up on a large file after a deadline.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+//	defer cancel()
+//	err := tonie.UploadFileContext(ctx, "My Story", "/path/to/audio.mp3")
+func (ct *CreativeTonie) UploadFileContext(ctx context.Context, title, filePath string) error {
+	_, err := ct.uploadPath(ctx, title, filePath, filePath, UploadOptions{})
+	return err
+}
+
+// UploadReader uploads audio data read from r to this Creative-Tonie.
+// It behaves like UploadFile but does not touch the local filesystem, which
+// makes it usable in environments such as WebAssembly in the browser.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Parameters:
+//   - title: The title for the new chapter
+//   - r: The audio data to upload
+//
+// Returns an error if the upload fails.
+//
+// Example:
+//
+//	err := tonie.UploadReader("My Story", bytes.NewReader(audio))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = tonie.Commit()
+func (ct *CreativeTonie) UploadReader(title string, r io.Reader) error {
+	return ct.UploadReaderContext(context.Background(), title, r)
+}
+
+// UploadReaderContext is like UploadReader, but the upload is aborted when
+// ctx is done.
+// Note: You must call Commit() after this to persist the changes.
+func (ct *CreativeTonie) UploadReaderContext(ctx context.Context, title string, r io.Reader) error {
 	if ct.requestHandler == nil {
 		return fmt.Errorf("tonie not properly initialized")
 	}
-	return ct.requestHandler.uploadFile(ct, filePath, title)
+	_, err := ct.requestHandler.uploadFile(ctx, ct, r, title, UploadOptions{})
+	return err
 }
 
 // Commit saves all changes made to this Creative-Tonie to the Toniebox cloud.
 // This must be called after making changes like renaming, uploading, or deleting chapters.
 //
-// Returns an error if the commit fails.
+// Returns a *ValidationError without contacting the API if the local chapters
+// are inconsistent (see Validate), or an error if the commit fails.
 //
 // Example:
 //
@@ -232,10 +461,23 @@ func (ct *CreativeTonie) UploadFile(title, filePath string) error {
 //	    log.Fatal(err)
 //	}
 func (ct *CreativeTonie) Commit() error {
+	return ct.CommitContext(context.Background())
+}
+
+// CommitContext is like Commit, but the request is aborted when ctx is done.
+// A cancelled commit may or may not have been applied by the API; call
+// RefreshContext to find out.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	err := tonie.CommitContext(ctx)
+func (ct *CreativeTonie) CommitContext(ctx context.Context) error {
 	if ct.requestHandler == nil {
 		return fmt.Errorf("tonie not properly initialized")
 	}
-	return ct.requestHandler.commitTonie(ct)
+	return ct.requestHandler.commitTonie(ctx, ct)
 }
 
 // Refresh reloads the current state of this Creative-Tonie from the Toniebox cloud.
@@ -251,11 +493,17 @@ func (ct *CreativeTonie) Commit() error {
 //	}
 //	fmt.Printf("Chapters present: %d\n", tonie.ChaptersPresent)
 func (ct *CreativeTonie) Refresh() error {
+	return ct.RefreshContext(context.Background())
+}
+
+// RefreshContext is like Refresh, but the request is aborted when ctx is
+// done. The tonie is left unchanged if the refresh fails.
+func (ct *CreativeTonie) RefreshContext(ctx context.Context) error {
 	if ct.requestHandler == nil {
 		return fmt.Errorf("tonie not properly initialized")
 	}
 
-	refreshed, err := ct.requestHandler.refreshTonie(ct)
+	refreshed, err := ct.requestHandler.refreshTonie(ctx, ct)
 	if err != nil {
 		return err
 	}
@@ -274,6 +522,8 @@ func (ct *CreativeTonie) Refresh() error {
 	ct.ChaptersRemaining = refreshed.ChaptersRemaining
 	ct.Chapters = refreshed.Chapters
 	ct.HouseholdID = refreshed.HouseholdID
+	ct.committedChapters = refreshed.committedChapters
+	ct.stale = false
 
 	return nil
 }
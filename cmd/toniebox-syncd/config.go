@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mikeboe/toniebox-api-go/dirsync"
+	"github.com/mikeboe/toniebox-api-go/eventlog"
+	"github.com/mikeboe/toniebox-api-go/kvstore"
+	"github.com/mikeboe/toniebox-api-go/notify"
+	"github.com/mikeboe/toniebox-api-go/policy"
+	"github.com/mikeboe/toniebox-api-go/store"
+	"github.com/mikeboe/toniebox-api-go/window"
+)
+
+// configKind is the kind expected in configuration files
+const configKind = "TonieSync"
+
+// config is the daemon configuration, modeled after a Kubernetes resource
+type config struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec spec `json:"spec"`
+}
+
+// spec is the desired state reconciled by the daemon
+type spec struct {
+	// Interval between reconciliations, e.g. "15m"
+	Interval duration `json:"interval"`
+	// Windows restrict uploads to certain hours, e.g. "22:00-06:00"
+	Windows string `json:"windows,omitempty"`
+	// Strategy resolves conflicts with changes made in the app
+	Strategy dirsync.Strategy `json:"strategy,omitempty"`
+	// Policy protects content from automated changes
+	Policy *policy.Policy `json:"policy,omitempty"`
+	// State configures where sync baselines are kept
+	State stateConfig `json:"state"`
+	// EventLog is a file to append JSON Lines events to, or "-" for stdout
+	EventLog string `json:"eventLog,omitempty"`
+	// MaxUploadSize rejects files larger than this many bytes
+	MaxUploadSize int64 `json:"maxUploadSize,omitempty"`
+	// AllowedTypes restricts uploads to these extensions or MIME types,
+	// e.g. [".mp3", "audio/*"]
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+	// Notify reports syncs that changed a tonie or failed
+	Notify *notifyConfig `json:"notify,omitempty"`
+	// Tonies lists the tonies to keep in sync
+	Tonies []tonieConfig `json:"tonies"`
+}
+
+// tonieConfig maps a tonie to its sources
+type tonieConfig struct {
+	HouseholdID string `json:"householdId"`
+	TonieID     string `json:"tonieId"`
+	dirsync.Config
+}
+
+// stateConfig selects a kvstore backend
+type stateConfig struct {
+	// Type is "memory", "file", "sqlite" or "s3"
+	Type string `json:"type"`
+	// Path is the directory (file) or database file (sqlite)
+	Path string      `json:"path,omitempty"`
+	S3   *kvstore.S3 `json:"s3,omitempty"`
+}
+
+// notifyConfig selects where the outcome of syncs is reported
+type notifyConfig struct {
+	// Webhook is a URL that events are posted to as JSON
+	Webhook string `json:"webhook,omitempty"`
+	// Slack and Discord are incoming webhook URLs
+	Slack   string `json:"slack,omitempty"`
+	Discord string `json:"discord,omitempty"`
+	// FailuresOnly only reports failed syncs
+	FailuresOnly bool `json:"failuresOnly,omitempty"`
+}
+
+// notifier returns the configured notifier, or nil if none is configured
+func (n *notifyConfig) notifier() notify.Notifier {
+	if n == nil {
+		return nil
+	}
+	var m notify.Multi
+	if n.Webhook != "" {
+		m = append(m, &notify.Webhook{URL: n.Webhook})
+	}
+	if n.Slack != "" {
+		m = append(m, &notify.Slack{WebhookURL: n.Slack})
+	}
+	if n.Discord != "" {
+		m = append(m, &notify.Discord{WebhookURL: n.Discord})
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	if n.FailuresOnly {
+		return notify.FailuresOnly(m)
+	}
+	return m
+}
+
+// duration is a time.Duration written as a string such as "15m"
+type duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// loadConfig reads and validates a YAML (or JSON) configuration file.
+// The YAML is converted to JSON first, so that the json tags of the library
+// types apply.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var cfg config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	if cfg.Kind != configKind {
+		return nil, fmt.Errorf("invalid config: kind must be %q", configKind)
+	}
+	if cfg.Spec.Interval <= 0 {
+		cfg.Spec.Interval = duration(15 * time.Minute)
+	}
+	if _, err := window.Parse(cfg.Spec.Windows); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if cfg.Spec.Policy != nil {
+		if err := cfg.Spec.Policy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if cfg.Spec.Strategy == dirsync.StrategyInteractive {
+		return nil, fmt.Errorf("invalid config: the daemon cannot resolve conflicts interactively")
+	}
+	for i, tonie := range cfg.Spec.Tonies {
+		if tonie.HouseholdID == "" || tonie.TonieID == "" {
+			return nil, fmt.Errorf("invalid config: tonie %d needs householdId and tonieId", i+1)
+		}
+	}
+	return &cfg, nil
+}
+
+// openState opens the configured state backend
+func (s stateConfig) open() (kvstore.Store, error) {
+	switch s.Type {
+	case "", "memory":
+		return kvstore.NewMemory(), nil
+	case "file":
+		return kvstore.NewFile(s.Path)
+	case "sqlite":
+		return store.Open(s.Path)
+	case "s3":
+		if s.S3 == nil {
+			return nil, fmt.Errorf("state type s3 needs an s3 section")
+		}
+		// Keep secrets out of the config file
+		if s.S3.AccessKey == "" {
+			s.S3.AccessKey = os.Getenv("S3_ACCESS_KEY")
+		}
+		if s.S3.SecretKey == "" {
+			s.S3.SecretKey = os.Getenv("S3_SECRET_KEY")
+		}
+		return s.S3, nil
+	default:
+		return nil, fmt.Errorf("unknown state type %q", s.Type)
+	}
+}
+
+// openEventLog opens the configured event log, or returns nil if disabled
+func openEventLog(path string) (*eventlog.JSONL, error) {
+	switch path {
+	case "":
+		return nil, nil
+	case "-":
+		return eventlog.NewJSONL(os.Stdout), nil
+	default:
+		return eventlog.OpenFile(path)
+	}
+}
@@ -0,0 +1,317 @@
+// Command toniebox-syncd continuously reconciles Creative-Tonies with local
+// directories. It is meant to run as a long-lived daemon, e.g. as a
+// Kubernetes Deployment with the source folders mounted as volumes.
+//
+// Credentials are read from the TONIEBOX_USERNAME and TONIEBOX_PASSWORD
+// environment variables (or a .env file); S3 credentials for external state
+// from S3_ACCESS_KEY and S3_SECRET_KEY.
+//
+// Usage:
+//
+//	toniebox-syncd -config /etc/toniebox/sync.yaml -addr :8080
+//
+// The configuration is a Kubernetes-style resource:
+//
+//	apiVersion: toniebox/v1
+//	kind: TonieSync
+//	metadata:
+//	  name: kids-room
+//	spec:
+//	  interval: 15m
+//	  windows: "22:00-06:00"
+//	  strategy: remote-wins
+//	  policy:
+//	    protectedChapters: ["Grandma *"]
+//	    maxDeletions: 10
+//	  state:
+//	    type: file
+//	    path: /var/lib/toniebox
+//	  eventLog: "-"
+//	  notify:
+//	    slack: https://hooks.slack.com/services/...
+//	    failuresOnly: true
+//	  tonies:
+//	    - householdId: 1a2b3c
+//	      tonieId: 4d5e6f
+//	      sources:
+//	        - dir: /media/recordings
+//	          prefix: "Mum: "
+//	          order: newest-first
+//	        - dir: /media/audiobooks
+//	          order: tracks
+//
+// HTTP endpoints for probes:
+//
+//	GET /healthz  liveness: 200 while the reconcile loop is running
+//	GET /readyz   readiness: 200 once logged in and reconciled at least once
+//	GET /metrics  Prometheus metrics of the reconcile loop and API requests
+//
+// On SIGTERM or SIGINT the daemon stops starting new work, lets the current
+// upload finish and exits.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/apply"
+	"github.com/mikeboe/toniebox-api-go/dirsync"
+	"github.com/mikeboe/toniebox-api-go/eventlog"
+	"github.com/mikeboe/toniebox-api-go/kvstore"
+	"github.com/mikeboe/toniebox-api-go/notify"
+	"github.com/mikeboe/toniebox-api-go/promexport"
+	"github.com/mikeboe/toniebox-api-go/window"
+)
+
+// shutdownTimeout bounds how long the probe server may take to shut down
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	configPath := flag.String("config", "/etc/toniebox/sync.yaml", "path to the sync configuration")
+	addr := flag.String("addr", ":8080", "address of the health endpoints")
+	flag.Parse()
+
+	// It's okay if .env doesn't exist, we might be using env vars directly
+	_ = godotenv.Load()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	username := os.Getenv("TONIEBOX_USERNAME")
+	password := os.Getenv("TONIEBOX_PASSWORD")
+	if username == "" || password == "" {
+		log.Fatal("Please set TONIEBOX_USERNAME and TONIEBOX_PASSWORD environment variables")
+	}
+
+	state, err := cfg.Spec.State.open()
+	if err != nil {
+		log.Fatalf("Failed to open state: %v", err)
+	}
+	events, err := openEventLog(cfg.Spec.EventLog)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	d := newDaemon(cfg, state)
+	if events != nil {
+		defer events.Close()
+		d.events = events
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: d.handler()}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Probe server failed: %v", err)
+		}
+	}()
+
+	d.run(ctx, username, password)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	if err := d.client.Close(shutdownCtx); err != nil {
+		log.Printf("Client shutdown: %v", err)
+	}
+	log.Print("Shut down")
+}
+
+// daemon reconciles the configured tonies periodically
+type daemon struct {
+	cfg    *config
+	state  kvstore.Store
+	client *toniebox.Client
+	events eventlog.Logger
+	// notifier reports syncs that changed a tonie or failed, and failed
+	// logins; nil disables notifications
+	notifier notify.Notifier
+
+	metrics    *promexport.Registry
+	reconciles *promexport.Counter
+	failures   *promexport.Counter
+
+	mu         sync.Mutex
+	loggedIn   bool
+	lastRun    time.Time
+	lastErr    error
+	reconciled bool
+	// lastSuccess is the time of the last run without errors
+	lastSuccess time.Time
+}
+
+// newDaemon creates a daemon whose client reports to the daemon's metrics
+func newDaemon(cfg *config, state kvstore.Store) *daemon {
+	d := &daemon{cfg: cfg, state: state, metrics: promexport.NewRegistry(), notifier: cfg.Spec.Notify.notifier()}
+	opts := []toniebox.Option{
+		toniebox.WithMetrics(d.metrics.Client),
+		toniebox.WithAutoRefresh(),
+		toniebox.WithWarnings(toniebox.WarningFunc(func(w toniebox.Warning) {
+			log.Printf("Warning: %s", w)
+		})),
+	}
+	if cfg.Spec.MaxUploadSize > 0 {
+		opts = append(opts, toniebox.WithMaxUploadSize(cfg.Spec.MaxUploadSize))
+	}
+	if len(cfg.Spec.AllowedTypes) > 0 {
+		opts = append(opts, toniebox.WithAllowedUploadTypes(cfg.Spec.AllowedTypes...))
+	}
+	d.client = toniebox.NewClient(opts...)
+	d.reconciles = d.metrics.Counter("toniebox_syncd_reconciles_total", "Completed reconcile runs.")
+	d.failures = d.metrics.Counter("toniebox_syncd_reconcile_failures_total", "Reconcile runs that failed for at least one tonie.")
+	d.metrics.GaugeFunc("toniebox_syncd_last_success_timestamp_seconds", "Time of the last successful reconcile run.", func() float64 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.lastSuccess.IsZero() {
+			return 0
+		}
+		return float64(d.lastSuccess.Unix())
+	})
+	d.metrics.GaugeFunc("toniebox_syncd_ready", "Whether the daemon is ready.", func() float64 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.loggedIn && d.reconciled {
+			return 1
+		}
+		return 0
+	})
+	return d
+}
+
+// run logs in and reconciles until ctx is done
+func (d *daemon) run(ctx context.Context, username, password string) {
+	interval := time.Duration(d.cfg.Spec.Interval)
+	for {
+		if err := d.reconcile(ctx, username, password); err != nil {
+			log.Printf("Reconcile failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// reconcile syncs every configured tonie once
+func (d *daemon) reconcile(ctx context.Context, username, password string) error {
+	if !d.isLoggedIn() {
+		startedAt := time.Now()
+		if _, err := d.client.LoginContext(ctx, username, password); err != nil {
+			d.finish(err)
+			if d.notifier != nil {
+				_ = d.notifier.Notify(ctx, notify.NewEvent("login", startedAt, "", err))
+			}
+			return err
+		}
+		d.mu.Lock()
+		d.loggedIn = true
+		d.mu.Unlock()
+	}
+
+	windows, _ := window.Parse(d.cfg.Spec.Windows)
+	syncer := &dirsync.Syncer{
+		// A new engine per run, as the policy's deletion limit applies per run
+		Engine: &apply.Engine{
+			Windows: windows,
+			Events:  d.events,
+			Policy:  d.cfg.Spec.Policy,
+		},
+		State:    d.state,
+		Strategy: d.cfg.Spec.Strategy,
+		Notifier: d.notifier,
+	}
+
+	var errs []error
+	for _, t := range d.cfg.Spec.Tonies {
+		if ctx.Err() != nil {
+			break
+		}
+		tonie, err := d.client.Household(t.HouseholdID).Tonie(t.TonieID)
+		if err == nil {
+			var plan *apply.Plan
+			plan, err = syncer.Sync(ctx, tonie, t.Config)
+			if err == nil && !plan.Empty() {
+				log.Printf("Reconciled %s:\n%s", tonie.Name, plan)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			log.Printf("Failed to sync tonie %s: %v", t.TonieID, err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	d.finish(err)
+	return err
+}
+
+// isLoggedIn reports whether the client has logged in successfully
+func (d *daemon) isLoggedIn() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.loggedIn
+}
+
+// finish records the outcome of a reconciliation
+func (d *daemon) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastRun = time.Now()
+	d.lastErr = err
+	d.reconciles.Inc()
+	if err != nil {
+		d.failures.Inc()
+		return
+	}
+	d.reconciled = true
+	d.lastSuccess = d.lastRun
+}
+
+// handler returns the handler of the health and metrics endpoints
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", d.metrics)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		status := map[string]interface{}{
+			"name":       d.cfg.Metadata.Name,
+			"loggedIn":   d.loggedIn,
+			"reconciled": d.reconciled,
+		}
+		if !d.lastRun.IsZero() {
+			status["lastRun"] = d.lastRun.Format(time.RFC3339)
+		}
+		if d.lastErr != nil {
+			status["lastError"] = d.lastErr.Error()
+		}
+		ready := d.loggedIn && d.reconciled
+		d.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+	return mux
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// newAccessToken returns a random token that authorizes the management API
+// until the server is restarted
+func newAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// allowedHosts returns the Host headers under which the server may be
+// reached: the listen address, its loopback names if it listens on all
+// interfaces or on loopback, and the host of publicURL
+func allowedHosts(addr, publicURL string) ([]string, error) {
+	hosts := []string{addr}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "" || host == "localhost" || (ip != nil && (ip.IsUnspecified() || ip.IsLoopback())) {
+		for _, name := range []string{"localhost", "127.0.0.1", "::1"} {
+			hosts = append(hosts, net.JoinHostPort(name, port))
+		}
+	}
+	if publicURL != "" {
+		u, err := url.Parse(publicURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid public URL %q", publicURL)
+		}
+		hosts = append(hosts, u.Host)
+		if u.Hostname() != u.Host {
+			// Browsers omit default ports from Host and Origin
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	return hosts, nil
+}
+
+// checkOrigin rejects requests sent to an unknown Host, e.g. through DNS
+// rebinding, and requests from pages of another origin
+func (s *server) checkOrigin(r *http.Request) error {
+	if !s.allowedHosts[strings.ToLower(r.Host)] {
+		return fmt.Errorf("host %q not allowed", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !strings.EqualFold(u.Host, r.Host) {
+			return fmt.Errorf("origin %q not allowed", origin)
+		}
+	}
+	return nil
+}
+
+// authorized reports whether r carries the access token as a bearer token.
+// Browsers never attach it on their own, so other sites cannot forge
+// requests with it.
+func (s *server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.accessToken)) == 1
+}
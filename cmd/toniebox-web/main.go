@@ -0,0 +1,118 @@
+// Command toniebox-web serves a small single-page web UI for managing the
+// Creative-Tonies of your households: list tonies, drag chapters to reorder
+// them, upload audio files and delete chapters.
+//
+// Credentials are read from the TONIEBOX_USERNAME and TONIEBOX_PASSWORD
+// environment variables (or a .env file).
+//
+// Usage:
+//
+//	toniebox-web -addr :8080
+//
+// Voice messages: with a secret set via -message-secret or
+// TONIEBOX_MESSAGE_SECRET, relatives can record voice messages straight onto
+// a tonie through a shared link. Print the link of a tonie with:
+//
+//	toniebox-web -message-link TONIE_ID -public-url https://tonies.example.com
+//
+// The link opens a recording page that posts to /tonies/{id}/messages and
+// only grants access to that tonie.
+//
+// The management API requires an access token that is generated on every
+// start; open the link printed at startup to use the UI. Requests must also
+// name the listen address (or the host of -public-url) in their Host header
+// and come from the same origin, which keeps other web sites and DNS
+// rebinding attacks out. When exposing the server, publish only
+// /message.html and /tonies/ through a reverse proxy anyway.
+//
+// Prometheus metrics of the requests to the Toniecloud are served at /metrics.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/promexport"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	messageSecret := flag.String("message-secret", os.Getenv("TONIEBOX_MESSAGE_SECRET"), "secret that signs voice message links; empty disables voice messages")
+	linkTonie := flag.String("message-link", "", "print the voice message link of this tonie ID and exit")
+	publicURL := flag.String("public-url", "", "base URL under which relatives reach the server (default http://ADDR)")
+	flag.Parse()
+
+	if *linkTonie != "" {
+		if *messageSecret == "" {
+			log.Fatal("Voice message links require -message-secret or TONIEBOX_MESSAGE_SECRET")
+		}
+		base := *publicURL
+		if base == "" {
+			base = "http://" + *addr
+		}
+		fmt.Println(messageLink(base, *messageSecret, *linkTonie))
+		return
+	}
+
+	// It's okay if .env doesn't exist, we might be using env vars directly
+	_ = godotenv.Load()
+
+	username := os.Getenv("TONIEBOX_USERNAME")
+	password := os.Getenv("TONIEBOX_PASSWORD")
+	if username == "" || password == "" {
+		log.Fatal("Please set TONIEBOX_USERNAME and TONIEBOX_PASSWORD environment variables")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	metrics := promexport.NewRegistry()
+	client := toniebox.NewClient(toniebox.WithMetrics(metrics.Client))
+	if _, err := client.LoginContext(ctx, username, password); err != nil {
+		log.Fatalf("Login failed: %v", err)
+	}
+
+	hosts, err := allowedHosts(*addr, *publicURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	accessToken, err := newAccessToken()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Serving Toniebox web UI on http://%s/#token=%s", *addr, accessToken)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.Handle("/", newServer(client, *messageSecret, accessToken, hosts))
+	srv := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	// Requests still running when the timeout passes are canceled, together
+	// with their calls to the Toniecloud
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	if err := client.Close(shutdownCtx); err != nil {
+		log.Printf("Client shutdown: %v", err)
+	}
+	log.Print("Shut down")
+}
+
+// shutdownTimeout bounds how long requests in flight may take on shutdown
+const shutdownTimeout = 10 * time.Second
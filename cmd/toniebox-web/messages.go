@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// messageToken returns the token that authorizes voice messages to a tonie.
+// Tokens are derived from the server secret, so they need not be stored and
+// a leaked link only grants access to a single tonie.
+func messageToken(secret, tonieID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("messages:" + tonieID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// messageLink returns the shared link that lets relatives record and send
+// voice messages to a tonie
+func messageLink(baseURL, secret, tonieID string) string {
+	query := url.Values{"tonie": {tonieID}, "token": {messageToken(secret, tonieID)}}
+	return strings.TrimRight(baseURL, "/") + "/message.html?" + query.Encode()
+}
+
+// handleMessages serves POST /tonies/{tonieID}/messages.
+//
+// The request is authorized with the tonie's message token, given as a
+// "token" query parameter or as a bearer token. The audio is sent either as
+// a multipart form with "file" and an optional "title", or as the raw
+// request body with the title in the "title" query parameter.
+func (s *server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/tonies/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "messages" {
+		http.NotFound(w, r)
+		return
+	}
+	tonieID := parts[0]
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	if s.messageSecret == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("voice messages are not enabled"))
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); bearer != r.Header.Get("Authorization") {
+		token = bearer
+	}
+	if !hmac.Equal([]byte(token), []byte(messageToken(s.messageSecret, tonieID))) {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid message token"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	var audio io.Reader = r.Body
+	title := r.URL.Query().Get("title")
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("missing file: %w", err))
+			return
+		}
+		defer file.Close()
+		audio = file
+		if t := r.FormValue("title"); t != "" {
+			title = t
+		}
+	}
+	if title == "" {
+		title = "Voice message " + time.Now().Format("2006-01-02 15:04")
+	}
+
+	tonie, err := s.findTonie(r.Context(), tonieID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := tonie.UploadReaderContext(r.Context(), title, audio); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if err := tonie.CommitContext(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	// Only confirm the message; the full tonie is not shared with the sender
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, map[string]string{"title": title, "tonie": tonie.Name})
+}
+
+// findTonie looks up a Creative-Tonie by ID across all households
+func (s *server) findTonie(ctx context.Context, tonieID string) (*toniebox.CreativeTonie, error) {
+	households, err := s.client.GetHouseholdsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range households {
+		tonies, err := s.client.GetCreativeToniesContext(ctx, &households[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := range tonies {
+			if tonies[j].ID == tonieID {
+				return &tonies[j], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("creative tonie %s not found", tonieID)
+}
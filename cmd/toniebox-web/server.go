@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// maxUploadSize limits the size of uploaded audio files held in memory
+const maxUploadSize = 32 << 20
+
+// server exposes a JSON API on top of the client and serves the single-page UI
+type server struct {
+	client *toniebox.Client
+	mux    *http.ServeMux
+	// messageSecret signs voice message links; empty disables voice messages
+	messageSecret string
+	// accessToken authorizes requests to the management API below /api/
+	accessToken string
+	// allowedHosts holds the lower-cased Host headers the API answers to
+	allowedHosts map[string]bool
+}
+
+// newServer creates the HTTP handler for the web UI
+func newServer(client *toniebox.Client, messageSecret, accessToken string, hosts []string) *server {
+	s := &server{
+		client:        client,
+		mux:           http.NewServeMux(),
+		messageSecret: messageSecret,
+		accessToken:   accessToken,
+		allowedHosts:  make(map[string]bool),
+	}
+	for _, host := range hosts {
+		s.allowedHosts[strings.ToLower(host)] = true
+	}
+
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+	s.mux.Handle("/", http.FileServer(http.FS(static)))
+	s.mux.HandleFunc("/api/households", s.handleHouseholds)
+	s.mux.HandleFunc("/api/households/", s.handleHousehold)
+	s.mux.HandleFunc("/tonies/", s.handleMessages)
+	return s
+}
+
+// ServeHTTP implements http.Handler. Requests to the management API must
+// come from the UI itself and carry the access token; voice messages are
+// authorized by their own token.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api := strings.HasPrefix(r.URL.Path, "/api/")
+	if api || strings.HasPrefix(r.URL.Path, "/tonies/") {
+		if err := s.checkOrigin(r); err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+	}
+	if api && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or wrong access token, open the link printed at startup"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleHouseholds serves GET /api/households
+func (s *server) handleHouseholds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	households, err := s.client.GetHouseholdsContext(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, households)
+}
+
+// handleHousehold routes all requests below /api/households/{householdID}/tonies
+func (s *server) handleHousehold(w http.ResponseWriter, r *http.Request) {
+	// Path: /api/households/{householdID}/tonies[/{tonieID}[/chapters[/{chapterID}]]]
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/households/"), "/"), "/")
+	if len(parts) < 2 || parts[1] != "tonies" {
+		http.NotFound(w, r)
+		return
+	}
+	householdID := parts[0]
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.listTonies(w, r, householdID)
+	case len(parts) == 4 && parts[3] == "chapters" && r.Method == http.MethodPut:
+		s.reorderChapters(w, r, householdID, parts[2])
+	case len(parts) == 4 && parts[3] == "chapters" && r.Method == http.MethodPost:
+		s.uploadChapter(w, r, householdID, parts[2])
+	case len(parts) == 5 && parts[3] == "chapters" && r.Method == http.MethodDelete:
+		s.deleteChapter(w, r, householdID, parts[2], parts[4])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// listTonies serves GET /api/households/{householdID}/tonies
+func (s *server) listTonies(w http.ResponseWriter, r *http.Request, householdID string) {
+	household, err := s.household(r.Context(), householdID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	tonies, err := s.client.GetCreativeToniesContext(r.Context(), household)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, tonies)
+}
+
+// reorderChapters serves PUT /api/households/{householdID}/tonies/{tonieID}/chapters
+// with a JSON array of chapter IDs in the desired order
+func (s *server) reorderChapters(w http.ResponseWriter, r *http.Request, householdID, tonieID string) {
+	var chapterIDs []string
+	if err := json.NewDecoder(r.Body).Decode(&chapterIDs); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid chapter order: %w", err))
+		return
+	}
+
+	tonie, err := s.tonie(r.Context(), householdID, tonieID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := tonie.ReorderChapters(chapterIDs); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := tonie.CommitContext(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, tonie)
+}
+
+// uploadChapter serves POST /api/households/{householdID}/tonies/{tonieID}/chapters
+// with a multipart form containing "title" and "file"
+func (s *server) uploadChapter(w http.ResponseWriter, r *http.Request, householdID, tonieID string) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	title := r.FormValue("title")
+	if title == "" {
+		title = header.Filename
+	}
+
+	tonie, err := s.tonie(r.Context(), householdID, tonieID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := tonie.UploadReaderContext(r.Context(), title, file); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if err := tonie.CommitContext(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, tonie)
+}
+
+// deleteChapter serves DELETE /api/households/{householdID}/tonies/{tonieID}/chapters/{chapterID}
+func (s *server) deleteChapter(w http.ResponseWriter, r *http.Request, householdID, tonieID, chapterID string) {
+	tonie, err := s.tonie(r.Context(), householdID, tonieID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	tonie.DeleteChapter(&toniebox.Chapter{ID: chapterID})
+	if err := tonie.CommitContext(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, tonie)
+}
+
+// household looks up a household by ID
+func (s *server) household(ctx context.Context, householdID string) (*toniebox.Household, error) {
+	households, err := s.client.GetHouseholdsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range households {
+		if households[i].ID == householdID {
+			return &households[i], nil
+		}
+	}
+	return nil, fmt.Errorf("household %s not found", householdID)
+}
+
+// tonie looks up a Creative-Tonie by household and tonie ID
+func (s *server) tonie(ctx context.Context, householdID, tonieID string) (*toniebox.CreativeTonie, error) {
+	household, err := s.household(ctx, householdID)
+	if err != nil {
+		return nil, err
+	}
+	tonies, err := s.client.GetCreativeToniesContext(ctx, household)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tonies {
+		if tonies[i].ID == tonieID {
+			return &tonies[i], nil
+		}
+	}
+	return nil, fmt.Errorf("creative tonie %s not found", tonieID)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// writeError writes err as a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
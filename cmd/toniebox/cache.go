@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mikeboe/toniebox-api-go/store"
+)
+
+// cachePath returns the location of the local state cache. It can be
+// overridden with the TONIEBOX_CACHE environment variable.
+func cachePath() (string, error) {
+	if path := os.Getenv("TONIEBOX_CACHE"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "toniebox", "state.db"), nil
+}
+
+// openCache opens the local state cache, creating it if necessary
+func openCache() (*store.Store, error) {
+	path, err := cachePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return store.Open(path)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// runChaptersRemove implements "toniebox chapters rm"
+func runChaptersRemove(args []string) error {
+	fs := flag.NewFlagSet("chapters rm", flag.ExitOnError)
+	household := fs.String("household", "", "household of the tonie (name or ID)")
+	tonieName := fs.String("tonie", "", "Creative-Tonie to clean up (name or ID, required)")
+	match := fs.String("match", "", "glob pattern matched against chapter titles, e.g. 'Folge *' (required)")
+	dryRun := fs.Bool("dry-run", false, "only list the matching chapters")
+	yes := fs.Bool("yes", false, "delete without asking for confirmation")
+	fs.Parse(args)
+	if *tonieName == "" || *match == "" {
+		fs.Usage()
+		return fmt.Errorf("--tonie and --match are required")
+	}
+	if _, err := path.Match(*match, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", *match, err)
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	tonie, err := findTonie(client, *household, *tonieName)
+	if err != nil {
+		return err
+	}
+
+	var matched []toniebox.Chapter
+	for _, chapter := range tonie.Chapters {
+		if ok, _ := path.Match(*match, chapter.Title); ok {
+			matched = append(matched, chapter)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Printf("No chapters of %q match %q.\n", tonie.Name, *match)
+		return nil
+	}
+
+	fmt.Printf("Chapters of %q matching %q:\n", tonie.Name, *match)
+	for _, chapter := range matched {
+		fmt.Printf("  - %s\n", chapter.Title)
+	}
+	if *dryRun {
+		fmt.Printf("Dry run: %d chapter(s) would be deleted.\n", len(matched))
+		return nil
+	}
+	if !*yes && !confirm(fmt.Sprintf("Delete %d chapter(s)?", len(matched))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	for i := range matched {
+		tonie.DeleteChapter(&matched[i])
+	}
+	if err := tonie.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %d chapter(s).\n", len(matched))
+	return nil
+}
+
+// confirm asks a yes/no question on stdin and defaults to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
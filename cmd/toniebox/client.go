@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// newClient creates a logged-in client from the environment
+func newClient() (*toniebox.Client, error) {
+	// It's okay if .env doesn't exist, we might be using env vars directly
+	_ = godotenv.Load()
+
+	if path := simulationPath(); path != "" {
+		return newSimulatedClient(path)
+	}
+
+	username := os.Getenv("TONIEBOX_USERNAME")
+	password := os.Getenv("TONIEBOX_PASSWORD")
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("please set TONIEBOX_USERNAME and TONIEBOX_PASSWORD environment variables")
+	}
+
+	// Households and tonies are cached locally for shell completion;
+	// the CLI keeps working if the cache is unavailable
+	var opts []toniebox.Option
+	if clientID := os.Getenv("TONIEBOX_CLIENT_ID"); clientID != "" {
+		opts = append(opts, toniebox.WithClientID(clientID))
+	}
+	if scopes := strings.Fields(os.Getenv("TONIEBOX_SCOPES")); len(scopes) > 0 {
+		opts = append(opts, toniebox.WithScopes(scopes...))
+	}
+	if cache, err := openCache(); err == nil {
+		opts = append(opts, toniebox.WithStaleCache(cache))
+	}
+
+	client := toniebox.NewClient(opts...)
+	if _, err := client.Login(username, password); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	return client, nil
+}
+
+// selectHouseholds returns all households, or only the one matching name or ID
+func selectHouseholds(client *toniebox.Client, name string) ([]toniebox.Household, error) {
+	households, err := client.GetHouseholds()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return households, nil
+	}
+	for _, household := range households {
+		if household.Name == name || household.ID == name {
+			return []toniebox.Household{household}, nil
+		}
+	}
+	return nil, fmt.Errorf("household %q not found", name)
+}
+
+// findTonie looks up a Creative-Tonie by name or ID, optionally restricted
+// to one household
+func findTonie(client *toniebox.Client, householdName, tonieName string) (*toniebox.CreativeTonie, error) {
+	households, err := selectHouseholds(client, householdName)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*toniebox.CreativeTonie
+	for i := range households {
+		tonies, err := client.GetCreativeTonies(&households[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := range tonies {
+			if tonies[j].Name == tonieName || tonies[j].ID == tonieName {
+				found = append(found, &tonies[j])
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("creative tonie %q not found", tonieName)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("creative tonie name %q is ambiguous, use --household or the tonie ID", tonieName)
+	}
+}
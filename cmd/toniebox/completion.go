@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// completeCommand is the hidden command used by completion scripts to list
+// resource names from the local cache without touching the network
+const completeCommand = "__complete"
+
+// runCompletion implements "toniebox completion <shell>"
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: toniebox completion bash|zsh|fish")
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return fmt.Errorf("unsupported shell %q", args[0])
+	}
+
+	resources, verbs := commandWords()
+	script = strings.NewReplacer("{{resources}}", resources, "{{verbs}}", verbs).Replace(script)
+	_, err := fmt.Print(script)
+	return err
+}
+
+// commandWords returns the known resources and verbs as space-separated lists
+func commandWords() (string, string) {
+	var resources, verbs []string
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		resource, verb, _ := strings.Cut(cmd.name, " ")
+		if !seen[resource] {
+			resources = append(resources, resource)
+			seen[resource] = true
+		}
+		if !seen[" "+verb] {
+			verbs = append(verbs, verb)
+			seen[" "+verb] = true
+		}
+	}
+	resources = append(resources, "upload", "completion")
+	return strings.Join(resources, " "), strings.Join(verbs, " ")
+}
+
+// runComplete implements "toniebox __complete households|tonies [--household NAME]",
+// printing one cached name per line. Errors are swallowed so that a missing
+// cache never breaks the shell.
+func runComplete(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	fs := flag.NewFlagSet(completeCommand, flag.ContinueOnError)
+	household := fs.String("household", "", "")
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil
+	}
+
+	cache, err := openCache()
+	if err != nil {
+		return nil
+	}
+	defer cache.Close()
+
+	households, _, err := cache.Households()
+	if err != nil {
+		return nil
+	}
+
+	switch args[0] {
+	case "households":
+		for _, h := range households {
+			fmt.Fprintln(os.Stdout, h.Name)
+		}
+	case "tonies":
+		for _, h := range households {
+			if *household != "" && h.Name != *household && h.ID != *household {
+				continue
+			}
+			tonies, _, err := cache.CreativeTonies(h.ID)
+			if err != nil {
+				continue
+			}
+			for _, t := range tonies {
+				fmt.Fprintln(os.Stdout, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+const bashCompletion = `# bash completion for toniebox
+_toniebox() {
+    local cur prev words cword
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "$prev" in
+        --household)
+            local IFS=$'\n'
+            COMPREPLY=($(compgen -W "$(toniebox __complete households 2>/dev/null)" -- "$cur"))
+            COMPREPLY=("${COMPREPLY[@]// /\\ }")
+            return ;;
+        --tonie)
+            local household="" i
+            for ((i = 1; i < COMP_CWORD; i++)); do
+                [[ "${COMP_WORDS[i]}" == --household ]] && household="${COMP_WORDS[i+1]//\\ / }"
+            done
+            local IFS=$'\n'
+            COMPREPLY=($(compgen -W "$(toniebox __complete tonies --household "$household" 2>/dev/null)" -- "$cur"))
+            COMPREPLY=("${COMPREPLY[@]// /\\ }")
+            return ;;
+    esac
+
+    case "$COMP_CWORD" in
+        1) COMPREPLY=($(compgen -W "{{resources}}" -- "$cur")) ;;
+        2)
+            if [[ "${COMP_WORDS[1]}" == completion ]]; then
+                COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            elif [[ "${COMP_WORDS[1]}" == upload ]]; then
+                COMPREPLY=($(compgen -W "--household --tonie --title --raw --chapter-duration" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -W "{{verbs}}" -- "$cur"))
+            fi ;;
+        *) COMPREPLY=($(compgen -W "--household --tonie --match --dry-run --yes --title --raw --chapter-duration" -- "$cur")) ;;
+    esac
+}
+complete -F _toniebox toniebox
+`
+
+const zshCompletion = `#compdef toniebox
+# zsh completion for toniebox
+_toniebox() {
+    local -a names
+    case "${words[CURRENT-1]}" in
+        --household)
+            names=("${(@f)$(toniebox __complete households 2>/dev/null)}")
+            compadd -a names
+            return ;;
+        --tonie)
+            local household=${words[${words[(i)--household]}+1]}
+            names=("${(@f)$(toniebox __complete tonies --household "$household" 2>/dev/null)}")
+            compadd -a names
+            return ;;
+    esac
+
+    case $CURRENT in
+        2) compadd {{resources}} ;;
+        3)
+            if [[ ${words[2]} == completion ]]; then
+                compadd bash zsh fish
+            elif [[ ${words[2]} == upload ]]; then
+                compadd -- --household --tonie --title --raw --chapter-duration
+            else
+                compadd {{verbs}}
+            fi ;;
+        *) compadd -- --household --tonie --match --dry-run --yes --title --raw --chapter-duration ;;
+    esac
+}
+compdef _toniebox toniebox
+`
+
+const fishCompletion = `# fish completion for toniebox
+function __toniebox_household
+    set -l tokens (commandline -opc)
+    set -l index (contains -i -- --household $tokens)
+    and echo $tokens[(math $index + 1)]
+end
+
+complete -c toniebox -f
+complete -c toniebox -n "__fish_is_nth_token 1" -a "{{resources}}"
+complete -c toniebox -n "__fish_is_nth_token 2; and not __fish_seen_subcommand_from completion upload" -a "{{verbs}}"
+complete -c toniebox -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c toniebox -l household -x -a "(toniebox __complete households 2>/dev/null)"
+complete -c toniebox -l tonie -x -a "(toniebox __complete tonies --household (__toniebox_household) 2>/dev/null)"
+complete -c toniebox -l match -x
+complete -c toniebox -l dry-run
+complete -c toniebox -l yes
+complete -c toniebox -l title -x
+complete -c toniebox -l raw
+complete -c toniebox -l chapter-duration -x
+`
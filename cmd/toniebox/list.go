@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runHouseholdsList implements "toniebox households ls"
+func runHouseholdsList(args []string) error {
+	fs := flag.NewFlagSet("households ls", flag.ExitOnError)
+	fs.Parse(args)
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	households, err := client.GetHouseholds()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tACCESS")
+	for _, household := range households {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", household.ID, household.Name, household.Access)
+	}
+	return tw.Flush()
+}
+
+// runToniesList implements "toniebox tonies ls"
+func runToniesList(args []string) error {
+	fs := flag.NewFlagSet("tonies ls", flag.ExitOnError)
+	household := fs.String("household", "", "only list tonies of this household (name or ID)")
+	fs.Parse(args)
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	households, err := selectHouseholds(client, *household)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tHOUSEHOLD\tCHAPTERS\tFREE")
+	for i := range households {
+		tonies, err := client.GetCreativeTonies(&households[i])
+		if err != nil {
+			return err
+		}
+		for _, tonie := range tonies {
+			free := time.Duration(tonie.SecondsRemaining) * time.Second
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", tonie.ID, tonie.Name, households[i].Name, tonie.ChaptersPresent, free)
+		}
+	}
+	return tw.Flush()
+}
+
+// runChaptersList implements "toniebox chapters ls"
+func runChaptersList(args []string) error {
+	fs := flag.NewFlagSet("chapters ls", flag.ExitOnError)
+	household := fs.String("household", "", "household of the tonie (name or ID)")
+	tonieName := fs.String("tonie", "", "Creative-Tonie to list (name or ID, required)")
+	fs.Parse(args)
+	if *tonieName == "" {
+		fs.Usage()
+		return fmt.Errorf("--tonie is required")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	tonie, err := findTonie(client, *household, *tonieName)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTITLE\tSTART\tLENGTH")
+	for _, track := range tonie.Tracklist() {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", track.Number, track.Title,
+			track.Start.Round(time.Second), track.Duration.Round(time.Second))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,122 @@
+// Command toniebox is a command-line interface for managing Creative-Tonies.
+//
+// Credentials are read from the TONIEBOX_USERNAME and TONIEBOX_PASSWORD
+// environment variables (or a .env file). Accounts of other frontends can
+// set TONIEBOX_CLIENT_ID and TONIEBOX_SCOPES (space-separated) to log in
+// with their OAuth client registration.
+//
+// Usage:
+//
+//	toniebox households ls
+//	toniebox tonies ls [--household NAME]
+//	toniebox chapters ls --tonie NAME [--household NAME]
+//	toniebox chapters rm --tonie NAME --match PATTERN [--household NAME] [--dry-run] [--yes]
+//	toniebox state export [--out FILE]
+//	toniebox upload --tonie NAME [--household NAME] [--title TITLE] [--raw] [--chapter-duration D] FILE|-
+//	toniebox completion bash|zsh|fish
+//
+// Simulation mode rehearses changes without touching the real account: set
+// TONIEBOX_SIMULATE to a snapshot written by "toniebox state export" and all
+// commands run against an in-process fake API seeded from it. The snapshot
+// file is updated after every successful command, so work on a copy:
+//
+//	toniebox state export --out snapshot.json
+//	cp snapshot.json rehearsal.json
+//	TONIEBOX_SIMULATE=rehearsal.json toniebox chapters rm --tonie Kids --match 'Old*' --yes
+//
+// "toniebox upload -" reads the audio from stdin, so recordings can be piped
+// in directly. With ffmpeg installed, the input is encoded to MP3 on the fly;
+// raw PCM (--raw) is otherwise buffered and uploaded as WAV:
+//
+//	arecord -f cd | toniebox upload --tonie Kids --title "Good Night" -
+//	arecord -f cd -t raw | toniebox upload --tonie Kids --raw --chapter-duration 20m -
+//
+// Household and tonie names are cached locally (see TONIEBOX_CACHE) and used
+// for shell completion. Enable completion with e.g.:
+//
+//	source <(toniebox completion bash)
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a CLI subcommand
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands lists all subcommands, keyed by "<resource> <verb>"
+var commands = []command{
+	{"households ls", "List households", runHouseholdsList},
+	{"tonies ls", "List Creative-Tonies", runToniesList},
+	{"chapters ls", "List the chapters of a Creative-Tonie", runChaptersList},
+	{"chapters rm", "Delete chapters matching a pattern", runChaptersRemove},
+	{"state export", "Write a snapshot of the account as JSON", runStateExport},
+}
+
+func main() {
+	// Commands without a verb
+	if len(os.Args) >= 2 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "completion":
+			run = runCompletion
+		case "upload":
+			run = runUpload
+		case completeCommand:
+			run = runComplete
+		}
+		if run != nil {
+			err := run(os.Args[2:])
+			if err == nil {
+				err = saveSimulation()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	if len(os.Args) < 3 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1] + " " + os.Args[2]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			err := cmd.run(os.Args[3:])
+			if err == nil {
+				err = saveSimulation()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	printUsage()
+	os.Exit(2)
+}
+
+// printUsage prints the list of subcommands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: toniebox <resource> <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-15s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "  %-15s %s\n", "upload", "Upload an audio file, or a recording from stdin with -")
+	fmt.Fprintf(os.Stderr, "  %-15s %s\n", "completion", "Print a shell completion script (bash, zsh, fish)")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'toniebox <resource> <command> -h' for the flags of a command.")
+}
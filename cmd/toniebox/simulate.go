@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/tonieboxtest"
+)
+
+// simulation is the fake API used when TONIEBOX_SIMULATE is set
+var simulation *tonieboxtest.Server
+
+// simulationPath returns the state file of simulation mode, or "" if the
+// CLI talks to the real API
+func simulationPath() string {
+	return os.Getenv("TONIEBOX_SIMULATE")
+}
+
+// newSimulatedClient returns a client backed by an in-process fake API seeded
+// from the state file at path
+func newSimulatedClient(path string) (*toniebox.Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulation state: %w", err)
+	}
+	var state toniebox.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse simulation state: %w", err)
+	}
+	simulation = tonieboxtest.NewServer(&state)
+	return simulation.Client(), nil
+}
+
+// saveSimulation writes the state of the fake API back to the state file,
+// so that consecutive commands build on each other
+func saveSimulation() error {
+	if simulation == nil {
+		return nil
+	}
+	return writeState(simulationPath(), simulation.State())
+}
+
+// writeState writes state as canonical JSON to path, or to stdout if path is "-"
+func writeState(path string, state *toniebox.State) error {
+	data, err := toniebox.MarshalCanonical(state)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// runStateExport implements "toniebox state export"
+func runStateExport(args []string) error {
+	fs := flag.NewFlagSet("state export", flag.ExitOnError)
+	out := fs.String("out", "-", "file to write the snapshot to")
+	fs.Parse(args)
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	state, err := client.ExportState()
+	if err != nil {
+		return err
+	}
+	return writeState(*out, state)
+}
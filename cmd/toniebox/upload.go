@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// runUpload implements "toniebox upload". The audio is read from a file or,
+// with "-", from stdin, so a fresh recording can be piped in directly:
+//
+//	arecord -f cd | toniebox upload --tonie Kids -
+func runUpload(args []string) error {
+	fs := flag.NewFlagSet("upload", flag.ExitOnError)
+	household := fs.String("household", "", "household of the tonie (name or ID)")
+	tonieName := fs.String("tonie", "", "Creative-Tonie to upload to (name or ID, required)")
+	title := fs.String("title", "", "chapter title (default: file name, or \"Recording\" and the time for stdin)")
+	raw := fs.Bool("raw", false, "input is raw PCM without a header, see --rate, --channels and --bits")
+	rate := fs.Int("rate", 44100, "sample rate of raw PCM input in Hz")
+	channels := fs.Int("channels", 2, "channels of raw PCM input")
+	bits := fs.Int("bits", 16, "bits per sample of raw PCM input (8, 16, 24 or 32)")
+	bitrate := fs.String("bitrate", "128k", "bitrate of the MP3 encoded with ffmpeg")
+	chapterDuration := fs.Duration("chapter-duration", 0, "split long recordings into chapters of at most this duration, e.g. 20m (requires ffmpeg)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: toniebox upload --tonie NAME [flags] FILE|-")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	// Flags may also follow the file
+	var source string
+	if fs.NArg() > 0 {
+		source = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+	if *tonieName == "" || source == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("--tonie and exactly one FILE or - are required")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	tonie, err := findTonie(client, *household, *tonieName)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if source != "-" {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+		if *title == "" {
+			*title = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		}
+	} else {
+		if *title == "" {
+			*title = "Recording " + time.Now().Format("2006-01-02 15:04")
+		}
+		// Ctrl+C also stops the recorder feeding stdin; keep going and
+		// upload what was recorded unless it is pressed again
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		defer signal.Stop(interrupts)
+		go func() {
+			<-interrupts
+			fmt.Fprintln(os.Stderr, "Finishing the upload, press Ctrl+C again to abort.")
+			<-interrupts
+			os.Exit(130)
+		}()
+	}
+
+	encoder := &audio.StreamEncoder{Bitrate: *bitrate}
+	if *raw {
+		encoder.PCM = &audio.PCMFormat{SampleRate: *rate, Channels: *channels, BitDepth: *bits}
+	}
+	encoded, err := encoder.Encode(context.Background(), in)
+	if err != nil {
+		return err
+	}
+	defer encoded.Close()
+
+	if *chapterDuration > 0 {
+		err = tonie.UploadStream(*title, encoded, toniebox.StreamOptions{ChapterDuration: *chapterDuration})
+	} else {
+		err = tonie.UploadReader(*title, encoded)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tonie.Commit(); err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded %q to %q.\n", *title, tonie.Name)
+	return nil
+}
@@ -1,13 +1,20 @@
 package toniebox
 
 const (
-	// API endpoints
+	// API endpoints. Only endpoints with a known source are listed: those
+	// used since the first release of this client, the token revocation
+	// endpoint advertised by the Keycloak discovery document of the login
+	// realm, and the config endpoint used by the Python tonie-api client
+	// (github.com/Wilhelmsen/tonie-api). The Toniecloud API is undocumented;
+	// do not add endpoints that have not been observed.
 	openIDConnect    = "https://login.tonies.com/auth/realms/tonies/protocol/openid-connect/token"
+	openIDRevoke     = "https://login.tonies.com/auth/realms/tonies/protocol/openid-connect/revoke"
 	creativeTonies   = "https://api.tonie.cloud/v2/households/%s/creativetonies"
 	creativeTonie    = "https://api.tonie.cloud/v2/households/%s/creativetonies/%s"
 	session          = "https://api.tonie.cloud/v2/sessions"
 	me               = "https://api.tonie.cloud/v2/me"
 	households       = "https://api.tonie.cloud/v2/households"
+	config           = "https://api.tonie.cloud/v2/config"
 	fileUpload       = "https://api.tonie.cloud/v2/file"
 	fileUploadAmazon = "https://bxn-toniecloud-prod-upload.s3.amazonaws.com/"
 
@@ -16,7 +23,8 @@ const (
 	contentTypeForm = "application/x-www-form-urlencoded"
 
 	// OAuth parameters
-	grantTypePassword = "password"
-	clientID          = "my-tonies"
-	scopeOpenID       = "openid"
+	grantTypePassword     = "password"
+	grantTypeRefreshToken = "refresh_token"
+	clientID              = "my-tonies"
+	scopeOpenID           = "openid"
 )
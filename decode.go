@@ -0,0 +1,508 @@
+package toniebox
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Creative-Tonie payloads are dominated by their chapter lists: a household
+// with a few full tonies carries thousands of chapters. The client therefore
+// reads them from the response one tonie at a time and decodes the chapters
+// with a small hand-written parser that allocates one string per chapter
+// instead of one per field and avoids reflection. The JSON is validated by
+// encoding/json as it is read, and anything the parser does not expect
+// (escaped strings, null values, differently cased or duplicate keys) is left
+// to encoding/json, so the result is the same as with json.Unmarshal.
+// BenchmarkDecodeCreativeTonies compares both.
+
+// decodeCreativeTonies reads a JSON array of Creative-Tonies from r. The
+// array is consumed element by element, so only one tonie is held in memory
+// besides the decoded result, and encoding/json never has to decode the
+// chapter lists, which make up most of the payload.
+func decodeCreativeTonies(r io.Reader) ([]CreativeTonie, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, decodeEnd(dec)
+	}
+	if tok != json.Delim('[') {
+		return nil, &json.UnmarshalTypeError{Value: tokenKind(tok), Type: reflect.TypeOf([]CreativeTonie(nil))}
+	}
+
+	tonies := []CreativeTonie{}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		var tonie CreativeTonie
+		if err := decodeTonieValue(raw, &tonie); err != nil {
+			return nil, err
+		}
+		tonies = append(tonies, tonie)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return tonies, decodeEnd(dec)
+}
+
+// decodeCreativeTonie reads a JSON Creative-Tonie from r into ct
+func decodeCreativeTonie(r io.Reader, ct *CreativeTonie) error {
+	dec := json.NewDecoder(r)
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if err := decodeEnd(dec); err != nil {
+		return err
+	}
+	return decodeTonieValue(raw, ct)
+}
+
+// decodeEnd fails if dec has anything but whitespace left, as json.Unmarshal
+// does
+func decodeEnd(dec *json.Decoder) error {
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errInvalidJSON
+		}
+		return err
+	}
+	return nil
+}
+
+// tokenKind describes a JSON token for an *json.UnmarshalTypeError
+func tokenKind(tok json.Token) string {
+	switch tok.(type) {
+	case json.Delim:
+		return "object"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	}
+	return "value"
+}
+
+// decodeTonieValue decodes the valid JSON value data into ct. The chapter
+// list is cut out and parsed by parseChapters; the remaining fields are
+// decoded by encoding/json.
+func decodeTonieValue(data []byte, ct *CreativeTonie) error {
+	start, end, ok := memberSpan(data, "chapters")
+	if !ok {
+		return json.Unmarshal(data, ct)
+	}
+
+	rest := make([]byte, 0, len(data)-(end-start)+4)
+	rest = append(rest, data[:start]...)
+	rest = append(rest, "null"...)
+	rest = append(rest, data[end:]...)
+	if err := json.Unmarshal(rest, ct); err != nil {
+		return err
+	}
+	chapters, err := parseChapters(data[start:end])
+	if err != nil {
+		return err
+	}
+	ct.Chapters = chapters
+	return nil
+}
+
+// memberSpan returns the span of the value of key in the JSON object data.
+// It reports false if the key is missing, appears more than once, also
+// appears in another case, or data is not a simple object.
+func memberSpan(data []byte, key string) (int, int, bool) {
+	p := jsonParser{data: data}
+	if !p.consume('{') || p.consume('}') {
+		return 0, 0, false
+	}
+	start, end, found := 0, 0, false
+	for {
+		name, ok := p.rawString()
+		if !ok || !p.consume(':') {
+			return 0, 0, false
+		}
+		p.skipSpace()
+		valueStart := p.pos
+		if !p.skipValue() {
+			return 0, 0, false
+		}
+		if bytes.EqualFold(name, []byte(key)) {
+			// encoding/json lets the last of several matching keys win
+			if found || string(name) != key {
+				return 0, 0, false
+			}
+			start, end, found = valueStart, p.pos, true
+		}
+		if p.consume(',') {
+			continue
+		}
+		if p.consume('}') && p.end() {
+			return start, end, found
+		}
+		return 0, 0, false
+	}
+}
+
+// parseChapters decodes a JSON array of chapters, or null
+func parseChapters(data []byte) ([]Chapter, error) {
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	p := jsonParser{data: data}
+	if !p.consume('[') {
+		return nil, &json.UnmarshalTypeError{Value: "non-array", Type: reflect.TypeOf([]Chapter(nil)), Field: "chapters"}
+	}
+	// A chapter takes about 150 bytes of JSON
+	chapters := make([]Chapter, 0, len(data)/150+1)
+	if p.consume(']') {
+		return chapters, nil
+	}
+	for {
+		p.skipSpace()
+		start := p.pos
+		var ch Chapter
+		if !parseChapter(&p, &ch) {
+			p.pos = start
+			if !p.skipValue() {
+				return nil, errInvalidJSON
+			}
+			var err error
+			if ch, err = decodeChapter(data[start:p.pos]); err != nil {
+				return nil, err
+			}
+		}
+		chapters = append(chapters, ch)
+		if p.consume(',') {
+			continue
+		}
+		if p.consume(']') {
+			return chapters, nil
+		}
+		return nil, errInvalidJSON
+	}
+}
+
+// decodeChapter decodes a chapter with encoding/json
+func decodeChapter(data []byte) (Chapter, error) {
+	var ch Chapter
+	err := json.Unmarshal(data, &ch)
+	return ch, err
+}
+
+// errInvalidJSON is returned for malformed JSON found by the fast scanner
+var errInvalidJSON = errors.New("invalid JSON")
+
+// chapterKeys are the JSON keys of Chapter, used to detect keys that only
+// match case-insensitively
+var chapterKeys = []string{"id", "file", "title", "seconds", "transcoding"}
+
+// parseChapter decodes the chapter object at the cursor on top of ch. It
+// reports false if the object needs the general decoder; ch is unchanged
+// in that case.
+func parseChapter(p *jsonParser, ch *Chapter) bool {
+	if !p.consume('{') {
+		return false
+	}
+
+	// Values are collected as spans of data and copied into one string at
+	// the end: ID, file, title and the seconds literal, which is parsed from
+	// that string to avoid another allocation
+	var spans [4][2]int
+	var present [4]bool
+	transcoding := ch.Transcoding
+	if !p.consume('}') {
+		for {
+			key, ok := p.rawString()
+			if !ok || !p.consume(':') {
+				return false
+			}
+			p.skipSpace()
+			field := -1
+			switch string(key) {
+			case "id":
+				field = 0
+			case "file":
+				field = 1
+			case "title":
+				field = 2
+			case "seconds":
+				start, end, ok := p.number()
+				if !ok {
+					return false
+				}
+				spans[3] = [2]int{start, end}
+				present[3] = true
+			case "transcoding":
+				if transcoding, ok = p.boolean(); !ok {
+					return false
+				}
+			default:
+				if foldsToChapterKey(key) || !p.skipValue() {
+					return false
+				}
+			}
+			if field >= 0 {
+				start := p.pos + 1
+				if _, ok := p.rawString(); !ok {
+					return false
+				}
+				spans[field] = [2]int{start, p.pos - 1}
+				present[field] = true
+			}
+
+			if p.consume(',') {
+				continue
+			}
+			if p.consume('}') {
+				break
+			}
+			return false
+		}
+	}
+
+	var b strings.Builder
+	size := 0
+	for i, span := range spans {
+		if present[i] {
+			size += span[1] - span[0]
+		}
+	}
+	b.Grow(size)
+	for i, span := range spans {
+		if present[i] {
+			b.Write(p.data[span[0]:span[1]])
+		}
+	}
+	all := b.String()
+
+	decoded := *ch
+	decoded.Transcoding = transcoding
+	targets := [...]*string{&decoded.ID, &decoded.File, &decoded.Title}
+	offset := 0
+	for i, span := range spans {
+		if !present[i] {
+			continue
+		}
+		value := all[offset : offset+span[1]-span[0]]
+		offset += len(value)
+		if i < len(targets) {
+			*targets[i] = value
+			continue
+		}
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		decoded.Seconds = seconds
+	}
+	*ch = decoded
+	return true
+}
+
+// foldsToChapterKey reports whether key matches a Chapter key only
+// case-insensitively, which encoding/json accepts as well
+func foldsToChapterKey(key []byte) bool {
+	for _, name := range chapterKeys {
+		if bytes.EqualFold(key, []byte(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonParser is a minimal cursor over a JSON document. Its methods report
+// false for anything outside the simple cases they handle.
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+// skipSpace advances past insignificant whitespace
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume advances past c if it is the next significant byte
+func (p *jsonParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// end reports whether only whitespace is left
+func (p *jsonParser) end() bool {
+	p.skipSpace()
+	return p.pos == len(p.data)
+}
+
+// rawString reads a string without escape sequences and returns its
+// contents, which are valid UTF-8
+func (p *jsonParser) rawString() ([]byte, bool) {
+	if !p.consume('"') {
+		return nil, false
+	}
+	end := bytes.IndexByte(p.data[p.pos:], '"')
+	if end < 0 {
+		return nil, false
+	}
+	s := p.data[p.pos : p.pos+end]
+	for _, c := range s {
+		if c == '\\' || c < 0x20 {
+			return nil, false
+		}
+	}
+	p.pos += end + 1
+	return s, utf8.Valid(s)
+}
+
+// number reads a JSON number and returns its span. It follows the JSON
+// grammar strictly: an optional minus, an integer without leading zeros, an
+// optional fraction and an optional exponent, each with at least one digit.
+func (p *jsonParser) number() (int, int, bool) {
+	start := p.pos
+	if p.pos < len(p.data) && p.data[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '0' {
+		p.pos++
+	} else if !p.digits() {
+		return 0, 0, false
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		p.pos++
+		if !p.digits() {
+			return 0, 0, false
+		}
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if !p.digits() {
+			return 0, 0, false
+		}
+	}
+	return start, p.pos, true
+}
+
+// digits advances past a run of decimal digits and reports whether there
+// was at least one
+func (p *jsonParser) digits() bool {
+	start := p.pos
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// boolean reads true or false
+func (p *jsonParser) boolean() (bool, bool) {
+	rest := p.data[p.pos:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("true")):
+		p.pos += 4
+		return true, true
+	case bytes.HasPrefix(rest, []byte("false")):
+		p.pos += 5
+		return false, true
+	}
+	return false, false
+}
+
+// skipValue skips any value, including nested objects and arrays. It only
+// checks the structure, so it must only be used on JSON that has been
+// validated, such as values read by a json.Decoder.
+func (p *jsonParser) skipValue() bool {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return false
+	}
+	switch c := p.data[p.pos]; {
+	case c == '"':
+		return p.skipString()
+	case c == '{' || c == '[':
+		return p.skipNested()
+	case c == 't' || c == 'f':
+		_, ok := p.boolean()
+		return ok
+	case c == 'n':
+		if bytes.HasPrefix(p.data[p.pos:], []byte("null")) {
+			p.pos += 4
+			return true
+		}
+		return false
+	case c == '-' || (c >= '0' && c <= '9'):
+		_, _, ok := p.number()
+		return ok
+	}
+	return false
+}
+
+// skipString skips a string, including escape sequences
+func (p *jsonParser) skipString() bool {
+	open := p.pos
+	p.pos++
+	for {
+		i := bytes.IndexByte(p.data[p.pos:], '"')
+		if i < 0 {
+			return false
+		}
+		p.pos += i + 1
+		// The quote is escaped if preceded by an odd number of backslashes
+		backslashes := 0
+		for j := p.pos - 2; j > open && p.data[j] == '\\'; j-- {
+			backslashes++
+		}
+		if backslashes%2 == 0 {
+			return true
+		}
+	}
+}
+
+// skipNested skips an object or array by matching brackets
+func (p *jsonParser) skipNested() bool {
+	depth := 0
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '"':
+			if !p.skipString() {
+				return false
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return true
+			}
+		}
+		p.pos++
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package toniebox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDecodeCreativeTonieParity checks that the chapter parser accepts,
+// rejects and decodes payloads exactly like json.Unmarshal
+func TestDecodeCreativeTonieParity(t *testing.T) {
+	tonies := []string{
+		`{"id":"t","chapters":[{"id":"a","file":"f","title":"A","seconds":1.5,"transcoding":false}]}`,
+		`{"id":"t","chapters":[]}`,
+		`{"id":"t","chapters":null}`,
+		`{"id":"t"}`,
+		`{}`,
+		`null`,
+		` {"id":"t","chapters":[{"id":"a","seconds":0}]} `,
+
+		// Numbers
+		`{"chapters":[{"id":"a","seconds":1.}]}`,
+		`{"chapters":[{"id":"a","seconds":.5}]}`,
+		`{"chapters":[{"id":"a","seconds":01}]}`,
+		`{"chapters":[{"id":"a","seconds":-}]}`,
+		`{"chapters":[{"id":"a","seconds":1e}]}`,
+		`{"chapters":[{"id":"a","seconds":1e+}]}`,
+		`{"chapters":[{"id":"a","seconds":1.5e-3}]}`,
+		`{"chapters":[{"id":"a","seconds":-0.25E2}]}`,
+		`{"chapters":[{"id":"a","seconds":1-2}]}`,
+		`{"chapters":[{"id":"a","seconds":+1}]}`,
+
+		// Skipped values
+		`{"chapters":[{"id":"a","x":[1 2]}]}`,
+		`{"chapters":[{"id":"a","x":{"y" 1}}]}`,
+		`{"chapters":[{"id":"a","x":[1,2,{"y":[true,null]}]}]}`,
+		`{"x":[1 2],"chapters":[]}`,
+		`{"chapters":[{"id":"a"}],"x":{]}`,
+		`[{"chapters":[]} {"chapters":[]}]`,
+
+		// Keys
+		`{"chapters":[{"id":"a"}],"chapters":[{"id":"b"}]}`,
+		`{"chapters":[{"id":"a"}],"Chapters":[{"id":"b"}]}`,
+		`{"CHAPTERS":[{"id":"a"}]}`,
+		`{"chapters":[{"id":"a","id":"b","seconds":1,"seconds":2}]}`,
+		`{"chapters":[{"id":"a","ID":"b"}]}`,
+		`{"chapters":[{"id":"a","transcoding":true,"transcoding":false}]}`,
+		`{"chapters":[{"id":"a","id":null}]}`,
+		`{"chapters":[{"id":"aä","title":"\"quoted\""}]}`,
+
+		// Malformed
+		`{"chapters":[{"id":"a"}]`,
+		`{"chapters":[{"id":"a"},]}`,
+		`{"chapters":{"id":"a"}}`,
+		`{"chapters":[{"id":1}]}`,
+		`{"chapters":[{"seconds":"1"}]}`,
+		`{"chapters":[]} x`,
+		`{"chapters":[]} {}`,
+		`{"chapters":[null,{"id":"a"}]}`,
+	}
+
+	for _, payload := range tonies {
+		var want CreativeTonie
+		wantErr := json.Unmarshal([]byte(payload), &want)
+		var got CreativeTonie
+		gotErr := decodeCreativeTonie(strings.NewReader(payload), &got)
+		compareDecoded(t, payload, want, wantErr, got, gotErr)
+
+		list := "[" + payload + "]"
+		var wantList []CreativeTonie
+		wantErr = json.Unmarshal([]byte(list), &wantList)
+		gotList, gotErr := decodeCreativeTonies(strings.NewReader(list))
+		compareDecoded(t, list, wantList, wantErr, gotList, gotErr)
+	}
+
+	for _, list := range []string{`[]`, `null`, `{}`, `"x"`, `[`, `[{"id":"a"}] ]`, `[1]`} {
+		var want []CreativeTonie
+		wantErr := json.Unmarshal([]byte(list), &want)
+		got, gotErr := decodeCreativeTonies(strings.NewReader(list))
+		compareDecoded(t, list, want, wantErr, got, gotErr)
+	}
+}
+
+// TestJSONParserNumber checks the number scanner against the JSON grammar
+func TestJSONParserNumber(t *testing.T) {
+	for _, number := range []string{"0", "-0", "1", "12", "1.5", "0.25", "-1.5e3", "1E+2", "1e-2"} {
+		p := jsonParser{data: []byte(number)}
+		if start, end, ok := p.number(); !ok || start != 0 || end != len(number) {
+			t.Errorf("%q: not read as a number", number)
+		}
+	}
+	for _, number := range []string{"1.", ".5", "-", "01", "-01", "1e", "1e+", "1.e2", "+1"} {
+		p := jsonParser{data: []byte(number)}
+		if _, end, ok := p.number(); ok && end == len(number) {
+			t.Errorf("%q: read as a number", number)
+		}
+	}
+}
+
+// compareDecoded fails unless both decoders failed, or both succeeded with
+// the same result
+func compareDecoded(t *testing.T, payload string, want interface{}, wantErr error, got interface{}, gotErr error) {
+	t.Helper()
+	switch {
+	case wantErr != nil && gotErr == nil:
+		t.Errorf("%s: decoded, json.Unmarshal failed with %v", payload, wantErr)
+	case wantErr == nil && gotErr != nil:
+		t.Errorf("%s: failed with %v, json.Unmarshal decoded it", payload, gotErr)
+	case wantErr == nil && !reflect.DeepEqual(want, got):
+		t.Errorf("%s: decoded %+v, json.Unmarshal decoded %+v", payload, got, want)
+	}
+}
+
+// BenchmarkDecodeCreativeTonies decodes a household of 20 tonies with 99
+// chapters each, as returned by the creativetonies endpoint. The
+// encoding-json case is the baseline; the client case runs the payload
+// through Client.GetCreativeTonies, including the (small) overhead of a
+// round trip to a stub transport. Compare runs with benchstat.
+func BenchmarkDecodeCreativeTonies(b *testing.B) {
+	payload := benchmarkPayload(20, DefaultMaxChapters)
+
+	b.Run("encoding-json", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(payload)))
+		for i := 0; i < b.N; i++ {
+			var result []CreativeTonie
+			if err := json.NewDecoder(bytes.NewReader(payload)).Decode(&result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("client", func(b *testing.B) {
+		client := NewClient(WithTransport(payloadTransport(payload)))
+		client.SetToken(&JWTToken{AccessToken: "bench", TokenType: "Bearer"})
+		household := &Household{ID: "household"}
+
+		b.ReportAllocs()
+		b.SetBytes(int64(len(payload)))
+		for i := 0; i < b.N; i++ {
+			if _, err := client.GetCreativeTonies(household); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// payloadTransport answers every request with the payload
+type payloadTransport []byte
+
+// RoundTrip implements http.RoundTripper
+func (t payloadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(t)),
+		ContentLength: int64(len(t)),
+		Request:       req,
+	}, nil
+}
+
+// benchmarkPayload encodes a household of tonies with realistic chapter titles
+func benchmarkPayload(tonies, chapters int) []byte {
+	list := make([]CreativeTonie, tonies)
+	for i := range list {
+		tonie := &list[i]
+		tonie.ID = fmt.Sprintf("tonie-%04d-5f3c-4b1e-9c1a", i)
+		tonie.Name = fmt.Sprintf("Creative-Tonie %d", i+1)
+		tonie.ImageURL = "https://cdn.tonie.cloud/v2/images/placeholder.png"
+		tonie.TranscodingErrors = []TranscodingError{}
+		tonie.HouseholdID = "household-5f3c-4b1e-9c1a"
+		for j := 0; j < chapters; j++ {
+			tonie.Chapters = append(tonie.Chapters, Chapter{
+				ID:      fmt.Sprintf("chapter-%04d-%04d-7d2e-4f1a", i, j),
+				File:    fmt.Sprintf("file-%04d-%04d-8a3b-5c2d-9e4f", i, j),
+				Title:   fmt.Sprintf("Chapter %d – Die Geschichte vom kleinen Bären", j+1),
+				Seconds: 123.456 + float64(j),
+			})
+			tonie.SecondsPresent += 123.456 + float64(j)
+		}
+		tonie.ChaptersPresent = chapters
+		tonie.ChaptersRemaining = DefaultMaxChapters - chapters
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,311 @@
+package dirsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/apply"
+	"github.com/mikeboe/toniebox-api-go/kvstore"
+)
+
+// StateStore persists the state of a tonie after each sync, which is used to
+// detect changes made in the cloud since (e.g. via the app). See kvstore for
+// the available backends.
+type StateStore = kvstore.Store
+
+// Strategy decides how conflicts between local sources and cloud changes
+// are resolved
+type Strategy string
+
+const (
+	// StrategyReport aborts the sync of a conflicting tonie with a
+	// *ConflictError. This is the default.
+	StrategyReport Strategy = "report"
+	// StrategyLocalWins overwrites cloud changes with the local sources
+	StrategyLocalWins Strategy = "local-wins"
+	// StrategyRemoteWins keeps cloud changes: chapters added in the cloud
+	// stay, chapters deleted in the cloud are not uploaded again and a tonie
+	// renamed in the cloud keeps its name
+	StrategyRemoteWins Strategy = "remote-wins"
+	// StrategyInteractive asks Syncer.Resolve for every conflict
+	StrategyInteractive Strategy = "interactive"
+)
+
+// ConflictKind identifies the type of a Conflict
+type ConflictKind string
+
+const (
+	// ConflictRemoteAdded is a chapter added in the cloud that the sync would delete
+	ConflictRemoteAdded ConflictKind = "remote-added"
+	// ConflictRemoteDeleted is a chapter deleted in the cloud that the sync would upload again
+	ConflictRemoteDeleted ConflictKind = "remote-deleted"
+	// ConflictRemoteRenamed is a tonie renamed in the cloud that the sync would rename back
+	ConflictRemoteRenamed ConflictKind = "remote-renamed"
+)
+
+// Conflict is a change made in the cloud since the last sync that the
+// local sources would overwrite
+type Conflict struct {
+	Kind    ConflictKind
+	TonieID string
+	// ChapterTitle is the affected chapter for chapter conflicts
+	ChapterTitle string
+	// RemoteName and LocalName are the tonie names for ConflictRemoteRenamed
+	RemoteName string
+	LocalName  string
+}
+
+// String returns a one-line description of the conflict
+func (c Conflict) String() string {
+	switch c.Kind {
+	case ConflictRemoteAdded:
+		return fmt.Sprintf("chapter %q was added in the cloud and is missing locally", c.ChapterTitle)
+	case ConflictRemoteDeleted:
+		return fmt.Sprintf("chapter %q was deleted in the cloud but still exists locally", c.ChapterTitle)
+	default:
+		return fmt.Sprintf("tonie was renamed to %q in the cloud, locally it is %q", c.RemoteName, c.LocalName)
+	}
+}
+
+// Resolution is the outcome of an interactive conflict decision
+type Resolution string
+
+const (
+	// ResolveLocal applies the local sources
+	ResolveLocal Resolution = "local"
+	// ResolveRemote keeps the cloud change
+	ResolveRemote Resolution = "remote"
+)
+
+// ConflictError is returned by Sync with StrategyReport when the tonie was
+// changed in the cloud since the last sync. Nothing is changed in that case.
+type ConflictError struct {
+	TonieID   string
+	Conflicts []Conflict
+}
+
+// Error implements the error interface
+func (e *ConflictError) Error() string {
+	msgs := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		msgs[i] = c.String()
+	}
+	return fmt.Sprintf("tonie %s changed since the last sync: %s", e.TonieID, strings.Join(msgs, "; "))
+}
+
+// baseline is the state of a tonie right after a sync, together with the
+// conflicts resolved in favor of the cloud. Those resolutions are kept until
+// the local sources change, so later syncs do not undo them.
+type baseline struct {
+	Name     string            `json:"name"`
+	Chapters []baselineChapter `json:"chapters"`
+	// Retained are titles of chapters added in the cloud that are kept
+	Retained []string `json:"retained,omitempty"`
+	// Suppressed are titles of local chapters deleted in the cloud that are
+	// not uploaded again
+	Suppressed []string `json:"suppressed,omitempty"`
+	// IgnoredName is the local tonie name overruled by a rename in the cloud
+	IgnoredName string `json:"ignoredName,omitempty"`
+}
+
+// baselineChapter is a chapter recorded in a baseline
+type baselineChapter struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// baselineKey returns the store key of a tonie's baseline
+func baselineKey(tonieID string) string {
+	return "dirsync/" + tonieID
+}
+
+// loadBaseline returns the baseline of tonie, or nil if it was never synced
+func (s *Syncer) loadBaseline(tonie *toniebox.CreativeTonie) (*baseline, error) {
+	if s.State == nil {
+		return nil, nil
+	}
+	value, _, err := s.State.Meta(baselineKey(tonie.ID))
+	if errors.Is(err, toniebox.ErrCacheMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync state: %w", err)
+	}
+	var b baseline
+	if err := json.Unmarshal([]byte(value), &b); err != nil {
+		return nil, fmt.Errorf("failed to decode sync state: %w", err)
+	}
+	return &b, nil
+}
+
+// saveBaseline records the current state of tonie and the remote-wins
+// resolutions of decisions as its baseline
+func (s *Syncer) saveBaseline(tonie *toniebox.CreativeTonie, decisions baseline) error {
+	if s.State == nil {
+		return nil
+	}
+	b := decisions
+	b.Name = tonie.Name
+	b.Chapters = nil
+	for _, ch := range tonie.Chapters {
+		b.Chapters = append(b.Chapters, baselineChapter{ID: ch.ID, Title: ch.Title})
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := s.State.SetMeta(baselineKey(tonie.ID), string(data)); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return nil
+}
+
+// conflicts returns the cloud changes since base that spec would overwrite
+func conflicts(tonie *toniebox.CreativeTonie, base *baseline, spec apply.Spec) []Conflict {
+	if base == nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(spec.Chapters))
+	for _, ch := range spec.Chapters {
+		wanted[ch.Title] = true
+	}
+	known := make(map[string]bool, len(base.Chapters))
+	for _, ch := range base.Chapters {
+		known[ch.ID] = true
+	}
+	current := make(map[string]bool, len(tonie.Chapters))
+	for _, ch := range tonie.Chapters {
+		current[ch.ID] = true
+	}
+
+	var found []Conflict
+	if spec.Name != "" && tonie.Name != base.Name && tonie.Name != spec.Name {
+		found = append(found, Conflict{Kind: ConflictRemoteRenamed, TonieID: tonie.ID, RemoteName: tonie.Name, LocalName: spec.Name})
+	}
+	for _, ch := range tonie.Chapters {
+		if !known[ch.ID] && !wanted[ch.Title] {
+			found = append(found, Conflict{Kind: ConflictRemoteAdded, TonieID: tonie.ID, ChapterTitle: ch.Title})
+		}
+	}
+	for _, ch := range base.Chapters {
+		if !current[ch.ID] && wanted[ch.Title] && tonie.FindChapterByTitle(ch.Title) == nil {
+			found = append(found, Conflict{Kind: ConflictRemoteDeleted, TonieID: tonie.ID, ChapterTitle: ch.Title})
+		}
+	}
+	return found
+}
+
+// reapply applies the remote-wins resolutions of earlier syncs to spec and
+// returns those still in effect. A resolution ends once the local sources no
+// longer contain the chapter or name, or the cloud no longer has the chapter.
+func reapply(tonie *toniebox.CreativeTonie, base *baseline, spec *apply.Spec) baseline {
+	var decisions baseline
+	if base == nil {
+		return decisions
+	}
+
+	if base.IgnoredName != "" && spec.Name == base.IgnoredName {
+		decisions.IgnoredName = spec.Name
+		spec.Name = ""
+	}
+	for _, title := range base.Suppressed {
+		if hasChapter(*spec, title) {
+			keepRemote(tonie, spec, Conflict{Kind: ConflictRemoteDeleted, ChapterTitle: title})
+			decisions.Suppressed = append(decisions.Suppressed, title)
+		}
+	}
+	for _, title := range base.Retained {
+		if tonie.FindChapterByTitle(title) != nil && !hasChapter(*spec, title) {
+			keepRemote(tonie, spec, Conflict{Kind: ConflictRemoteAdded, ChapterTitle: title})
+			decisions.Retained = append(decisions.Retained, title)
+		}
+	}
+	return decisions
+}
+
+// record adds a remote-wins resolution of c to decisions
+func (b *baseline) record(c Conflict) {
+	switch c.Kind {
+	case ConflictRemoteRenamed:
+		b.IgnoredName = c.LocalName
+	case ConflictRemoteDeleted:
+		b.Suppressed = append(b.Suppressed, c.ChapterTitle)
+	case ConflictRemoteAdded:
+		b.Retained = append(b.Retained, c.ChapterTitle)
+	}
+}
+
+// hasChapter reports whether spec contains a chapter with the given title
+func hasChapter(spec apply.Spec, title string) bool {
+	for _, ch := range spec.Chapters {
+		if ch.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+// keepRemote changes spec so that the cloud change of c is preserved
+func keepRemote(tonie *toniebox.CreativeTonie, spec *apply.Spec, c Conflict) {
+	switch c.Kind {
+	case ConflictRemoteRenamed:
+		spec.Name = ""
+	case ConflictRemoteDeleted:
+		for i, ch := range spec.Chapters {
+			if ch.Title == c.ChapterTitle {
+				spec.Chapters = append(spec.Chapters[:i], spec.Chapters[i+1:]...)
+				break
+			}
+		}
+	case ConflictRemoteAdded:
+		// Keep the chapter at its current position, as far as possible
+		position := tonie.ChapterPosition(tonie.FindChapterByTitle(c.ChapterTitle).ID)
+		if position > len(spec.Chapters) {
+			position = len(spec.Chapters)
+		}
+		chapters := append([]apply.ChapterSpec(nil), spec.Chapters[:position]...)
+		chapters = append(chapters, apply.ChapterSpec{Title: c.ChapterTitle})
+		spec.Chapters = append(chapters, spec.Chapters[position:]...)
+	}
+}
+
+// resolve applies the configured strategy to the conflicts, changing spec
+// and recording remote-wins resolutions in decisions
+func (s *Syncer) resolve(tonie *toniebox.CreativeTonie, spec *apply.Spec, found []Conflict, decisions *baseline) error {
+	if len(found) == 0 {
+		return nil
+	}
+
+	for _, c := range found {
+		var resolution Resolution
+		switch s.Strategy {
+		case "", StrategyReport:
+			return &ConflictError{TonieID: tonie.ID, Conflicts: found}
+		case StrategyLocalWins:
+			resolution = ResolveLocal
+		case StrategyRemoteWins:
+			resolution = ResolveRemote
+		case StrategyInteractive:
+			if s.Resolve == nil {
+				return fmt.Errorf("interactive conflict resolution requires Syncer.Resolve")
+			}
+			resolution = s.Resolve(c)
+		default:
+			return fmt.Errorf("unknown conflict strategy %q", s.Strategy)
+		}
+
+		switch resolution {
+		case ResolveLocal:
+		case ResolveRemote:
+			keepRemote(tonie, spec, c)
+			decisions.record(c)
+		default:
+			return fmt.Errorf("unknown conflict resolution %q", resolution)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Package dirsync keeps Creative-Tonies in sync with local directories.
+//
+// The desired content of a tonie is assembled from one or more source
+// directories, e.g. "own recordings" followed by "purchased MP3s". Each source
+// has its own file order and title prefix. The assembled content is turned
+// into an apply.Spec and applied with the apply engine, so windows, policies
+// and event logs configured on the engine are honored:
+//
+//	cfg := dirsync.Config{Sources: []dirsync.Source{
+//	    {Dir: "/media/recordings", Prefix: "Mum: ", Order: dirsync.OrderNewestFirst},
+//	    {Dir: "/media/audiobooks/gruffalo", Order: dirsync.OrderTracks},
+//	}}
+//	syncer := &dirsync.Syncer{Engine: &apply.Engine{}}
+//	plan, err := syncer.Sync(ctx, tonie, cfg)
+package dirsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/apply"
+	"github.com/mikeboe/toniebox-api-go/notify"
+)
+
+// Order defines how the files of a source are ordered
+type Order string
+
+const (
+	// OrderName sorts files naturally by name (01, 02, ..., 10). This is the default.
+	OrderName Order = "name"
+	// OrderTracks sorts files by their disc and track-number tags, then by name
+	OrderTracks Order = "tracks"
+	// OrderOldestFirst sorts files by modification time, oldest first
+	OrderOldestFirst Order = "oldest-first"
+	// OrderNewestFirst sorts files by modification time, newest first
+	OrderNewestFirst Order = "newest-first"
+)
+
+// Source is a directory contributing chapters to a tonie
+type Source struct {
+	// Dir is the directory to read audio files from (non-recursively)
+	Dir string `json:"dir"`
+	// Prefix is prepended to the titles of all chapters from this source
+	Prefix string `json:"prefix,omitempty"`
+	// Order defines the order of the files; defaults to OrderName
+	Order Order `json:"order,omitempty"`
+	// Extensions restricts the files picked up; defaults to
+	// toniebox.DefaultAudioExtensions
+	Extensions []string `json:"extensions,omitempty"`
+	// TitleTemplate derives chapter titles from file names and tags,
+	// see toniebox.ParseTitleTemplate
+	TitleTemplate string `json:"titleTemplate,omitempty"`
+	// Include restricts the source to files whose name matches at least one
+	// of these globs (path.Match syntax); empty includes all files
+	Include []string `json:"include,omitempty"`
+}
+
+// Config describes the desired content of a tonie
+type Config struct {
+	// Name is the desired tonie name; empty keeps the current name
+	Name string `json:"name,omitempty"`
+	// Sources contribute chapters in the given order: all chapters of the
+	// first source, then those of the second, and so on
+	Sources []Source `json:"sources"`
+}
+
+// Spec assembles the desired state from the configured sources
+func (c Config) Spec() (apply.Spec, error) {
+	spec := apply.Spec{Name: c.Name}
+	for _, source := range c.Sources {
+		files, err := source.Files()
+		if err != nil {
+			return apply.Spec{}, err
+		}
+		for _, file := range files {
+			spec.Chapters = append(spec.Chapters, apply.ChapterSpec{
+				Title: source.Prefix + file.ChapterTitle(),
+				File:  file.Path,
+			})
+		}
+	}
+	return spec, nil
+}
+
+// Files lists the audio files of the source in its configured order.
+// Files not matching Include or ignored by the directory's .tonieignore file
+// are left out.
+func (s Source) Files() ([]toniebox.BatchFile, error) {
+	switch s.Order {
+	case "", OrderName, OrderTracks, OrderOldestFirst, OrderNewestFirst:
+	default:
+		return nil, fmt.Errorf("source %s: unknown order %q", s.Dir, s.Order)
+	}
+	for _, pattern := range s.Include {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("source %s: invalid include pattern %q: %w", s.Dir, pattern, err)
+		}
+	}
+	ignore, err := LoadIgnore(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("source %s: %w", s.Dir, err)
+	}
+
+	files, err := toniebox.ScanDir(s.Dir, toniebox.BatchOptions{
+		Extensions:    s.Extensions,
+		UseTrackTags:  s.Order == OrderTracks,
+		TitleTemplate: s.TitleTemplate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("source %s: %w", s.Dir, err)
+	}
+
+	selected := files[:0]
+	for _, file := range files {
+		name := filepath.Base(file.Path)
+		if s.included(name) && !ignore.Ignored(name) {
+			selected = append(selected, file)
+		}
+	}
+	files = selected
+
+	if s.Order == OrderOldestFirst || s.Order == OrderNewestFirst {
+		modTimes := make(map[string]int64, len(files))
+		for _, file := range files {
+			info, err := os.Stat(file.Path)
+			if err != nil {
+				return nil, fmt.Errorf("source %s: %w", s.Dir, err)
+			}
+			modTimes[file.Path] = info.ModTime().UnixNano()
+		}
+		// Files are already in name order, which breaks ties
+		sort.SliceStable(files, func(i, j int) bool {
+			a, b := modTimes[files[i].Path], modTimes[files[j].Path]
+			if s.Order == OrderNewestFirst {
+				return a > b
+			}
+			return a < b
+		})
+	}
+	return files, nil
+}
+
+// included reports whether name matches the Include globs
+func (s Source) included(name string) bool {
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, pattern := range s.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Syncer brings tonies into the state described by a Config
+type Syncer struct {
+	// Engine plans and applies the changes; nil uses a zero apply.Engine
+	Engine *apply.Engine
+	// DryRun only computes the plan without applying it
+	DryRun bool
+	// State records each tonie after a sync to detect later cloud changes.
+	// Without it, conflicts are not detected and local sources always win.
+	State StateStore
+	// Strategy resolves conflicts with cloud changes; defaults to StrategyReport
+	Strategy Strategy
+	// Resolve decides each conflict with StrategyInteractive
+	Resolve func(conflict Conflict) Resolution
+	// Notifier is informed when a sync changed a tonie or failed. Syncs
+	// without changes and dry runs are not reported.
+	Notifier notify.Notifier
+}
+
+// Sync assembles the desired state of tonie from cfg and applies the
+// resulting plan. The plan is returned in any case, so callers can show what
+// was (or, with DryRun, would be) changed.
+//
+// If the tonie was changed in the cloud since the last sync in a way the plan
+// would overwrite, the configured Strategy decides: by default Sync returns a
+// *ConflictError without changing anything.
+func (s *Syncer) Sync(ctx context.Context, tonie *toniebox.CreativeTonie, cfg Config) (*apply.Plan, error) {
+	startedAt := time.Now()
+	plan, err := s.sync(ctx, tonie, cfg)
+	if s.Notifier != nil && !s.DryRun && (err != nil || (plan != nil && !plan.Empty())) {
+		_ = s.Notifier.Notify(ctx, notify.NewEvent("sync "+tonie.Name, startedAt, summary(plan), err))
+	}
+	return plan, err
+}
+
+// summary describes the changes of plan in one line
+func summary(plan *apply.Plan) string {
+	if plan == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d created, %d deleted, %d renamed, %d reordered",
+		plan.Count(apply.ActionCreate), plan.Count(apply.ActionDelete),
+		plan.Count(apply.ActionRename), plan.Count(apply.ActionReorder))
+}
+
+// sync implements Sync without notification
+func (s *Syncer) sync(ctx context.Context, tonie *toniebox.CreativeTonie, cfg Config) (*apply.Plan, error) {
+	engine := s.Engine
+	if engine == nil {
+		engine = &apply.Engine{}
+	}
+
+	spec, err := cfg.Spec()
+	if err != nil {
+		return nil, err
+	}
+	base, err := s.loadBaseline(tonie)
+	if err != nil {
+		return nil, err
+	}
+	decisions := reapply(tonie, base, &spec)
+	if err := s.resolve(tonie, &spec, conflicts(tonie, base, spec), &decisions); err != nil {
+		return nil, err
+	}
+
+	plan, err := engine.Plan(tonie, spec)
+	if err != nil {
+		return nil, err
+	}
+	if s.DryRun {
+		return plan, nil
+	}
+	if !plan.Empty() {
+		if err := engine.ApplyContext(ctx, tonie, plan); err != nil {
+			return plan, err
+		}
+	}
+	return plan, s.saveBaseline(tonie, decisions)
+}
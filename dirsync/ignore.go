@@ -0,0 +1,102 @@
+package dirsync
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the name of the ignore file read from every source directory
+const IgnoreFileName = ".tonieignore"
+
+// Ignore is a parsed ignore file in gitignore syntax. Sources are scanned
+// non-recursively, so patterns are matched against file names:
+//
+//	# comments and blank lines are skipped
+//	*.tmp        ignore temporary files
+//	cover.*      ignore cover art
+//	!keep.tmp    re-include a previously ignored file
+//	/draft.mp3   a leading slash anchors to the source directory
+//	\#hash.mp3   a backslash escapes a leading # or !
+//
+// Patterns ending in a slash only match directories and therefore never
+// match a file. The last matching pattern wins.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// ignoreRule is a single pattern of an ignore file
+type ignoreRule struct {
+	pattern string
+	negate  bool
+}
+
+// ParseIgnore parses an ignore file
+func ParseIgnore(r io.Reader) (*Ignore, error) {
+	ig := &Ignore{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(text, "!") {
+			rule.negate = true
+			text = text[1:]
+		} else if strings.HasPrefix(text, `\#`) || strings.HasPrefix(text, `\!`) {
+			text = text[1:]
+		}
+		if strings.HasSuffix(text, "/") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "/")
+		text = strings.TrimPrefix(text, "**/")
+		if _, err := path.Match(text, ""); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", line, text, err)
+		}
+		rule.pattern = text
+		ig.rules = append(ig.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ig, nil
+}
+
+// LoadIgnore reads the ignore file of dir. It returns an empty Ignore if dir
+// has no ignore file.
+func LoadIgnore(dir string) (*Ignore, error) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Ignore{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ig, err := ParseIgnore(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name(), err)
+	}
+	return ig, nil
+}
+
+// Ignored reports whether the file with the given name is ignored
+func (ig *Ignore) Ignored(name string) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,129 @@
+package toniebox
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WithHostOverrides maps API host names to fixed addresses, similar to
+// entries in /etc/hosts. Keys are host names such as "api.tonie.cloud",
+// values are IP addresses or host names, optionally with a port. TLS still
+// verifies the certificate against the original host name.
+// This is useful on split-horizon networks that redirect the Toniebox cloud
+// to a local server such as TeddyCloud.
+//
+// The option only takes effect with the default transport or an
+// *http.Transport; custom round trippers are left unchanged.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithHostOverrides(map[string]string{
+//	    "api.tonie.cloud": "192.168.1.20",
+//	}))
+func WithHostOverrides(hosts map[string]string) Option {
+	overrides := make(map[string]string, len(hosts))
+	for host, addr := range hosts {
+		overrides[host] = addr
+	}
+	return withDialer(func(dial dialFunc) dialFunc {
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return dial(ctx, network, address)
+			}
+			target, ok := overrides[host]
+			if !ok {
+				return dial(ctx, network, address)
+			}
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				target = net.JoinHostPort(target, port)
+			}
+			return dial(ctx, network, target)
+		}
+	})
+}
+
+// WithResolver resolves API host names with resolver instead of the system
+// resolver, e.g. to query a specific DNS server.
+// Like WithHostOverrides it only affects *http.Transport based clients.
+//
+// Example:
+//
+//	resolver := &net.Resolver{
+//	    PreferGo: true,
+//	    Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+//	        return (&net.Dialer{}).DialContext(ctx, network, "192.168.1.1:53")
+//	    },
+//	}
+//	client := toniebox.NewClient(toniebox.WithResolver(resolver))
+func WithResolver(resolver *net.Resolver) Option {
+	return withDialer(func(dial dialFunc) dialFunc {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Resolver:  resolver,
+		}
+		return dialer.DialContext
+	})
+}
+
+// WithUnixSocket sends all API connections to the Unix domain socket at path
+// instead of dialing the API hosts over TCP. This suits local TeddyCloud or
+// sidecar setups. Requests keep their original URLs, so the server behind the
+// socket must still speak TLS for the https API hosts.
+// Like WithHostOverrides it only affects *http.Transport based clients.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithUnixSocket("/run/teddycloud.sock"))
+func WithUnixSocket(path string) Option {
+	return withDialer(func(dialFunc) dialFunc {
+		dialer := &net.Dialer{Timeout: 30 * time.Second}
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+	})
+}
+
+// dialFunc matches the DialContext field of http.Transport
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// withDialer returns an option that wraps the dial function of the client's
+// transport
+func withDialer(wrap func(dialFunc) dialFunc) Option {
+	return withHTTPTransport(func(transport *http.Transport) {
+		dial := dialFunc(transport.DialContext)
+		if dial == nil {
+			dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		}
+		transport.DialContext = wrap(dial)
+	})
+}
+
+// withHTTPTransport returns an option that modifies the *http.Transport of the
+// client. The client and transport are copied so that values passed in by the
+// caller are not modified. Custom round trippers are left unchanged.
+func withHTTPTransport(modify func(*http.Transport)) Option {
+	return func(rh *requestHandler) {
+		var transport *http.Transport
+		switch t := rh.client.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return
+		}
+		modify(transport)
+		if rh.pinnedTransport != nil && rh.client.Transport == rh.pinnedTransport {
+			// The clone keeps the pin verification of its TLS config
+			rh.pinnedTransport = transport
+		}
+
+		client := *rh.client
+		client.Transport = transport
+		rh.client = &client
+	}
+}
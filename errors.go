@@ -0,0 +1,145 @@
+package toniebox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIError is returned when the Toniebox API responds with an unexpected status code
+type APIError struct {
+	// Op describes the failed operation (e.g. "request", "login")
+	Op string
+	// StatusCode is the HTTP status code returned by the API
+	StatusCode int
+	// Body is the raw response body
+	Body string
+	// Location is the redirect target of a 3xx response that was not followed
+	Location string
+}
+
+// newAPIError builds an APIError from an unexpected response, consuming its body
+func newAPIError(op string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Location:   resp.Header.Get("Location"),
+	}
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// StaleError is returned together with cached data when the API could not be
+// reached and the client was configured with WithStaleCache.
+// The returned data reflects the state at FetchedAt.
+type StaleError struct {
+	// FetchedAt is the time the cached data was retrieved from the API
+	FetchedAt time.Time
+	// Err is the error that prevented fetching fresh data
+	Err error
+}
+
+// Error implements the error interface
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("serving stale data from %s: %v", e.FetchedAt.Format(time.RFC3339), e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *StaleError) Unwrap() error {
+	return e.Err
+}
+
+// IsStale reports whether err signals stale cached data and, if so, when that
+// data was fetched. Callers that accept stale data can keep using the result.
+//
+// Example:
+//
+//	households, err := client.GetHouseholds()
+//	if fetchedAt, ok := toniebox.IsStale(err); ok {
+//	    fmt.Printf("Showing data from %s\n", fetchedAt)
+//	} else if err != nil {
+//	    log.Fatal(err)
+//	}
+func IsStale(err error) (time.Time, bool) {
+	var stale *StaleError
+	if errors.As(err, &stale) {
+		return stale.FetchedAt, true
+	}
+	return time.Time{}, false
+}
+
+// DuplicateNameError is returned when renaming a Creative-Tonie would give it
+// the same name as another Creative-Tonie in its household. Duplicate names
+// make name-based lookups ambiguous.
+type DuplicateNameError struct {
+	// Name is the requested tonie name
+	Name string
+	// ConflictingIDs are the IDs of the tonies already using Name
+	ConflictingIDs []string
+}
+
+// Error implements the error interface
+func (e *DuplicateNameError) Error() string {
+	return fmt.Sprintf("tonie name %q is already used in this household by %s", e.Name, strings.Join(e.ConflictingIDs, ", "))
+}
+
+// MultiError collects the failures of a batch or concurrent operation.
+// Individual errors can be inspected with errors.Is and errors.As, which
+// check every collected error.
+type MultiError struct {
+	// Errors are the collected failures, in input order
+	Errors []error
+}
+
+// Error implements the error interface
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the collected errors
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// newMultiError returns a *MultiError of the non-nil errs, or nil if there are none
+func newMultiError(errs []error) error {
+	var collected []error
+	for _, err := range errs {
+		if err != nil {
+			collected = append(collected, err)
+		}
+	}
+	if len(collected) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: collected}
+}
+
+// PanicError is returned in place of a panic that occurred in a worker
+// goroutine or callback, so that a single failure does not crash the process
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
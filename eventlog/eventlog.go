@@ -0,0 +1,177 @@
+// Package eventlog records structured events of long-running subsystems
+// (apply engine, scheduler, watcher, ...) as JSON Lines. Each event is
+// written as a single flat JSON object per line, which log shippers such as
+// Promtail/Loki or Filebeat/Elasticsearch ingest without further parsing.
+//
+// Example:
+//
+//	events, err := eventlog.OpenFile("/var/log/toniebox/events.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer events.Close()
+//	sched := &schedule.Scheduler{Client: client, Events: events}
+package eventlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mikeboe/toniebox-api-go/notify"
+)
+
+// Level is the severity of an event
+type Level string
+
+const (
+	// LevelInfo marks regular progress
+	LevelInfo Level = "info"
+	// LevelWarn marks recoverable problems, e.g. a failed task that is retried later
+	LevelWarn Level = "warn"
+	// LevelError marks failures
+	LevelError Level = "error"
+)
+
+// Entry is a single structured event
+type Entry struct {
+	// Time is when the event occurred; the zero value is replaced by the current time
+	Time time.Time
+	// Level is the severity; empty defaults to LevelInfo
+	Level Level
+	// Source names the emitting subsystem (e.g. "apply", "schedule")
+	Source string
+	// Event is a short, stable event name (e.g. "upload", "run_finished")
+	Event string
+	// Message is an optional human-readable description
+	Message string
+	// Fields are additional attributes, flattened into the JSON object.
+	// They cannot override the fixed keys time, level, source, event and msg.
+	Fields map[string]interface{}
+}
+
+// MarshalJSON encodes the entry as a flat JSON object
+func (e Entry) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(e.Fields)+5)
+	for k, v := range e.Fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		obj[k] = v
+	}
+	obj["time"] = e.Time.Format(time.RFC3339Nano)
+	obj["level"] = e.Level
+	obj["source"] = e.Source
+	obj["event"] = e.Event
+	if e.Message != "" {
+		obj["msg"] = e.Message
+	}
+	return json.Marshal(obj)
+}
+
+// Logger receives events. Implementations must be safe for concurrent use.
+// Logging never fails from the caller's point of view; implementations
+// handle write errors themselves.
+type Logger interface {
+	Log(entry Entry)
+}
+
+// Func adapts an ordinary function to the Logger interface
+type Func func(entry Entry)
+
+// Log implements Logger
+func (f Func) Log(entry Entry) {
+	f(entry)
+}
+
+// JSONL writes events as JSON Lines to a writer
+type JSONL struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewJSONL returns a Logger that writes one JSON object per line to w
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{w: w}
+}
+
+// OpenFile opens path for appending, creating it if necessary, and returns
+// a JSONL logger writing to it. The caller must Close it.
+func OpenFile(path string) (*JSONL, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return NewJSONL(f), nil
+}
+
+// Log implements Logger. Write errors are recorded and reported by Err.
+func (l *JSONL) Log(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.Level == "" {
+		entry.Level = LevelInfo
+	}
+	line, err := json.Marshal(entry)
+	if err == nil {
+		line = append(line, '\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil {
+		// A single write per line keeps concurrent appenders from interleaving
+		_, err = l.w.Write(line)
+	}
+	if err != nil && l.err == nil {
+		l.err = err
+	}
+}
+
+// Err returns the first error that occurred while encoding or writing an event
+func (l *JSONL) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close closes the underlying writer if it implements io.Closer
+func (l *JSONL) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Notifier returns a notify.Notifier that records job outcomes as
+// "job_finished" events of source
+func Notifier(l Logger, source string) notify.Notifier {
+	return notify.Func(func(ctx context.Context, event notify.Event) error {
+		entry := Entry{
+			Time:    event.FinishedAt,
+			Level:   LevelInfo,
+			Source:  source,
+			Event:   "job_finished",
+			Message: event.Summary,
+			Fields: map[string]interface{}{
+				"job":         event.Job,
+				"status":      event.Status,
+				"duration_ms": event.Duration().Milliseconds(),
+			},
+		}
+		if event.Err != nil {
+			entry.Level = LevelError
+			entry.Fields["error"] = event.Err.Error()
+		}
+		l.Log(entry)
+		return nil
+	})
+}
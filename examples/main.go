@@ -36,6 +36,9 @@ func main() {
 	fmt.Printf("✓ Login successful (Access Token: %s...)\n", token.AccessToken[:10])
 	fmt.Printf("✓ Login successful (Refresh Token: %s...)\n", token.RefreshToken[:10])
 	fmt.Println("✓ Login successful")
+	if identity, err := token.Identity(); err == nil {
+		fmt.Printf("✓ Logged in as %s\n", identity.Email)
+	}
 
 	// Get personal information
 	fmt.Println("\nFetching user information...")
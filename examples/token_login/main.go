@@ -55,4 +55,10 @@ func main() {
 		log.Fatalf("Failed to get households with token: %v", err)
 	}
 	fmt.Printf("✓ Successfully retrieved %d household(s)\n", len(households))
+
+	// 5. Read the current token back, e.g. to store it in a database.
+	// It may have been renewed while the client was used.
+	current := newClient.Token()
+	fmt.Printf("✓ Token to store (Access: %s..., Refresh: %s...)\n",
+		current.AccessToken[:10], current.RefreshToken[:10])
 }
@@ -0,0 +1,43 @@
+package toniebox
+
+import (
+	"context"
+	"time"
+)
+
+// State is a snapshot of everything the library can read from an account.
+// It is produced by Client.Snapshot and Client.ExportState and is suitable
+// for backups, diffs and seeding test servers.
+type State struct {
+	ExportedAt time.Time        `json:"exportedAt"`
+	Me         *Me              `json:"me"`
+	Households []HouseholdState `json:"households"`
+}
+
+// HouseholdState is the exported state of a single household
+type HouseholdState struct {
+	Household Household       `json:"household"`
+	Tonies    []CreativeTonie `json:"tonies"`
+}
+
+// ExportState reads the account, all households and their Creative-Tonies
+// into a single snapshot, see Snapshot. Use
+// MarshalCanonical to serialize it byte-stably.
+//
+// Example:
+//
+//	state, err := client.ExportState()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	data, _ := toniebox.MarshalCanonical(state)
+//	os.WriteFile("backup.json", data, 0o600)
+func (c *Client) ExportState() (*State, error) {
+	return c.ExportStateContext(context.Background())
+}
+
+// ExportStateContext is like ExportState, but the requests are aborted when
+// ctx is done.
+func (c *Client) ExportStateContext(ctx context.Context) (*State, error) {
+	return c.Snapshot(ctx)
+}
@@ -0,0 +1,48 @@
+//go:build js && wasm
+
+package toniebox
+
+import (
+	"net/http"
+)
+
+// WithFetchOptions configures the browser fetch API used by net/http when
+// running as WebAssembly. Mode and credentials map to the fetch "mode" and
+// "credentials" request options; empty values leave the browser defaults.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithFetchOptions("cors", "omit"))
+func WithFetchOptions(mode, credentials string) Option {
+	return func(rh *requestHandler) {
+		rh.client.Transport = &fetchTransport{
+			base:        rh.client.Transport,
+			mode:        mode,
+			credentials: credentials,
+		}
+	}
+}
+
+// fetchTransport sets the js.fetch request headers understood by the wasm transport
+type fetchTransport struct {
+	base        http.RoundTripper
+	mode        string
+	credentials string
+}
+
+// RoundTrip implements http.RoundTripper
+func (ft *fetchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if ft.mode != "" {
+		req.Header.Set("js.fetch:mode", ft.mode)
+	}
+	if ft.credentials != "" {
+		req.Header.Set("js.fetch:credentials", ft.credentials)
+	}
+
+	base := ft.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,262 @@
+// Package fixtures checks the API models against captured API payloads.
+//
+// A fixture is a sanitized JSON response of the Toniecloud, stored as
+// <model>/<name>.json where <model> names the decoding target (see Models).
+// Check decodes a fixture into its model, encodes it again and reports every
+// field that was dropped or changed on the way. A field missing from the
+// models thus shows up as soon as somebody adds a payload containing it.
+//
+// The payloads shipped with the package are embedded as Golden. To add a
+// regression case, sanitize a captured response and drop it into
+// fixtures/payloads:
+//
+//	go run ./internal/fixturecheck -sanitize response.json > fixtures/payloads/creativetonie/new-field.json
+//	go run ./internal/fixturecheck
+package fixtures
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// Golden holds the payloads shipped with the package
+//
+//go:embed payloads
+var Golden embed.FS
+
+// Models maps fixture directories to constructors of their decoding target
+var Models = map[string]func() interface{}{
+	"token":          func() interface{} { return &toniebox.JWTToken{} },
+	"me":             func() interface{} { return &toniebox.Me{} },
+	"households":     func() interface{} { return &[]toniebox.Household{} },
+	"creativetonie":  func() interface{} { return &toniebox.CreativeTonie{} },
+	"creativetonies": func() interface{} { return &[]toniebox.CreativeTonie{} },
+	"upload":         func() interface{} { return &toniebox.AmazonBean{} },
+}
+
+// Fixture is a captured payload together with the model it decodes into
+type Fixture struct {
+	// Model is the key in Models
+	Model string
+	// Name is the file name without extension
+	Name string
+	Data []byte
+}
+
+// String returns the path of the fixture relative to the fixture root
+func (f Fixture) String() string {
+	return f.Model + "/" + f.Name + ".json"
+}
+
+// Load reads all fixtures below the root of fsys, e.g. os.DirFS("payloads")
+// or the "payloads" subtree of Golden. Directories not listed in Models are
+// rejected, so a typo does not silently skip a fixture.
+func Load(fsys fs.FS) ([]Fixture, error) {
+	var fixtures []Fixture
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".json" {
+			return nil
+		}
+		model := path.Dir(p)
+		if _, ok := Models[model]; !ok {
+			return fmt.Errorf("fixture %s: unknown model %q", p, model)
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		fixtures = append(fixtures, Fixture{
+			Model: model,
+			Name:  strings.TrimSuffix(path.Base(p), ".json"),
+			Data:  data,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+// LoadGolden returns the fixtures shipped with the package
+func LoadGolden() ([]Fixture, error) {
+	sub, err := fs.Sub(Golden, "payloads")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Diff is a difference between a payload and its round trip through the models
+type Diff struct {
+	// Path locates the value, e.g. "chapters[0].title"
+	Path string
+	// Want is the value in the payload
+	Want interface{}
+	// Got is the value after the round trip; nil if it was dropped
+	Got interface{}
+}
+
+// String returns a one-line description of the difference
+func (d Diff) String() string {
+	if d.Got == nil {
+		return fmt.Sprintf("%s: dropped (was %s)", d.Path, formatJSON(d.Want))
+	}
+	return fmt.Sprintf("%s: %s became %s", d.Path, formatJSON(d.Want), formatJSON(d.Got))
+}
+
+// LossError is returned by Check when a round trip loses information
+type LossError struct {
+	Fixture Fixture
+	Diffs   []Diff
+}
+
+// Error implements the error interface
+func (e *LossError) Error() string {
+	msgs := make([]string, len(e.Diffs))
+	for i, d := range e.Diffs {
+		msgs[i] = d.String()
+	}
+	return fmt.Sprintf("%s does not round-trip: %s", e.Fixture, strings.Join(msgs, "; "))
+}
+
+// Check decodes f into its model, encodes it again and compares the result
+// with the payload. Fields added by the encoder (e.g. zero values without
+// omitempty) are fine; dropped or changed values are returned as a
+// *LossError.
+func Check(f Fixture) error {
+	newModel, ok := Models[f.Model]
+	if !ok {
+		return fmt.Errorf("%s: unknown model %q", f, f.Model)
+	}
+
+	var want interface{}
+	if err := decode(f.Data, &want); err != nil {
+		return fmt.Errorf("%s: invalid JSON: %w", f, err)
+	}
+	model := newModel()
+	if err := json.Unmarshal(f.Data, model); err != nil {
+		return fmt.Errorf("%s: failed to decode into %T: %w", f, model, err)
+	}
+	encoded, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode %T: %w", f, model, err)
+	}
+	var got interface{}
+	if err := decode(encoded, &got); err != nil {
+		return fmt.Errorf("%s: failed to decode round trip: %w", f, err)
+	}
+
+	var diffs []Diff
+	compare("", want, got, &diffs)
+	if len(diffs) > 0 {
+		return &LossError{Fixture: f, Diffs: diffs}
+	}
+	return nil
+}
+
+// CheckAll checks every fixture and returns all failures as a
+// *toniebox.MultiError
+func CheckAll(fixtures []Fixture) error {
+	var errs []error
+	for _, f := range fixtures {
+		if err := Check(f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &toniebox.MultiError{Errors: errs}
+}
+
+// decode parses JSON keeping numbers exact
+func decode(data []byte, v *interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// compare records the values of want that are missing or different in got
+func compare(p string, want, got interface{}, diffs *[]Diff) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, Diff{Path: p, Want: want, Got: got})
+			return
+		}
+		keys := make([]string, 0, len(w))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if p != "" {
+				child = p + "." + k
+			}
+			gv, ok := g[k]
+			if !ok {
+				if w[k] != nil {
+					*diffs = append(*diffs, Diff{Path: child, Want: w[k]})
+				}
+				continue
+			}
+			compare(child, w[k], gv, diffs)
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			*diffs = append(*diffs, Diff{Path: p, Want: want, Got: got})
+			return
+		}
+		for i := range w {
+			compare(fmt.Sprintf("%s[%d]", p, i), w[i], g[i], diffs)
+		}
+	case json.Number:
+		g, ok := got.(json.Number)
+		if !ok || !sameNumber(w, g) {
+			*diffs = append(*diffs, Diff{Path: p, Want: want, Got: got})
+		}
+	default:
+		if !reflect.DeepEqual(want, got) {
+			*diffs = append(*diffs, Diff{Path: p, Want: want, Got: got})
+		}
+	}
+}
+
+// sameNumber reports whether two JSON numbers have the same value, so that
+// e.g. 120 and 120.0 are equal
+func sameNumber(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	af, errA := a.Float64()
+	bf, errB := b.Float64()
+	return errA == nil && errB == nil && af == bf
+}
+
+// formatJSON renders a decoded JSON value compactly
+func formatJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > 60 {
+		return string(data[:57]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,119 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sensitiveKeys lists the keys whose string values are replaced by Sanitize
+var sensitiveKeys = map[string]string{
+	"email":                "user@example.com",
+	"firstName":            "Jane",
+	"lastName":             "Doe",
+	"displayName":          "Jane Doe",
+	"ownerName":            "Jane Doe",
+	"authCode":             "REDACTED",
+	"access_token":         "REDACTED",
+	"refresh_token":        "REDACTED",
+	"macAddress":           "00:00:00:00:00:00",
+	"policy":               "REDACTED",
+	"x-amz-credential":     "REDACTED",
+	"x-amz-signature":      "REDACTED",
+	"x-amz-security-token": "REDACTED",
+}
+
+// idKeys lists the keys whose values are replaced by stable placeholders,
+// so that references between objects stay intact
+var idKeys = map[string]bool{
+	"id":              true,
+	"uuid":            true,
+	"householdId":     true,
+	"assignedTonieId": true,
+	"file":            true,
+	"fileId":          true,
+	"key":             true,
+}
+
+// Sanitize replaces personal data and secrets in a captured payload with
+// placeholders. IDs are replaced consistently: the same ID always maps to
+// the same placeholder, so relations between objects survive. The result is
+// indented for readable diffs.
+//
+// Sanitize only knows the fields of the current models; review the output
+// for personal data in new fields before committing it.
+func Sanitize(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := decode(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	s := &sanitizer{ids: make(map[string]string)}
+	v = s.value("", v)
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// sanitizer keeps the ID mapping of one Sanitize call
+type sanitizer struct {
+	ids map[string]string
+}
+
+// value sanitizes v found under key
+func (s *sanitizer) value(key string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		// Sorted keys make the placeholders independent of map order
+		sort.Strings(keys)
+		for _, k := range keys {
+			t[k] = s.value(k, t[k])
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = s.value(key, t[i])
+		}
+		return t
+	case string:
+		if t == "" {
+			return t
+		}
+		if placeholder, ok := sensitiveKeys[key]; ok {
+			return placeholder
+		}
+		if idKeys[key] {
+			return s.id(t)
+		}
+		if strings.HasPrefix(t, "http://") || strings.HasPrefix(t, "https://") {
+			return sanitizeURL(t)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// id returns the placeholder of an ID
+func (s *sanitizer) id(id string) string {
+	placeholder, ok := s.ids[id]
+	if !ok {
+		placeholder = fmt.Sprintf("id-%d", len(s.ids)+1)
+		s.ids[id] = placeholder
+	}
+	return placeholder
+}
+
+// sanitizeURL drops the query of a URL, which often carries signatures
+func sanitizeURL(u string) string {
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		return u[:i]
+	}
+	return u
+}
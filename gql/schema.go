@@ -0,0 +1,156 @@
+// Package gql exposes the Toniebox data model (households → Creative-Tonies
+// → chapters) as a GraphQL schema, so frontends can query exactly the fields
+// they need from a single endpoint.
+//
+// Example query:
+//
+//	{
+//	  households {
+//	    name
+//	    creativeTonies {
+//	      name
+//	      secondsRemaining
+//	      chapters { title seconds }
+//	    }
+//	  }
+//	}
+package gql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// NewSchema builds a GraphQL schema whose resolvers are backed by client
+func NewSchema(client *toniebox.Client) (graphql.Schema, error) {
+	chapterType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Chapter",
+		Description: "A chapter/track on a Creative-Tonie",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"file":        &graphql.Field{Type: graphql.String},
+			"title":       &graphql.Field{Type: graphql.String},
+			"seconds":     &graphql.Field{Type: graphql.Float},
+			"transcoding": &graphql.Field{Type: graphql.Boolean},
+		},
+	})
+
+	tonieType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "CreativeTonie",
+		Description: "A Creative-Tonie figurine",
+		Fields: graphql.Fields{
+			"id":                &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":              &graphql.Field{Type: graphql.String},
+			"live":              &graphql.Field{Type: graphql.Boolean},
+			"private":           &graphql.Field{Type: graphql.Boolean},
+			"imageUrl":          &graphql.Field{Type: graphql.String},
+			"transcodingErrors": &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"transcoding":       &graphql.Field{Type: graphql.Boolean},
+			"secondsPresent":    &graphql.Field{Type: graphql.Float},
+			"secondsRemaining":  &graphql.Field{Type: graphql.Float},
+			"chaptersPresent":   &graphql.Field{Type: graphql.Int},
+			"chaptersRemaining": &graphql.Field{Type: graphql.Int},
+			"chapters":          &graphql.Field{Type: graphql.NewList(chapterType)},
+			"householdId":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	householdType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Household",
+		Description: "A Toniebox household",
+		Fields: graphql.Fields{
+			"id":                          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":                        &graphql.Field{Type: graphql.String},
+			"image":                       &graphql.Field{Type: graphql.String},
+			"foreignCreativeTonieContent": &graphql.Field{Type: graphql.Boolean},
+			"access":                      &graphql.Field{Type: graphql.String},
+			"canLeave":                    &graphql.Field{Type: graphql.Boolean},
+			"ownerName":                   &graphql.Field{Type: graphql.String},
+			"creativeTonies": &graphql.Field{
+				Type: graphql.NewList(tonieType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					household := p.Source.(toniebox.Household)
+					return client.GetCreativeToniesContext(p.Context, &household)
+				},
+			},
+			"creativeTonie": &graphql.Field{
+				Type: tonieType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					household := p.Source.(toniebox.Household)
+					tonies, err := client.GetCreativeToniesContext(p.Context, &household)
+					if err != nil {
+						return nil, err
+					}
+					id := p.Args["id"].(string)
+					for _, tonie := range tonies {
+						if tonie.ID == id {
+							return tonie, nil
+						}
+					}
+					return nil, fmt.Errorf("creative tonie %s not found", id)
+				},
+			},
+		},
+	})
+
+	meType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Me",
+		Description: "The authenticated user",
+		Fields: graphql.Fields{
+			"email":                        &graphql.Field{Type: graphql.String},
+			"uuid":                         &graphql.Field{Type: graphql.String},
+			"firstName":                    &graphql.Field{Type: graphql.String},
+			"lastName":                     &graphql.Field{Type: graphql.String},
+			"acceptedTermsOfUse":           &graphql.Field{Type: graphql.Boolean},
+			"profileImage":                 &graphql.Field{Type: graphql.String},
+			"isVerified":                   &graphql.Field{Type: graphql.Boolean},
+			"isEduUser":                    &graphql.Field{Type: graphql.Boolean},
+			"notificationCount":            &graphql.Field{Type: graphql.Int},
+			"requiresVerificationToUpload": &graphql.Field{Type: graphql.Boolean},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type: meType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return client.GetMeContext(p.Context)
+				},
+			},
+			"households": &graphql.Field{
+				Type: graphql.NewList(householdType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return client.GetHouseholdsContext(p.Context)
+				},
+			},
+			"household": &graphql.Field{
+				Type: householdType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					households, err := client.GetHouseholdsContext(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					id := p.Args["id"].(string)
+					for _, household := range households {
+						if household.ID == id {
+							return household, nil
+						}
+					}
+					return nil, fmt.Errorf("household %s not found", id)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
@@ -0,0 +1,100 @@
+package toniebox
+
+import (
+	"time"
+)
+
+// HistoryKind identifies the type of a HistoryEvent
+type HistoryKind string
+
+const (
+	// HistoryUpload records a chapter that was added to a tonie
+	HistoryUpload HistoryKind = "upload"
+	// HistoryDelete records a chapter that was removed from a tonie
+	HistoryDelete HistoryKind = "delete"
+)
+
+// HistoryEvent records a completed chapter upload or deletion.
+// The Toniecloud API offers no activity feed for households, so history is
+// recorded client-side as changes are committed (see WithHistory) and can be
+// queried from the recorder, e.g. store.Store.History.
+type HistoryEvent struct {
+	Time         time.Time
+	Kind         HistoryKind
+	HouseholdID  string
+	TonieID      string
+	TonieName    string
+	ChapterID    string
+	ChapterTitle string
+	Seconds      float64
+}
+
+// HistoryRecorder persists history events, e.g. in the local state store
+type HistoryRecorder interface {
+	RecordHistory(events []HistoryEvent) error
+}
+
+// WithHistory records every chapter upload and deletion once it has been
+// committed successfully. Failures to record are ignored so that they never
+// fail a commit that already succeeded.
+//
+// Example:
+//
+//	st, _ := store.Open("toniebox.db")
+//	client := toniebox.NewClient(toniebox.WithHistory(st))
+func WithHistory(recorder HistoryRecorder) Option {
+	return func(rh *requestHandler) {
+		rh.history = recorder
+	}
+}
+
+// recordHistory diffs the committed chapters of tonie against the last known
+// server state and records the resulting uploads and deletions
+func (rh *requestHandler) recordHistory(tonie *CreativeTonie) {
+	if rh.history == nil {
+		return
+	}
+
+	events := chapterChanges(tonie, tonie.committedChapters, tonie.Chapters, time.Now())
+	if len(events) > 0 {
+		rh.history.RecordHistory(events)
+	}
+}
+
+// chapterChanges returns the uploads and deletions between two chapter lists
+func chapterChanges(tonie *CreativeTonie, before, after []Chapter, now time.Time) []HistoryEvent {
+	beforeIDs := make(map[string]bool, len(before))
+	for _, ch := range before {
+		beforeIDs[ch.ID] = true
+	}
+	afterIDs := make(map[string]bool, len(after))
+	for _, ch := range after {
+		afterIDs[ch.ID] = true
+	}
+
+	event := func(kind HistoryKind, ch Chapter) HistoryEvent {
+		return HistoryEvent{
+			Time:         now,
+			Kind:         kind,
+			HouseholdID:  tonie.HouseholdID,
+			TonieID:      tonie.ID,
+			TonieName:    tonie.Name,
+			ChapterID:    ch.ID,
+			ChapterTitle: ch.Title,
+			Seconds:      ch.Seconds,
+		}
+	}
+
+	var events []HistoryEvent
+	for _, ch := range before {
+		if !afterIDs[ch.ID] {
+			events = append(events, event(HistoryDelete, ch))
+		}
+	}
+	for _, ch := range after {
+		if !beforeIDs[ch.ID] {
+			events = append(events, event(HistoryUpload, ch))
+		}
+	}
+	return events
+}
@@ -0,0 +1,95 @@
+package toniebox
+
+import "fmt"
+
+// Hooks are callbacks invoked around Creative-Tonie operations. They let
+// applications enforce policies or trigger side effects without wrapping
+// every call site. All fields are optional.
+type Hooks struct {
+	// BeforeUpload is called before a file is uploaded to tonie, after the
+	// local checks passed. Returning an error aborts the upload.
+	BeforeUpload func(tonie *CreativeTonie, title string) error
+	// AfterUpload is called after chapter has been uploaded and added to
+	// tonie. The chapter is not committed yet.
+	AfterUpload func(tonie *CreativeTonie, chapter Chapter)
+	// BeforeCommit is called before tonie is sent to the API with the
+	// chapter uploads and deletions since the last commit, in the same form
+	// as recorded by WithHistory. Returning an error aborts the commit.
+	BeforeCommit func(tonie *CreativeTonie, changes []HistoryEvent) error
+	// AfterCommit is called after tonie has been committed successfully
+	AfterCommit func(tonie *CreativeTonie, changes []HistoryEvent)
+}
+
+// WithHooks registers lifecycle hooks for all Creative-Tonies of the client.
+// The option can be given several times; hooks then run in registration
+// order and the first failing Before hook aborts the operation.
+//
+// Example:
+//
+//	// Forbid deleting chapters tagged "keep" in the local state store
+//	client := toniebox.NewClient(toniebox.WithHooks(toniebox.Hooks{
+//	    BeforeCommit: func(tonie *toniebox.CreativeTonie, changes []toniebox.HistoryEvent) error {
+//	        for _, change := range changes {
+//	            if change.Kind != toniebox.HistoryDelete {
+//	                continue
+//	            }
+//	            meta, err := st.ChapterMeta(change.ChapterID)
+//	            if err != nil {
+//	                continue
+//	            }
+//	            if _, keep := meta.Tags["keep"]; keep {
+//	                return fmt.Errorf("chapter %q is tagged keep", change.ChapterTitle)
+//	            }
+//	        }
+//	        return nil
+//	    },
+//	}))
+func WithHooks(hooks Hooks) Option {
+	return func(rh *requestHandler) {
+		rh.hooks = append(rh.hooks, hooks)
+	}
+}
+
+// beforeUpload runs the BeforeUpload hooks
+func (rh *requestHandler) beforeUpload(tonie *CreativeTonie, title string) error {
+	for _, h := range rh.hooks {
+		if h.BeforeUpload == nil {
+			continue
+		}
+		if err := h.BeforeUpload(tonie, title); err != nil {
+			return fmt.Errorf("upload rejected by hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// afterUpload runs the AfterUpload hooks
+func (rh *requestHandler) afterUpload(tonie *CreativeTonie, chapter Chapter) {
+	for _, h := range rh.hooks {
+		if h.AfterUpload != nil {
+			h.AfterUpload(tonie, chapter)
+		}
+	}
+}
+
+// beforeCommit runs the BeforeCommit hooks
+func (rh *requestHandler) beforeCommit(tonie *CreativeTonie, changes []HistoryEvent) error {
+	for _, h := range rh.hooks {
+		if h.BeforeCommit == nil {
+			continue
+		}
+		if err := h.BeforeCommit(tonie, changes); err != nil {
+			return fmt.Errorf("commit rejected by hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// afterCommit runs the AfterCommit hooks
+func (rh *requestHandler) afterCommit(tonie *CreativeTonie, changes []HistoryEvent) {
+	for _, h := range rh.hooks {
+		if h.AfterCommit != nil {
+			h.AfterCommit(tonie, changes)
+		}
+	}
+}
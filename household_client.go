@@ -0,0 +1,97 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// HouseholdClient is a handle bound to a single household. Its methods work
+// without passing the Household around. Create one with Client.Household.
+type HouseholdClient struct {
+	client *Client
+	id     string
+
+	mu        sync.Mutex
+	household *Household
+}
+
+// Household returns a handle for the household with the given ID.
+// The household is looked up on first use.
+//
+// Example:
+//
+//	home := client.Household(householdID)
+//	tonies, err := home.Tonies()
+func (c *Client) Household(id string) *HouseholdClient {
+	return &HouseholdClient{client: c, id: id}
+}
+
+// ID returns the household ID
+func (hc *HouseholdClient) ID() string {
+	return hc.id
+}
+
+// Info returns the household details.
+// Returns an error if the user does not belong to the household.
+func (hc *HouseholdClient) Info() (*Household, error) {
+	return hc.InfoContext(context.Background())
+}
+
+// InfoContext is like Info, but the lookup is aborted when ctx is done
+func (hc *HouseholdClient) InfoContext(ctx context.Context) (*Household, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.household != nil {
+		return hc.household, nil
+	}
+
+	households, err := hc.client.GetHouseholdsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range households {
+		if households[i].ID == hc.id {
+			hc.household = &households[i]
+			return hc.household, nil
+		}
+	}
+	return nil, fmt.Errorf("household %s not found", hc.id)
+}
+
+// Tonies retrieves all Creative-Tonies in the household
+func (hc *HouseholdClient) Tonies() ([]CreativeTonie, error) {
+	return hc.ToniesContext(context.Background())
+}
+
+// ToniesContext is like Tonies, but the requests are aborted when ctx is done
+func (hc *HouseholdClient) ToniesContext(ctx context.Context) ([]CreativeTonie, error) {
+	household, err := hc.InfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hc.client.GetCreativeToniesContext(ctx, household)
+}
+
+// Tonie retrieves the Creative-Tonie with the given ID.
+// Like Tonies it may return cached data together with a *StaleError.
+func (hc *HouseholdClient) Tonie(id string) (*CreativeTonie, error) {
+	return hc.TonieContext(context.Background(), id)
+}
+
+// TonieContext is like Tonie, but the requests are aborted when ctx is done
+func (hc *HouseholdClient) TonieContext(ctx context.Context, id string) (*CreativeTonie, error) {
+	tonies, err := hc.ToniesContext(ctx)
+	if tonies == nil && err != nil {
+		return nil, err
+	}
+	for i := range tonies {
+		if tonies[i].ID == id {
+			return &tonies[i], err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("creative tonie %s not found", id)
+}
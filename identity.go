@@ -0,0 +1,139 @@
+package toniebox
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoIdentity is returned by JWTToken.Identity if neither the ID token nor
+// the access token is a JWT
+var ErrNoIdentity = errors.New("token carries no identity claims")
+
+// Identity holds the claims of the logged-in user taken from the ID token
+// and the access token
+type Identity struct {
+	// Subject is the ID of the user (the "sub" claim), which matches Me.UUID
+	Subject string
+	// Email is the email address of the account
+	Email string
+	// EmailVerified reports whether the email address has been confirmed
+	EmailVerified bool
+	// Name is the full name of the user
+	Name string
+	// GivenName and FamilyName are the parts of the name, if known
+	GivenName  string
+	FamilyName string
+	// Roles are the roles granted to the user, e.g. by the realm
+	Roles []string
+	// IssuedAt and ExpiresAt are the lifetime of the token, zero if unknown
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// HasRole reports whether the user was granted role
+func (id *Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// claims are the JWT claims the identity is read from
+type claims struct {
+	Subject       string   `json:"sub"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Name          string   `json:"name"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Roles         []string `json:"roles"`
+	RealmAccess   struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// Identity decodes the identity claims of the token. Claims of the ID token
+// take precedence; missing ones are taken from the access token.
+//
+// The signatures are not verified: the token was received over TLS from
+// the login server, and the claims are only meant for display. Do not use
+// them for authorization decisions on tokens received from third parties.
+//
+// Example:
+//
+//	identity, err := token.Identity()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Logged in as %s\n", identity.Email)
+func (t *JWTToken) Identity() (*Identity, error) {
+	var found bool
+	identity := &Identity{}
+	for _, raw := range []string{t.IDToken, t.AccessToken} {
+		if raw == "" {
+			continue
+		}
+		c, err := decodeClaims(raw)
+		if err != nil {
+			continue
+		}
+		found = true
+		identity.merge(c)
+	}
+	if !found {
+		return nil, ErrNoIdentity
+	}
+	return identity, nil
+}
+
+// merge fills the fields of id that are still empty from c
+func (id *Identity) merge(c *claims) {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&id.Subject, c.Subject)
+	fill(&id.Email, c.Email)
+	fill(&id.Name, c.Name)
+	fill(&id.GivenName, c.GivenName)
+	fill(&id.FamilyName, c.FamilyName)
+	id.EmailVerified = id.EmailVerified || c.EmailVerified
+
+	for _, role := range append(c.Roles, c.RealmAccess.Roles...) {
+		if !id.HasRole(role) {
+			id.Roles = append(id.Roles, role)
+		}
+	}
+	if id.IssuedAt.IsZero() && c.IssuedAt > 0 {
+		id.IssuedAt = time.Unix(c.IssuedAt, 0)
+	}
+	if id.ExpiresAt.IsZero() && c.ExpiresAt > 0 {
+		id.ExpiresAt = time.Unix(c.ExpiresAt, 0)
+	}
+}
+
+// decodeClaims decodes the payload of a JWT without verifying its signature
+func decodeClaims(token string) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT payload: %w", err)
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse JWT claims: %w", err)
+	}
+	return &c, nil
+}
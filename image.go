@@ -0,0 +1,46 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// DownloadImage writes the image of this Creative-Tonie to w.
+// The image is fetched with the authenticated client, following redirects.
+// Downloads are cached in memory by ETag, so repeated calls only transfer the
+// image again if it changed.
+//
+// Example:
+//
+//	var buf bytes.Buffer
+//	if err := tonie.DownloadImage(&buf); err != nil {
+//	    log.Fatal(err)
+//	}
+func (ct *CreativeTonie) DownloadImage(w io.Writer) error {
+	return ct.DownloadImageContext(context.Background(), w)
+}
+
+// DownloadImageContext is like DownloadImage, but the download is aborted
+// when ctx is done.
+func (ct *CreativeTonie) DownloadImageContext(ctx context.Context, w io.Writer) error {
+	if ct.requestHandler == nil {
+		return fmt.Errorf("tonie not properly initialized")
+	}
+	return ct.requestHandler.downloadImage(ctx, ct.ImageURL, w)
+}
+
+// DownloadImage writes the image of this household to w.
+// It behaves like CreativeTonie.DownloadImage.
+func (h *Household) DownloadImage(w io.Writer) error {
+	return h.DownloadImageContext(context.Background(), w)
+}
+
+// DownloadImageContext is like DownloadImage, but the download is aborted
+// when ctx is done.
+func (h *Household) DownloadImageContext(ctx context.Context, w io.Writer) error {
+	if h.requestHandler == nil {
+		return fmt.Errorf("household not properly initialized")
+	}
+	return h.requestHandler.downloadImage(ctx, h.Image, w)
+}
@@ -0,0 +1,64 @@
+// Command fixturecheck verifies that the API models round-trip captured
+// payloads without loss, and sanitizes new payloads before they are added.
+//
+// Usage:
+//
+//	go run ./internal/fixturecheck               # check the fixtures in fixtures/payloads
+//	go run ./internal/fixturecheck -dir DIR      # check the fixtures in DIR
+//	go run ./internal/fixturecheck -sanitize FILE
+//
+// See package fixtures for the directory layout.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mikeboe/toniebox-api-go/fixtures"
+)
+
+func main() {
+	dir := flag.String("dir", "", "directory with fixtures (default: the fixtures shipped with the package)")
+	sanitize := flag.String("sanitize", "", "print a sanitized copy of this captured payload and exit")
+	flag.Parse()
+
+	if *sanitize != "" {
+		data, err := os.ReadFile(*sanitize)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out, err := fixtures.Sanitize(data)
+		if err != nil {
+			log.Fatalf("%s: %v", *sanitize, err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	var all []fixtures.Fixture
+	var err error
+	if *dir != "" {
+		all, err = fixtures.Load(os.DirFS(*dir))
+	} else {
+		all, err = fixtures.LoadGolden()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := 0
+	for _, f := range all {
+		if err := fixtures.Check(f); err != nil {
+			failed++
+			fmt.Printf("FAIL %v\n", err)
+			continue
+		}
+		fmt.Printf("ok   %s\n", f)
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d fixtures lose data\n", failed, len(all))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,203 @@
+// Command genmodels generates the Go model structs of the toniebox package
+// from the OpenAPI description in openapi/toniecloud.json.
+//
+// Usage (via go generate in the repository root):
+//
+//	go run ./internal/genmodels -spec openapi/toniecloud.json -out models_gen.go
+//
+// Schema and property order in the spec is preserved in the generated code.
+// The following vendor extensions are supported:
+//   - x-go-name: overrides the Go name of a property
+//   - x-go-type: overrides the Go type of a property (e.g. a named string type)
+//   - x-go-internal-fields: unexported Go fields appended to a struct
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+)
+
+// schema is the subset of an OpenAPI schema object understood by the generator
+type schema struct {
+	Description    string        `json:"description"`
+	Type           string        `json:"type"`
+	Ref            string        `json:"$ref"`
+	Required       []string      `json:"required"`
+	Properties     orderedFields `json:"properties"`
+	Items          *schema       `json:"items"`
+	GoName         string        `json:"x-go-name"`
+	GoType         string        `json:"x-go-type"`
+	InternalFields []string      `json:"x-go-internal-fields"`
+}
+
+// spec is the subset of an OpenAPI document understood by the generator
+type spec struct {
+	Components struct {
+		Schemas orderedFields `json:"schemas"`
+	} `json:"components"`
+}
+
+// namedSchema is a schema together with the key it was declared under
+type namedSchema struct {
+	Name   string
+	Schema schema
+}
+
+// orderedFields decodes a JSON object of schemas while preserving key order
+type orderedFields []namedSchema
+
+// UnmarshalJSON implements json.Unmarshaler
+func (of *orderedFields) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var s schema
+		if err := dec.Decode(&s); err != nil {
+			return fmt.Errorf("%v: %w", key, err)
+		}
+		*of = append(*of, namedSchema{Name: key.(string), Schema: s})
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func main() {
+	specPath := flag.String("spec", "openapi/toniecloud.json", "path to the OpenAPI document")
+	outPath := flag.String("out", "models_gen.go", "path of the generated Go file")
+	pkg := flag.String("package", "toniebox", "Go package name of the generated file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to read spec: %v", err)
+	}
+
+	var doc spec
+	if err := json.Unmarshal(data, &doc); err != nil {
+		log.Fatalf("Failed to parse spec: %v", err)
+	}
+
+	src, err := generate(*pkg, *specPath, doc)
+	if err != nil {
+		log.Fatalf("Failed to generate models: %v", err)
+	}
+
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		log.Fatalf("Failed to write models: %v", err)
+	}
+}
+
+// generate renders the Go source for all schemas in doc
+func generate(pkg, specPath string, doc spec) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by genmodels from %s. DO NOT EDIT.\n\n", specPath)
+	fmt.Fprintf(&buf, "package %s\n", pkg)
+
+	for _, named := range doc.Components.Schemas {
+		s := named.Schema
+		if s.Type != "object" {
+			return nil, fmt.Errorf("schema %s: only object schemas are supported", named.Name)
+		}
+
+		required := make(map[string]bool, len(s.Required))
+		for _, name := range s.Required {
+			required[name] = true
+		}
+
+		buf.WriteString("\n")
+		if s.Description != "" {
+			fmt.Fprintf(&buf, "// %s\n", s.Description)
+		}
+		fmt.Fprintf(&buf, "type %s struct {\n", named.Name)
+		for _, prop := range s.Properties {
+			typ, err := goType(prop.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("schema %s, property %s: %w", named.Name, prop.Name, err)
+			}
+			tag := prop.Name
+			if !required[prop.Name] {
+				tag += ",omitempty"
+			}
+			if prop.Schema.Description != "" {
+				fmt.Fprintf(&buf, "\t// %s\n", prop.Schema.Description)
+			}
+			fmt.Fprintf(&buf, "\t%s %s `json:\"%s\"`\n", goName(prop.Name, prop.Schema.GoName), typ, tag)
+		}
+		if len(s.InternalFields) > 0 {
+			buf.WriteString("\n\t// Internal fields not serialized to JSON\n")
+			for _, field := range s.InternalFields {
+				fmt.Fprintf(&buf, "\t%s `json:\"-\"`\n", field)
+			}
+		}
+		buf.WriteString("}\n")
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// goType maps an OpenAPI schema to a Go type expression
+func goType(s schema) (string, error) {
+	if s.GoType != "" {
+		return s.GoType, nil
+	}
+	if s.Ref != "" {
+		return s.Ref[strings.LastIndex(s.Ref, "/")+1:], nil
+	}
+	switch s.Type {
+	case "string":
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "array":
+		if s.Items == nil {
+			return "", fmt.Errorf("array without items")
+		}
+		item, err := goType(*s.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	default:
+		return "", fmt.Errorf("unsupported type %q", s.Type)
+	}
+}
+
+// initialisms maps lower-case words to their Go spelling
+var initialisms = map[string]string{
+	"id":   "ID",
+	"url":  "URL",
+	"uuid": "UUID",
+}
+
+// goName derives an exported Go field name from a JSON property name
+func goName(property, override string) string {
+	if override != "" {
+		return override
+	}
+	if name, ok := initialisms[property]; ok {
+		return name
+	}
+	name := strings.ToUpper(property[:1]) + property[1:]
+	for _, suffix := range []string{"Id", "Url"} {
+		if strings.HasSuffix(name, suffix) {
+			name = strings.TrimSuffix(name, suffix) + initialisms[strings.ToLower(suffix)]
+		}
+	}
+	return name
+}
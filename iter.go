@@ -0,0 +1,65 @@
+package toniebox
+
+import (
+	"context"
+	"iter"
+)
+
+// AllChapters returns an iterator over the chapters of this Creative-Tonie
+// and their zero-based positions, in playback order
+//
+// Example:
+//
+//	for i, chapter := range tonie.AllChapters() {
+//	    fmt.Printf("%d. %s\n", i+1, chapter.Title)
+//	}
+func (ct *CreativeTonie) AllChapters() iter.Seq2[int, Chapter] {
+	return func(yield func(int, Chapter) bool) {
+		for i, chapter := range ct.Chapters {
+			if !yield(i, chapter) {
+				return
+			}
+		}
+	}
+}
+
+// AllTonies returns an iterator over the Creative-Tonies in a household.
+// The tonies are fetched when the iteration starts; if that fails, the
+// iterator yields the error once. If the tonies are served from the stale
+// cache (see WithStaleCache), the iterator first yields a nil tonie with the
+// *StaleError and then the cached tonies without an error.
+//
+// Example:
+//
+//	for tonie, err := range client.AllTonies(&households[0]) {
+//	    var stale *toniebox.StaleError
+//	    if errors.As(err, &stale) {
+//	        log.Printf("showing tonies from %s", stale.FetchedAt)
+//	        continue
+//	    }
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    fmt.Println(tonie.Name)
+//	}
+func (c *Client) AllTonies(household *Household) iter.Seq2[*CreativeTonie, error] {
+	return c.AllToniesContext(context.Background(), household)
+}
+
+// AllToniesContext is like AllTonies, but the request is aborted when ctx
+// is done.
+func (c *Client) AllToniesContext(ctx context.Context, household *Household) iter.Seq2[*CreativeTonie, error] {
+	return func(yield func(*CreativeTonie, error) bool) {
+		tonies, err := c.GetCreativeToniesContext(ctx, household)
+		if err != nil {
+			if !yield(nil, err) || len(tonies) == 0 {
+				return
+			}
+		}
+		for i := range tonies {
+			if !yield(&tonies[i], nil) {
+				return
+			}
+		}
+	}
+}
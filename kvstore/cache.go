@@ -0,0 +1,79 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// cache adapts a Store to toniebox.Cache
+type cache struct {
+	store Store
+}
+
+// cacheEntry is a cached API response together with its fetch time
+type cacheEntry struct {
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewCache returns a toniebox.Cache keeping households and Creative-Tonies in
+// store, for use with toniebox.WithStaleCache
+func NewCache(store Store) toniebox.Cache {
+	return &cache{store: store}
+}
+
+// put stores v under key
+func (c *cache) put(key string, v interface{}, fetchedAt time.Time) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	entry, err := json.Marshal(cacheEntry{FetchedAt: fetchedAt, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	return c.store.SetMeta(key, string(entry))
+}
+
+// get decodes the value stored under key into v and returns its fetch time
+func (c *cache) get(key string, v interface{}) (time.Time, error) {
+	value, _, err := c.store.Meta(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	if err := json.Unmarshal(entry.Data, v); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return entry.FetchedAt, nil
+}
+
+// PutHouseholds implements toniebox.Cache
+func (c *cache) PutHouseholds(households []toniebox.Household, fetchedAt time.Time) error {
+	return c.put("cache/households", households, fetchedAt)
+}
+
+// Households implements toniebox.Cache
+func (c *cache) Households() ([]toniebox.Household, time.Time, error) {
+	var households []toniebox.Household
+	fetchedAt, err := c.get("cache/households", &households)
+	return households, fetchedAt, err
+}
+
+// PutCreativeTonies implements toniebox.Cache
+func (c *cache) PutCreativeTonies(householdID string, tonies []toniebox.CreativeTonie, fetchedAt time.Time) error {
+	return c.put("cache/tonies/"+householdID, tonies, fetchedAt)
+}
+
+// CreativeTonies implements toniebox.Cache
+func (c *cache) CreativeTonies(householdID string) ([]toniebox.CreativeTonie, time.Time, error) {
+	var tonies []toniebox.CreativeTonie
+	fetchedAt, err := c.get("cache/tonies/"+householdID, &tonies)
+	return tonies, fetchedAt, err
+}
@@ -0,0 +1,145 @@
+// Package kvstore provides pluggable key-value storage for the state of
+// long-running subsystems such as the upload scheduler (job progress) and
+// the directory sync (baselines), as well as for the client's stale cache.
+//
+// Backends:
+//   - NewMemory: in-process, for tests and one-shot runs
+//   - NewFile: one file per key in a local directory
+//   - *store.Store: the SQLite state store
+//   - S3: any S3-compatible object storage, so that containers can run
+//     stateless with external state
+//
+// Example:
+//
+//	kv := &kvstore.S3{
+//	    Endpoint:  "https://s3.eu-central-1.amazonaws.com",
+//	    Region:    "eu-central-1",
+//	    Bucket:    "toniebox-state",
+//	    AccessKey: os.Getenv("S3_ACCESS_KEY"),
+//	    SecretKey: os.Getenv("S3_SECRET_KEY"),
+//	}
+//	sched := &schedule.Scheduler{Client: client, Progress: kv}
+//	client := toniebox.NewClient(toniebox.WithStaleCache(kvstore.NewCache(kv)))
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// Store is a key-value store. Meta returns the value stored under key and
+// when it was last updated, or toniebox.ErrCacheMiss if the key is unknown.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	SetMeta(key, value string) error
+	Meta(key string) (string, time.Time, error)
+}
+
+// memoryEntry is a value held by a Memory store
+type memoryEntry struct {
+	value     string
+	updatedAt time.Time
+}
+
+// Memory is an in-process Store
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemory returns an empty in-process Store
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry)}
+}
+
+// SetMeta implements Store
+func (m *Memory) SetMeta(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{value: value, updatedAt: time.Now()}
+	return nil
+}
+
+// Meta implements Store
+func (m *Memory) Meta(key string) (string, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return "", time.Time{}, toniebox.ErrCacheMiss
+	}
+	return entry.value, entry.updatedAt, nil
+}
+
+// File is a Store keeping one file per key in a directory. Writes are atomic,
+// so a crash never leaves a half-written value behind.
+type File struct {
+	dir string
+}
+
+// NewFile returns a Store in dir, creating the directory if necessary
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return &File{dir: dir}, nil
+}
+
+// path returns the file holding key
+func (f *File) path(key string) string {
+	return filepath.Join(f.dir, url.PathEscape(key))
+}
+
+// SetMeta implements Store
+func (f *File) SetMeta(key, value string) error {
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(value); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// Meta implements Store
+func (f *File) Meta(key string) (string, time.Time, error) {
+	path := f.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", time.Time{}, toniebox.ErrCacheMiss
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return string(data), info.ModTime(), nil
+}
+
+// joinKey joins a key prefix and a key with a slash
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + key
+}
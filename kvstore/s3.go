@@ -0,0 +1,167 @@
+package kvstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// S3 is a Store backed by S3-compatible object storage (AWS S3, MinIO,
+// Cloudflare R2, ...). Each key is stored as an object below Prefix.
+// Requests are signed with AWS Signature Version 4 and use path-style URLs.
+type S3 struct {
+	// Endpoint is the base URL of the service, e.g. "https://s3.eu-central-1.amazonaws.com"
+	Endpoint string `json:"endpoint"`
+	// Region is the signing region, e.g. "eu-central-1"; defaults to "us-east-1"
+	Region string `json:"region,omitempty"`
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to all object keys, e.g. "toniebox/"
+	Prefix    string `json:"prefix,omitempty"`
+	AccessKey string `json:"accessKey,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+	// HTTPClient is used for requests; defaults to http.DefaultClient
+	HTTPClient *http.Client `json:"-"`
+}
+
+// SetMeta implements Store
+func (s *S3) SetMeta(key, value string) error {
+	resp, err := s.do(http.MethodPut, key, []byte(value))
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to store %s: status %d: %s", key, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// Meta implements Store. The update time is the object's Last-Modified date.
+func (s *S3) Meta(key string) (string, time.Time, error) {
+	resp, err := s.do(http.MethodGet, key, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", time.Time{}, toniebox.ErrCacheMiss
+	case resp.StatusCode != http.StatusOK:
+		return "", time.Time{}, fmt.Errorf("failed to read %s: status %d: %s", key, resp.StatusCode, body)
+	case err != nil:
+		return "", time.Time{}, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	updatedAt, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return string(body), updatedAt, nil
+}
+
+// do sends a signed request for the object holding key
+func (s *S3) do(method, key string, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	endpoint.Path += "/" + s.Bucket + "/" + joinKey(s.Prefix, key)
+	endpoint.RawPath = escapePath(endpoint.Path)
+
+	req, err := http.NewRequest(method, endpoint.String(), strings.NewReader(string(body)))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	s.sign(req, body, time.Now().UTC())
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// sign adds an AWS Signature Version 4 to req
+func (s *S3) sign(req *http.Request, body []byte, now time.Time) {
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapePath URI-encodes every path segment as required by Signature Version 4
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes everything but unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sha256Hex returns the hex-encoded SHA-256 hash of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,94 @@
+// Package library indexes local audio files so that chapters on a
+// Creative-Tonie can be mapped back to the files they were created from.
+package library
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// Index maps chapter titles to local audio files
+type Index struct {
+	byTitle map[string]string
+}
+
+// Build walks the given root directories recursively and indexes every audio
+// file (see toniebox.DefaultAudioExtensions) by its file name without
+// extension and by its title tag. When several files share a key, the first
+// one found wins.
+//
+// Example:
+//
+//	index, err := library.Build("/srv/media/kids")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	path, ok := index.Lookup(tonie.Chapters[0])
+func Build(roots ...string) (*Index, error) {
+	ix := &Index{byTitle: make(map[string]string)}
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !isAudio(path) {
+				return nil
+			}
+			ix.Add(path)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to index %s: %w", root, err)
+		}
+	}
+	return ix, nil
+}
+
+// Add indexes a single file
+func (ix *Index) Add(path string) {
+	base := filepath.Base(path)
+	ix.add(strings.TrimSuffix(base, filepath.Ext(base)), path)
+	if tags, err := audio.ReadTags(path); err == nil && tags.Title != "" {
+		ix.add(tags.Title, path)
+	}
+}
+
+// Len returns the number of indexed keys
+func (ix *Index) Len() int {
+	return len(ix.byTitle)
+}
+
+// Lookup returns the local file a chapter was most likely created from
+func (ix *Index) Lookup(chapter toniebox.Chapter) (string, bool) {
+	path, ok := ix.byTitle[normalize(chapter.Title)]
+	return path, ok
+}
+
+// add registers path under key unless the key is already taken
+func (ix *Index) add(key, path string) {
+	key = normalize(key)
+	if _, exists := ix.byTitle[key]; !exists {
+		ix.byTitle[key] = path
+	}
+}
+
+// normalize makes title matching case- and whitespace-insensitive
+func normalize(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// isAudio reports whether path has a known audio file extension
+func isAudio(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range toniebox.DefaultAudioExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package toniebox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrClientClosed is returned for requests made after Client.Close
+var ErrClientClosed = errors.New("client is closed")
+
+// lifecycle tracks the requests in flight so that Close can wait for them
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	// idle is closed when the last request in flight finishes after Close
+	idle chan struct{}
+
+	// done is canceled by Close; abort cancels the requests in flight once
+	// the deadline of Close has passed
+	done        context.Context
+	cancelDone  context.CancelFunc
+	abort       context.Context
+	cancelAbort context.CancelFunc
+}
+
+// init prepares the contexts of the lifecycle
+func (l *lifecycle) init() {
+	l.done, l.cancelDone = context.WithCancel(context.Background())
+	l.abort, l.cancelAbort = context.WithCancel(context.Background())
+}
+
+// begin registers a request in flight. The returned context is canceled if
+// Close gives up waiting; end must be called once the request is finished.
+func (l *lifecycle) begin(parent context.Context) (ctx context.Context, end func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, nil, ErrClientClosed
+	}
+	l.inflight++
+
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(l.abort, cancel)
+	var once sync.Once
+	end = func() {
+		once.Do(func() {
+			stop()
+			cancel()
+			l.mu.Lock()
+			l.inflight--
+			if l.inflight == 0 && l.idle != nil {
+				close(l.idle)
+				l.idle = nil
+			}
+			l.mu.Unlock()
+		})
+	}
+	return ctx, end, nil
+}
+
+// close rejects new requests and waits until the requests in flight are
+// finished or ctx is done, in which case they are canceled
+func (l *lifecycle) close(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.closed = true
+	l.cancelDone()
+	var idle chan struct{}
+	if l.inflight > 0 {
+		idle = make(chan struct{})
+		l.idle = idle
+	}
+	l.mu.Unlock()
+
+	if idle == nil {
+		return true, nil
+	}
+	select {
+	case <-idle:
+		return true, nil
+	case <-ctx.Done():
+		l.cancelAbort()
+		return true, ctx.Err()
+	}
+}
+
+// releasingBody ends the request of a response once its body is closed
+type releasingBody struct {
+	io.ReadCloser
+	end func()
+}
+
+// Close implements io.Closer
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.end()
+	return err
+}
+
+// Close shuts the client down: new requests fail with ErrClientClosed,
+// requests in flight may finish until ctx is done and are canceled after
+// that, watchers and schedulers bound to the client via Context stop, the
+// session is disconnected like with Disconnect and idle connections are
+// closed. Calling Close again has no effect.
+//
+// Returns ctx.Err() if requests had to be canceled, joined with the error of
+// the token revocation.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := client.Close(ctx); err != nil {
+//	    log.Printf("shutdown: %v", err)
+//	}
+func (c *Client) Close(ctx context.Context) error {
+	rh := c.requestHandler
+	first, err := rh.life.close(ctx)
+	if !first {
+		return nil
+	}
+
+	// The revocation needs the token, so it is only dropped now
+	if disconnectErr := rh.disconnect(ctx, true); disconnectErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to disconnect: %w", disconnectErr))
+	}
+	rh.imagesMu.Lock()
+	rh.images = nil
+	rh.imagesMu.Unlock()
+	rh.client.CloseIdleConnections()
+	return err
+}
+
+// Done returns a channel that is closed when Close is called
+func (c *Client) Done() <-chan struct{} {
+	return c.requestHandler.life.done.Done()
+}
+
+// Context returns a copy of parent that is also canceled when the client is
+// closed. Long-running tasks working with the client, such as watchers and
+// schedulers, run with it so that Close stops them.
+//
+// Example:
+//
+//	ctx, cancel := client.Context(ctx)
+//	defer cancel()
+func (c *Client) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(c.requestHandler.life.done, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
@@ -0,0 +1,97 @@
+package toniebox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxChapters is the maximum number of chapters the Toniecloud accepts
+// on a single Creative-Tonie
+const DefaultMaxChapters = 99
+
+// ErrChapterLimit is returned when adding a chapter would exceed the maximum
+// number of chapters of a Creative-Tonie
+var ErrChapterLimit = errors.New("chapter limit reached")
+
+// ErrFileTooLarge is returned when a file exceeds the upload size limit
+// reported by GetLimits or set with WithMaxUploadSize
+var ErrFileTooLarge = errors.New("file too large")
+
+// WithMaxChapters overrides the maximum number of chapters per Creative-Tonie
+// enforced locally before uploads, in case the service limit changes.
+// Values below 1 restore DefaultMaxChapters, or the limit reported by
+// GetLimits.
+func WithMaxChapters(n int) Option {
+	return func(rh *requestHandler) {
+		rh.maxChapters = n
+	}
+}
+
+// GetLimits retrieves the service limits: the maximum number of chapters
+// and the recording time of a Creative-Tonie and the maximum upload size.
+// The limits are remembered, so that the local checks before uploads and
+// commits use them instead of DefaultMaxChapters; call GetLimits once after
+// logging in. A limit set with WithMaxChapters still takes precedence.
+//
+// The remaining capacity of a single tonie is reported by its
+// ChaptersRemaining and SecondsRemaining.
+//
+// Example:
+//
+//	limits, err := client.GetLimits()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Up to %d chapters and %d minutes per tonie\n", limits.MaxChapters, limits.MaxSeconds/60)
+func (c *Client) GetLimits() (*Limits, error) {
+	return c.GetLimitsContext(context.Background())
+}
+
+// GetLimitsContext is like GetLimits, but the request is aborted when ctx is
+// done.
+func (c *Client) GetLimitsContext(ctx context.Context) (*Limits, error) {
+	return c.requestHandler.getLimits(ctx)
+}
+
+// getLimits retrieves and remembers the service limits
+func (rh *requestHandler) getLimits(ctx context.Context) (*Limits, error) {
+	var result Limits
+	if err := rh.executeGetRequest(ctx, config, &result); err != nil {
+		return nil, err
+	}
+	rh.limits.Store(&result)
+	limits := result
+	return &limits, nil
+}
+
+// serviceLimits returns the limits retrieved by GetLimits, or zero limits if
+// they are not known
+func (rh *requestHandler) serviceLimits() Limits {
+	if rh == nil {
+		return Limits{}
+	}
+	if limits := rh.limits.Load(); limits != nil {
+		return *limits
+	}
+	return Limits{}
+}
+
+// MaxChapters returns the maximum number of chapters of this Creative-Tonie
+func (ct *CreativeTonie) MaxChapters() int {
+	if ct.requestHandler != nil && ct.requestHandler.maxChapters > 0 {
+		return ct.requestHandler.maxChapters
+	}
+	if limit := ct.requestHandler.serviceLimits().MaxChapters; limit > 0 {
+		return limit
+	}
+	return DefaultMaxChapters
+}
+
+// checkChapterLimit returns ErrChapterLimit if no further chapter fits
+func (ct *CreativeTonie) checkChapterLimit() error {
+	if limit := ct.MaxChapters(); len(ct.Chapters) >= limit {
+		return fmt.Errorf("%w: tonie %q already has %d of %d chapters", ErrChapterLimit, ct.Name, len(ct.Chapters), limit)
+	}
+	return nil
+}
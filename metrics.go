@@ -0,0 +1,216 @@
+package toniebox
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestStats describes a single HTTP round trip to the Toniecloud or S3.
+// Retries are reported as separate round trips.
+type RequestStats struct {
+	Operation Operation
+	Method    string
+	Host      string
+	// FinalURL is the URL that produced the response, after following
+	// redirects; empty if no response was received
+	FinalURL string
+	// Redirects is the number of redirects that were followed
+	Redirects int
+	// StatusCode is zero if no response was received
+	StatusCode int
+	Err        error
+	// Duration is the time until the response headers were received. For
+	// uploads this includes transferring the request body.
+	Duration time.Duration
+	// BytesSent is the size of the request body, or -1 if unknown
+	BytesSent int64
+	// ConnReused reports whether an idle keep-alive connection was reused
+	ConnReused bool
+	// TLSHandshake is the duration of the TLS handshake; zero if the
+	// connection was reused or no handshake took place
+	TLSHandshake time.Duration
+}
+
+// Throughput returns the upload rate of the request in bytes per second,
+// or zero if it cannot be determined
+func (s RequestStats) Throughput() float64 {
+	if s.BytesSent <= 0 || s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.BytesSent) / s.Duration.Seconds()
+}
+
+// Metrics receives measurements of every HTTP round trip made by a client.
+// Implementations must be safe for concurrent use and should return quickly.
+type Metrics interface {
+	ObserveRequest(stats RequestStats)
+}
+
+// MetricsFunc adapts a function to the Metrics interface
+type MetricsFunc func(stats RequestStats)
+
+// ObserveRequest implements Metrics
+func (f MetricsFunc) ObserveRequest(stats RequestStats) {
+	f(stats)
+}
+
+// WithMetrics reports every HTTP round trip to m, e.g. to export them to a
+// monitoring system.
+//
+// Example:
+//
+//	collector := toniebox.NewMetricsCollector()
+//	client := toniebox.NewClient(toniebox.WithMetrics(collector))
+func WithMetrics(m Metrics) Option {
+	return func(rh *requestHandler) {
+		rh.metrics = m
+	}
+}
+
+// send performs a single round trip and reports it to the metrics hook and
+// the warning handler
+func (rh *requestHandler) send(req *http.Request, op Operation) (*http.Response, error) {
+	if rh.metrics == nil {
+		if !rh.warningsEnabled() {
+			return rh.client.Do(req)
+		}
+		start := time.Now()
+		resp, err := rh.client.Do(req)
+		rh.warnResponse(req, op, resp, time.Since(start))
+		return resp, err
+	}
+
+	stats := RequestStats{
+		Operation: op,
+		Method:    req.Method,
+		Host:      req.URL.Host,
+		BytesSent: req.ContentLength,
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		stats.BytesSent = 0
+	}
+
+	var handshakeStart time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			stats.ConnReused = info.Reused
+		},
+		TLSHandshakeStart: func() {
+			handshakeStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			if !handshakeStart.IsZero() {
+				stats.TLSHandshake = time.Since(handshakeStart)
+			}
+		},
+	}
+	traced := req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
+	resp, err := rh.client.Do(traced)
+	stats.Duration = time.Since(start)
+	stats.Err = err
+	if resp != nil {
+		stats.StatusCode = resp.StatusCode
+		stats.FinalURL = resp.Request.URL.String()
+		for r := resp.Request; r.Response != nil; r = r.Response.Request {
+			stats.Redirects++
+		}
+	}
+	rh.metrics.ObserveRequest(stats)
+	rh.warnResponse(req, op, resp, stats.Duration)
+	return resp, err
+}
+
+// HostMetrics aggregates the round trips to one host
+type HostMetrics struct {
+	Requests       int
+	Errors         int
+	ReusedConns    int
+	TLSHandshakes  int
+	HandshakeTime  time.Duration
+	BytesSent      int64
+	UploadDuration time.Duration
+	TotalDuration  time.Duration
+}
+
+// ReuseRatio returns the share of requests that reused a connection
+func (m HostMetrics) ReuseRatio() float64 {
+	if m.Requests == 0 {
+		return 0
+	}
+	return float64(m.ReusedConns) / float64(m.Requests)
+}
+
+// Throughput returns the average upload rate in bytes per second across all
+// requests that sent a body
+func (m HostMetrics) Throughput() float64 {
+	if m.BytesSent == 0 || m.UploadDuration <= 0 {
+		return 0
+	}
+	return float64(m.BytesSent) / m.UploadDuration.Seconds()
+}
+
+// AverageHandshake returns the mean TLS handshake duration
+func (m HostMetrics) AverageHandshake() time.Duration {
+	if m.TLSHandshakes == 0 {
+		return 0
+	}
+	return m.HandshakeTime / time.Duration(m.TLSHandshakes)
+}
+
+// MetricsCollector is a Metrics implementation that aggregates round trips
+// per host. Comparing the S3 upload host with the API hosts helps to tell
+// Toniecloud slowness apart from local network problems.
+type MetricsCollector struct {
+	mu    sync.Mutex
+	hosts map[string]*HostMetrics
+}
+
+// NewMetricsCollector creates an empty MetricsCollector
+func NewMetricsCollector() *MetricsCollector {
+	return &MetricsCollector{hosts: make(map[string]*HostMetrics)}
+}
+
+// ObserveRequest implements Metrics
+func (c *MetricsCollector) ObserveRequest(stats RequestStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m, ok := c.hosts[stats.Host]
+	if !ok {
+		m = &HostMetrics{}
+		c.hosts[stats.Host] = m
+	}
+	m.Requests++
+	m.TotalDuration += stats.Duration
+	if stats.Err != nil || stats.StatusCode >= 400 {
+		m.Errors++
+	}
+	if stats.ConnReused {
+		m.ReusedConns++
+	}
+	if stats.TLSHandshake > 0 {
+		m.TLSHandshakes++
+		m.HandshakeTime += stats.TLSHandshake
+	}
+	if stats.BytesSent > 0 && stats.Err == nil {
+		m.BytesSent += stats.BytesSent
+		m.UploadDuration += stats.Duration
+	}
+}
+
+// Snapshot returns the aggregated metrics per host
+func (c *MetricsCollector) Snapshot() map[string]HostMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make(map[string]HostMetrics, len(c.hosts))
+	for host, m := range c.hosts {
+		result[host] = *m
+	}
+	return result
+}
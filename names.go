@@ -0,0 +1,78 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+)
+
+// CheckTonieName reports whether the tonie with ID tonieID can be named name
+// without clashing with another tonie in tonies. It returns a
+// *DuplicateNameError listing the conflicting tonies if the name is taken.
+//
+// Example:
+//
+//	if err := toniebox.CheckTonieName(tonies, tonie.ID, "Bedtime"); err != nil {
+//	    log.Fatal(err)
+//	}
+func CheckTonieName(tonies []CreativeTonie, tonieID, name string) error {
+	var conflicts []string
+	for _, other := range tonies {
+		if other.ID != tonieID && other.Name == name {
+			conflicts = append(conflicts, other.ID)
+		}
+	}
+	if len(conflicts) > 0 {
+		return &DuplicateNameError{Name: name, ConflictingIDs: conflicts}
+	}
+	return nil
+}
+
+// CheckName reports whether this Creative-Tonie can be renamed to name without
+// creating a duplicate name within its household. The household's tonies are
+// fetched from the Toniebox cloud.
+//
+// Returns a *DuplicateNameError if the name is already in use.
+func (ct *CreativeTonie) CheckName(name string) error {
+	return ct.CheckNameContext(context.Background(), name)
+}
+
+// CheckNameContext is like CheckName, but the request is aborted when ctx
+// is done.
+func (ct *CreativeTonie) CheckNameContext(ctx context.Context, name string) error {
+	if ct.requestHandler == nil || ct.household == nil {
+		return fmt.Errorf("tonie not properly initialized")
+	}
+
+	tonies, err := ct.requestHandler.getCreativeTonies(ctx, ct.household)
+	if err != nil {
+		return fmt.Errorf("failed to list household tonies: %w", err)
+	}
+	return CheckTonieName(tonies, ct.ID, name)
+}
+
+// Rename changes the name of this Creative-Tonie after checking that no other
+// tonie in the household already uses it.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Returns a *DuplicateNameError if the name is already in use.
+//
+// Example:
+//
+//	if err := tonie.Rename("Bedtime"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	err := tonie.Commit()
+func (ct *CreativeTonie) Rename(name string) error {
+	return ct.RenameContext(context.Background(), name)
+}
+
+// RenameContext is like Rename, but the name check is aborted when ctx is
+// done.
+// Note: You must call Commit() after this to persist the changes.
+func (ct *CreativeTonie) RenameContext(ctx context.Context, name string) error {
+	if err := ct.CheckNameContext(ctx, name); err != nil {
+		return err
+	}
+	ct.Name = name
+	return nil
+}
@@ -0,0 +1,109 @@
+// Package notify defines the Notifier hook invoked by long-running
+// subsystems (sync engine, scheduler, ...) when a job completes or fails,
+// together with reference implementations for Slack, Discord and generic
+// JSON webhooks.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status describes the outcome of a job
+type Status string
+
+const (
+	// StatusSucceeded indicates that the job finished without errors
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed indicates that the job finished with an error
+	StatusFailed Status = "failed"
+)
+
+// Event describes the outcome of a single job run
+type Event struct {
+	// Job is a short, human-readable name of the job (e.g. "sync kids-room")
+	Job string
+	// Status is the outcome of the job
+	Status Status
+	// Summary is an optional one-line description of what happened
+	Summary string
+	// Err is the error that caused the job to fail, if any
+	Err error
+	// StartedAt and FinishedAt delimit the job run
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// NewEvent builds an Event for a job that started at startedAt and finished
+// now. The status is derived from err.
+func NewEvent(job string, startedAt time.Time, summary string, err error) Event {
+	status := StatusSucceeded
+	if err != nil {
+		status = StatusFailed
+	}
+	return Event{
+		Job:        job,
+		Status:     status,
+		Summary:    summary,
+		Err:        err,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+}
+
+// Duration returns how long the job ran
+func (e Event) Duration() time.Duration {
+	return e.FinishedAt.Sub(e.StartedAt)
+}
+
+// String returns a one-line, human-readable description of the event
+func (e Event) String() string {
+	msg := fmt.Sprintf("%s %s after %s", e.Job, e.Status, e.Duration().Round(time.Second))
+	if e.Summary != "" {
+		msg += ": " + e.Summary
+	}
+	if e.Err != nil {
+		msg += " (" + e.Err.Error() + ")"
+	}
+	return msg
+}
+
+// Notifier is informed about the outcome of jobs
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Func adapts an ordinary function to the Notifier interface
+type Func func(ctx context.Context, event Event) error
+
+// Notify implements Notifier
+func (f Func) Notify(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Multi fans an event out to several notifiers. All notifiers are invoked
+// even if some of them fail; the errors are joined.
+type Multi []Notifier
+
+// Notify implements Notifier
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FailuresOnly wraps a notifier so that it is only invoked for failed jobs
+func FailuresOnly(n Notifier) Notifier {
+	return Func(func(ctx context.Context, event Event) error {
+		if event.Status != StatusFailed {
+			return nil
+		}
+		return n.Notify(ctx, event)
+	})
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook posts events as JSON to an arbitrary HTTP endpoint
+type Webhook struct {
+	// URL is the endpoint the event is posted to
+	URL string
+	// Headers are added to every request (e.g. an Authorization header)
+	Headers map[string]string
+	// Client is the HTTP client to use; http.DefaultClient if nil
+	Client *http.Client
+}
+
+// webhookPayload is the JSON document sent by Webhook
+type webhookPayload struct {
+	Job        string    `json:"job"`
+	Status     Status    `json:"status"`
+	Summary    string    `json:"summary,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// Notify implements Notifier
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	payload := webhookPayload{
+		Job:        event.Job,
+		Status:     event.Status,
+		Summary:    event.Summary,
+		StartedAt:  event.StartedAt,
+		FinishedAt: event.FinishedAt,
+	}
+	if event.Err != nil {
+		payload.Error = event.Err.Error()
+	}
+	return postJSON(ctx, w.Client, w.URL, w.Headers, payload)
+}
+
+// Slack posts events to a Slack incoming webhook
+type Slack struct {
+	// WebhookURL is the Slack incoming webhook URL
+	WebhookURL string
+	// Client is the HTTP client to use; http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Notify implements Notifier
+func (s *Slack) Notify(ctx context.Context, event Event) error {
+	icon := ":white_check_mark:"
+	if event.Status == StatusFailed {
+		icon = ":x:"
+	}
+	payload := map[string]string{"text": icon + " " + event.String()}
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, payload)
+}
+
+// Discord posts events to a Discord channel webhook
+type Discord struct {
+	// WebhookURL is the Discord webhook URL
+	WebhookURL string
+	// Client is the HTTP client to use; http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Notify implements Notifier
+func (d *Discord) Notify(ctx context.Context, event Event) error {
+	icon := "✅"
+	if event.Status == StatusFailed {
+		icon = "❌"
+	}
+	payload := map[string]string{"content": icon + " " + event.String()}
+	return postJSON(ctx, d.Client, d.WebhookURL, nil, payload)
+}
+
+// postJSON sends payload as a JSON POST request and checks for a 2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("notification failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package toniebox
+
+import (
+	"strings"
+)
+
+// WithClientID sets the OAuth client ID used to log in and renew tokens.
+// Alternative frontends, such as the EDU portal or regional apps, are
+// registered with the login server under their own client IDs. Defaults to
+// "my-tonies".
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithClientID("tonies-edu"))
+func WithClientID(clientID string) Option {
+	return func(rh *requestHandler) {
+		rh.clientID = clientID
+	}
+}
+
+// WithScopes sets the OAuth scopes requested at login. Without "openid" the
+// login server issues no ID token, so JWTToken.Identity only returns the
+// claims of the access token. Defaults to "openid".
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithScopes("openid", "email", "offline_access"))
+func WithScopes(scopes ...string) Option {
+	return func(rh *requestHandler) {
+		rh.scopes = append([]string(nil), scopes...)
+	}
+}
+
+// oauthClientID returns the configured OAuth client ID
+func (rh *requestHandler) oauthClientID() string {
+	if rh.clientID != "" {
+		return rh.clientID
+	}
+	return clientID
+}
+
+// oauthScope returns the configured scopes in the form of the scope parameter
+func (rh *requestHandler) oauthScope() string {
+	if len(rh.scopes) > 0 {
+		return strings.Join(rh.scopes, " ")
+	}
+	return scopeOpenID
+}
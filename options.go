@@ -0,0 +1,77 @@
+package toniebox
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Option configures optional behaviour of a Client.
+type Option func(*requestHandler)
+
+// WithHTTPClient replaces the underlying HTTP client used for all requests.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithHTTPClient(&http.Client{Timeout: time.Minute}))
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(rh *requestHandler) {
+		if httpClient != nil {
+			rh.client = httpClient
+		}
+	}
+}
+
+// WithTransport replaces the transport of the underlying HTTP client.
+// This is useful for platforms with a custom network stack, such as the
+// fetch-based transport used when compiling for GOOS=js/GOARCH=wasm.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithTransport(http.DefaultTransport))
+func WithTransport(transport http.RoundTripper) Option {
+	return func(rh *requestHandler) {
+		if transport != nil {
+			rh.client.Transport = transport
+		}
+	}
+}
+
+// ErrTooManyRedirects is returned when a request exceeds the redirect limit
+// set with WithRedirectPolicy
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// WithRedirectPolicy limits how many redirects a request may follow.
+// With maxRedirects set to 0, redirects are not followed at all: the 3xx
+// response is returned as an *APIError whose Location holds the target.
+// If sameHostOnly is set, redirects to another host are not followed either.
+// The final URL of every request is reported in RequestStats.FinalURL.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithRedirectPolicy(3, false))
+func WithRedirectPolicy(maxRedirects int, sameHostOnly bool) Option {
+	return WithCheckRedirect(func(req *http.Request, via []*http.Request) error {
+		if maxRedirects == 0 {
+			return http.ErrUseLastResponse
+		}
+		if sameHostOnly && req.URL.Host != via[0].URL.Host {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, maxRedirects)
+		}
+		return nil
+	})
+}
+
+// WithCheckRedirect sets the redirect policy of the underlying HTTP client,
+// see http.Client.CheckRedirect. The client is copied, so an *http.Client
+// passed to WithHTTPClient is not modified.
+func WithCheckRedirect(check func(req *http.Request, via []*http.Request) error) Option {
+	return func(rh *requestHandler) {
+		client := *rh.client
+		client.CheckRedirect = check
+		rh.client = &client
+	}
+}
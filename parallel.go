@@ -0,0 +1,45 @@
+package toniebox
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// runParallel calls fn for every index in [0, n) using at most workers
+// goroutines. Panics are recovered into *PanicError. The failures are
+// returned as a *MultiError in index order, or nil if all calls succeeded.
+func runParallel(n, workers int, fn func(i int) error) error {
+	if workers <= 0 || workers > n {
+		workers = n
+	}
+
+	errs := make([]error, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = safeCall(func() error { return fn(i) })
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return newMultiError(errs)
+}
+
+// safeCall calls fn and converts a panic into a *PanicError
+func safeCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,113 @@
+package toniebox
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrCertificatePinMismatch is returned when a pinned host presents a
+// certificate chain that matches none of its pins
+var ErrCertificatePinMismatch = errors.New("certificate does not match any pin")
+
+// ErrCertificatePinsUnenforced is returned for every request of a client with
+// certificate pins whose transport cannot verify them
+var ErrCertificatePinsUnenforced = errors.New("certificate pins cannot be enforced by the transport")
+
+// WithCertificatePins pins the TLS certificates of API hosts.
+// pins maps a host name such as "login.tonies.com" to the accepted
+// base64-encoded SHA-256 hashes of a certificate's SubjectPublicKeyInfo (the
+// format used by HTTP Public Key Pinning). A connection is accepted if any
+// certificate in the verified chain matches one of the host's pins; hosts
+// without pins are not affected. Normal certificate verification still applies.
+//
+// Pinning lets security-conscious deployments detect TLS interception of
+// credential traffic. Include a backup pin, e.g. of the issuing CA, so that
+// routine certificate renewals do not break the client.
+//
+// The pins are verified during the TLS handshake, which requires an
+// *http.Transport. If the transport cannot verify them, e.g. a custom
+// transport, the QUIC transport or the browser's fetch API, or if it is
+// replaced by a later WithTransport or WithHTTPClient, every request fails
+// with ErrCertificatePinsUnenforced instead of being sent unpinned.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithCertificatePins(map[string][]string{
+//	    "login.tonies.com": {primaryPin, backupPin},
+//	    "api.tonie.cloud":  {primaryPin, backupPin},
+//	}))
+func WithCertificatePins(pins map[string][]string) Option {
+	accepted := make(map[string]map[string]bool, len(pins))
+	for host, hashes := range pins {
+		accepted[host] = make(map[string]bool, len(hashes))
+		for _, hash := range hashes {
+			accepted[host][hash] = true
+		}
+	}
+
+	install := withHTTPTransport(func(transport *http.Transport) {
+		config := transport.TLSClientConfig
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+
+		verify := config.VerifyConnection
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+			return checkPins(cs, accepted[cs.ServerName])
+		}
+		transport.TLSClientConfig = config
+	})
+	return func(rh *requestHandler) {
+		rh.pinned = true
+		rh.pinnedTransport = nil
+		install(rh)
+		if transport, ok := rh.client.Transport.(*http.Transport); ok {
+			rh.pinnedTransport = transport
+		}
+	}
+}
+
+// checkPinsEnforced fails if certificate pins are set but the transport of
+// the client is not the one verifying them
+func (rh *requestHandler) checkPinsEnforced() error {
+	if !rh.pinned || (rh.pinnedTransport != nil && rh.client.Transport == rh.pinnedTransport) {
+		return nil
+	}
+	return fmt.Errorf("%w: %T", ErrCertificatePinsUnenforced, rh.client.Transport)
+}
+
+// SPKIPin returns the pin of cert in the format expected by WithCertificatePins
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// checkPins verifies that a certificate of the connection matches one of pins
+func checkPins(cs tls.ConnectionState, pins map[string]bool) error {
+	if len(pins) == 0 {
+		return nil
+	}
+
+	certs := cs.PeerCertificates
+	if len(cs.VerifiedChains) > 0 {
+		certs = cs.VerifiedChains[0]
+	}
+	for _, cert := range certs {
+		if pins[SPKIPin(cert)] {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", cs.ServerName, ErrCertificatePinMismatch)
+}
@@ -0,0 +1,188 @@
+// Package policy protects content from automated changes. A Policy lists
+// chapters that must never be deleted, tonies that must never be renamed and
+// a cap on deletions per run. The apply engine evaluates it before changing
+// anything, and Hooks enforces it on every commit of a client:
+//
+//	p, err := policy.Load("policy.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	engine := &apply.Engine{Policy: p}
+//	client := toniebox.NewClient(toniebox.WithHooks(p.Hooks()))
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// Policy describes which automated changes are allowed. Patterns use the
+// syntax of path.Match and are matched case-sensitively.
+// The zero value allows everything.
+type Policy struct {
+	// ProtectedChapters are patterns of chapter titles that must not be deleted
+	ProtectedChapters []string `json:"protectedChapters,omitempty"`
+	// ProtectedTonies are patterns of tonie names or IDs that must not be renamed
+	ProtectedTonies []string `json:"protectedTonies,omitempty"`
+	// MaxDeletions caps the number of chapter deletions per run; zero means
+	// no limit
+	MaxDeletions int `json:"maxDeletions,omitempty"`
+}
+
+// Load reads a policy from a JSON file and validates its patterns
+func Load(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate reports malformed patterns
+func (p *Policy) Validate() error {
+	for _, pattern := range append(append([]string(nil), p.ProtectedChapters...), p.ProtectedTonies...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid policy pattern %q: %w", pattern, err)
+		}
+	}
+	if p.MaxDeletions < 0 {
+		return fmt.Errorf("invalid policy: maxDeletions must not be negative")
+	}
+	return nil
+}
+
+// ChangeKind identifies the type of a Change
+type ChangeKind string
+
+const (
+	// ChangeDelete removes a chapter
+	ChangeDelete ChangeKind = "delete"
+	// ChangeRename renames a tonie
+	ChangeRename ChangeKind = "rename"
+)
+
+// Change is a change subject to the policy
+type Change struct {
+	Kind      ChangeKind
+	TonieID   string
+	TonieName string
+	// ChapterTitle is the deleted chapter for ChangeDelete
+	ChapterTitle string
+	// NewName is the new tonie name for ChangeRename
+	NewName string
+}
+
+// Violation describes a change rejected by the policy
+type Violation struct {
+	Change Change
+	// Rule names the violated rule: "protectedChapters", "protectedTonies"
+	// or "maxDeletions"
+	Rule string
+	// Pattern is the matching pattern for protection rules
+	Pattern string
+}
+
+// String returns a one-line description of the violation
+func (v Violation) String() string {
+	switch v.Rule {
+	case "protectedChapters":
+		return fmt.Sprintf("chapter %q on %q is protected by %q", v.Change.ChapterTitle, v.Change.TonieName, v.Pattern)
+	case "protectedTonies":
+		return fmt.Sprintf("tonie %q is protected from renaming by %q", v.Change.TonieName, v.Pattern)
+	default:
+		return fmt.Sprintf("deleting chapter %q on %q exceeds the deletion limit", v.Change.ChapterTitle, v.Change.TonieName)
+	}
+}
+
+// ViolationError is returned when changes violate the policy.
+// None of the changes should be applied in that case.
+type ViolationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface
+func (e *ViolationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "policy violation: " + strings.Join(msgs, "; ")
+}
+
+// Check evaluates changes against the policy. priorDeletions is the number of
+// deletions already performed in the current run and counts towards
+// MaxDeletions. Check returns a *ViolationError listing every violation, or
+// nil if all changes are allowed.
+func (p *Policy) Check(changes []Change, priorDeletions int) error {
+	var violations []Violation
+	deletions := priorDeletions
+	for _, change := range changes {
+		switch change.Kind {
+		case ChangeDelete:
+			if pattern, ok := match(p.ProtectedChapters, change.ChapterTitle); ok {
+				violations = append(violations, Violation{Change: change, Rule: "protectedChapters", Pattern: pattern})
+				continue
+			}
+			deletions++
+			if p.MaxDeletions > 0 && deletions > p.MaxDeletions {
+				violations = append(violations, Violation{Change: change, Rule: "maxDeletions"})
+			}
+		case ChangeRename:
+			pattern, ok := match(p.ProtectedTonies, change.TonieName)
+			if !ok {
+				pattern, ok = match(p.ProtectedTonies, change.TonieID)
+			}
+			if ok {
+				violations = append(violations, Violation{Change: change, Rule: "protectedTonies", Pattern: pattern})
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+// Hooks returns client hooks that reject commits deleting protected chapters
+// or more chapters than MaxDeletions at once. Renames are not visible to
+// hooks; they are checked by the apply engine.
+func (p *Policy) Hooks() toniebox.Hooks {
+	return toniebox.Hooks{
+		BeforeCommit: func(tonie *toniebox.CreativeTonie, events []toniebox.HistoryEvent) error {
+			var changes []Change
+			for _, event := range events {
+				if event.Kind == toniebox.HistoryDelete {
+					changes = append(changes, Change{
+						Kind:         ChangeDelete,
+						TonieID:      event.TonieID,
+						TonieName:    event.TonieName,
+						ChapterTitle: event.ChapterTitle,
+					})
+				}
+			}
+			return p.Check(changes, 0)
+		},
+	}
+}
+
+// match returns the first pattern matching s
+func match(patterns []string, s string) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, s); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
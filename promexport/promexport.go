@@ -0,0 +1,376 @@
+// Package promexport exposes client metrics in the Prometheus text format,
+// without depending on the Prometheus client library.
+//
+// A Registry comes with collectors for the HTTP round trips of a client and
+// for the Go runtime pre-registered; binaries add their own counters and
+// gauges on top.
+//
+// Example:
+//
+//	reg := promexport.NewRegistry()
+//	client := toniebox.NewClient(toniebox.WithMetrics(reg.Client))
+//	runs := reg.Counter("toniebox_sync_runs_total", "Completed sync runs.")
+//	http.Handle("/metrics", reg)
+package promexport
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// ContentType is the media type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector writes a group of metrics on every scrape
+type Collector interface {
+	Collect(w *Writer)
+}
+
+// CollectorFunc adapts a function to the Collector interface
+type CollectorFunc func(w *Writer)
+
+// Collect implements Collector
+func (f CollectorFunc) Collect(w *Writer) {
+	f(w)
+}
+
+// Registry holds the collectors exposed by a metrics endpoint. It implements
+// http.Handler and serves the metrics of all collectors.
+type Registry struct {
+	// Client aggregates the round trips of clients created with
+	// toniebox.WithMetrics(reg.Client)
+	Client *ClientCollector
+
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry creates a Registry with the client and Go runtime collectors
+// registered
+func NewRegistry() *Registry {
+	r := &Registry{Client: NewClientCollector()}
+	r.Register(r.Client)
+	r.Register(CollectorFunc(collectRuntime))
+	return r
+}
+
+// Register adds a collector to the registry
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Counter registers and returns a counter without labels
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{}
+	r.Register(CollectorFunc(func(w *Writer) {
+		w.Header(name, help, "counter")
+		w.Sample(name, nil, c.Value())
+	}))
+	return c
+}
+
+// GaugeFunc registers a gauge whose value is read from fn on every scrape
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.Register(CollectorFunc(func(w *Writer) {
+		w.Header(name, help, "gauge")
+		w.Sample(name, nil, fn())
+	}))
+}
+
+// WriteTo writes the metrics of all collectors in the text format
+func (r *Registry) WriteTo(buf *bytes.Buffer) {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	w := &Writer{buf: buf}
+	for _, c := range collectors {
+		c.Collect(w)
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+// Counter is a monotonically increasing value that is safe for concurrent use
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter by delta; negative values are ignored
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+// Value returns the current value
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// Writer formats metrics in the Prometheus text format
+type Writer struct {
+	buf *bytes.Buffer
+}
+
+// Header writes the HELP and TYPE lines of a metric family
+func (w *Writer) Header(name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// Sample writes a single sample. Labels are written in sorted order.
+func (w *Writer) Sample(name string, labels map[string]string, value float64) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w.buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(w.buf, `%s="%s"`, k, labelEscaper.Replace(labels[k]))
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatValue(value))
+	w.buf.WriteByte('\n')
+}
+
+// labelEscaper escapes label values as required by the text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatValue formats a sample value, including the special float values
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// DefaultBuckets are the upper bounds in seconds of the request duration
+// histogram. Uploads of long chapters take minutes, hence the long tail.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// requestKey identifies a request series
+type requestKey struct {
+	operation string
+	host      string
+	code      string
+}
+
+// histogram is a cumulative histogram of durations in seconds
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// ClientCollector implements toniebox.Metrics and exports the round trips of
+// a client as Prometheus metrics:
+//
+//	toniebox_requests_total{operation,host,code}
+//	toniebox_request_duration_seconds{operation,host}
+//	toniebox_upload_bytes_total{host}
+//	toniebox_connections_reused_total{host}
+//	toniebox_tls_handshake_seconds_total{host}
+//
+// The code label is "error" for requests that received no response.
+type ClientCollector struct {
+	// Buckets are the histogram bounds; defaults to DefaultBuckets
+	Buckets []float64
+
+	mu         sync.Mutex
+	requests   map[requestKey]uint64
+	durations  map[requestKey]*histogram
+	bytesSent  map[string]int64
+	reused     map[string]uint64
+	handshakes map[string]time.Duration
+}
+
+// NewClientCollector creates an empty ClientCollector
+func NewClientCollector() *ClientCollector {
+	return &ClientCollector{
+		requests:   make(map[requestKey]uint64),
+		durations:  make(map[requestKey]*histogram),
+		bytesSent:  make(map[string]int64),
+		reused:     make(map[string]uint64),
+		handshakes: make(map[string]time.Duration),
+	}
+}
+
+// ObserveRequest implements toniebox.Metrics
+func (c *ClientCollector) ObserveRequest(stats toniebox.RequestStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	code := "error"
+	if stats.StatusCode != 0 {
+		code = strconv.Itoa(stats.StatusCode)
+	}
+	c.requests[requestKey{string(stats.Operation), stats.Host, code}]++
+
+	key := requestKey{operation: string(stats.Operation), host: stats.Host}
+	h, ok := c.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets()))}
+		c.durations[key] = h
+	}
+	seconds := stats.Duration.Seconds()
+	for i, bound := range c.buckets() {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+
+	if stats.BytesSent > 0 && stats.Err == nil {
+		c.bytesSent[stats.Host] += stats.BytesSent
+	}
+	if stats.ConnReused {
+		c.reused[stats.Host]++
+	}
+	c.handshakes[stats.Host] += stats.TLSHandshake
+}
+
+// buckets returns the configured or default histogram bounds
+func (c *ClientCollector) buckets() []float64 {
+	if len(c.Buckets) > 0 {
+		return c.Buckets
+	}
+	return DefaultBuckets
+}
+
+// Collect implements Collector
+func (c *ClientCollector) Collect(w *Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w.Header("toniebox_requests_total", "HTTP round trips to the Toniecloud and S3.", "counter")
+	keys := make([]requestKey, 0, len(c.requests))
+	for k := range c.requests {
+		keys = append(keys, k)
+	}
+	sortKeys(keys)
+	for _, k := range keys {
+		w.Sample("toniebox_requests_total", map[string]string{"operation": k.operation, "host": k.host, "code": k.code}, float64(c.requests[k]))
+	}
+
+	w.Header("toniebox_request_duration_seconds", "Time until the response headers were received.", "histogram")
+	keys = keys[:0]
+	for k := range c.durations {
+		keys = append(keys, k)
+	}
+	sortKeys(keys)
+	for _, k := range keys {
+		h := c.durations[k]
+		for i, bound := range c.buckets() {
+			w.Sample("toniebox_request_duration_seconds_bucket", map[string]string{"operation": k.operation, "host": k.host, "le": formatValue(bound)}, float64(h.counts[i]))
+		}
+		labels := map[string]string{"operation": k.operation, "host": k.host}
+		w.Sample("toniebox_request_duration_seconds_bucket", map[string]string{"operation": k.operation, "host": k.host, "le": "+Inf"}, float64(h.count))
+		w.Sample("toniebox_request_duration_seconds_sum", labels, h.sum)
+		w.Sample("toniebox_request_duration_seconds_count", labels, float64(h.count))
+	}
+
+	w.Header("toniebox_upload_bytes_total", "Bytes sent in successful requests.", "counter")
+	for _, host := range sortedHosts(c.bytesSent) {
+		w.Sample("toniebox_upload_bytes_total", map[string]string{"host": host}, float64(c.bytesSent[host]))
+	}
+	w.Header("toniebox_connections_reused_total", "Requests that reused a keep-alive connection.", "counter")
+	for _, host := range sortedHosts(c.reused) {
+		w.Sample("toniebox_connections_reused_total", map[string]string{"host": host}, float64(c.reused[host]))
+	}
+	w.Header("toniebox_tls_handshake_seconds_total", "Time spent in TLS handshakes.", "counter")
+	for _, host := range sortedHosts(c.handshakes) {
+		w.Sample("toniebox_tls_handshake_seconds_total", map[string]string{"host": host}, c.handshakes[host].Seconds())
+	}
+}
+
+// sortKeys orders request keys for stable output
+func sortKeys(keys []requestKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.operation != b.operation {
+			return a.operation < b.operation
+		}
+		if a.host != b.host {
+			return a.host < b.host
+		}
+		return a.code < b.code
+	})
+}
+
+// sortedHosts returns the keys of a per-host map in sorted order
+func sortedHosts[V any](m map[string]V) []string {
+	hosts := make([]string, 0, len(m))
+	for host := range m {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// startTime is reported as the process start time
+var startTime = time.Now()
+
+// collectRuntime writes Go runtime and process metrics
+func collectRuntime(w *Writer) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	w.Header("go_goroutines", "Number of goroutines that currently exist.", "gauge")
+	w.Sample("go_goroutines", nil, float64(runtime.NumGoroutine()))
+	w.Header("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", "gauge")
+	w.Sample("go_memstats_heap_alloc_bytes", nil, float64(mem.HeapAlloc))
+	w.Header("go_memstats_sys_bytes", "Number of bytes obtained from the system.", "gauge")
+	w.Sample("go_memstats_sys_bytes", nil, float64(mem.Sys))
+	w.Header("go_gc_cycles_total", "Number of completed GC cycles.", "counter")
+	w.Sample("go_gc_cycles_total", nil, float64(mem.NumGC))
+	w.Header("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", "gauge")
+	w.Sample("process_start_time_seconds", nil, float64(startTime.UnixNano())/1e9)
+}
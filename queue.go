@@ -0,0 +1,219 @@
+package toniebox
+
+import (
+	"container/heap"
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Priority determines the order in which queued requests are dispatched.
+// Higher values are dispatched first.
+type Priority int
+
+const (
+	// PriorityBackground is used for bulk work such as uploads
+	PriorityBackground Priority = 0
+	// PriorityNormal is used for writes such as commits
+	PriorityNormal Priority = 50
+	// PriorityInteractive is used for reads a user is waiting for
+	PriorityInteractive Priority = 100
+)
+
+// Operation classifies requests for the purpose of prioritisation
+type Operation string
+
+const (
+	// OperationLogin covers authentication requests
+	OperationLogin Operation = "login"
+	// OperationRead covers GET requests such as GetHouseholds or Refresh
+	OperationRead Operation = "read"
+	// OperationCommit covers PATCH requests such as Commit
+	OperationCommit Operation = "commit"
+	// OperationUpload covers upload slot requests and S3 uploads
+	OperationUpload Operation = "upload"
+)
+
+// defaultPriorities maps each operation to its default priority class
+var defaultPriorities = map[Operation]Priority{
+	OperationLogin:  PriorityInteractive,
+	OperationRead:   PriorityInteractive,
+	OperationCommit: PriorityNormal,
+	OperationUpload: PriorityBackground,
+}
+
+// WithRequestQueue limits the number of concurrent requests to maxConcurrent.
+// Waiting requests are dispatched by priority, so interactive reads (e.g. a
+// Refresh for a UI) overtake queued background uploads sharing the same client.
+// Requests that are already in flight are never interrupted. A request
+// waiting to be retried gives up its slot and queues again for the retry.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithRequestQueue(2))
+func WithRequestQueue(maxConcurrent int) Option {
+	return func(rh *requestHandler) {
+		if maxConcurrent > 0 {
+			rh.queue = newDispatchQueue(maxConcurrent)
+		}
+	}
+}
+
+// WithOperationPriority overrides the priority class of an operation.
+// It only has an effect in combination with WithRequestQueue.
+//
+// Example:
+//
+//	client := toniebox.NewClient(
+//	    toniebox.WithRequestQueue(2),
+//	    toniebox.WithOperationPriority(toniebox.OperationCommit, toniebox.PriorityInteractive),
+//	)
+func WithOperationPriority(op Operation, priority Priority) Option {
+	return func(rh *requestHandler) {
+		if rh.priorities == nil {
+			rh.priorities = make(map[Operation]Priority)
+		}
+		rh.priorities[op] = priority
+	}
+}
+
+// priorityKey is the context key for per-call priority overrides
+type priorityKey struct{}
+
+// ContextWithPriority returns a context that overrides the priority of the
+// requests issued with it
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// priority resolves the priority of a request for op issued with ctx
+func (rh *requestHandler) priority(ctx context.Context, op Operation) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	if p, ok := rh.priorities[op]; ok {
+		return p
+	}
+	return defaultPriorities[op]
+}
+
+// do sends req, retrying transient failures according to the retry policy.
+// Each attempt goes through the dispatch queue (if configured) on its own,
+// so a request does not hold a slot while it waits to be retried.
+func (rh *requestHandler) do(req *http.Request, op Operation) (*http.Response, error) {
+	return rh.sendWithRetry(req, op)
+}
+
+// sendQueued sends a single attempt of req once the dispatch queue (if
+// configured) admits it
+func (rh *requestHandler) sendQueued(req *http.Request, op Operation) (*http.Response, error) {
+	if rh.queue != nil {
+		if err := rh.queue.acquire(req.Context(), rh.priority(req.Context(), op)); err != nil {
+			return nil, err
+		}
+		defer rh.queue.release()
+	}
+	return rh.send(req, op)
+}
+
+// dispatchQueue is a counting semaphore that admits waiters by priority
+type dispatchQueue struct {
+	mu      sync.Mutex
+	active  int
+	limit   int
+	seq     uint64
+	waiters waiterHeap
+}
+
+// waiter is a request waiting for a free slot
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// newDispatchQueue creates a queue admitting up to limit concurrent requests
+func newDispatchQueue(limit int) *dispatchQueue {
+	return &dispatchQueue{limit: limit}
+}
+
+// acquire blocks until a slot is available or ctx is done
+func (q *dispatchQueue) acquire(ctx context.Context, priority Priority) error {
+	q.mu.Lock()
+	if q.active < q.limit && len(q.waiters) == 0 {
+		q.active++
+		q.mu.Unlock()
+		return nil
+	}
+	w := &waiter{priority: priority, seq: q.seq, ready: make(chan struct{})}
+	q.seq++
+	heap.Push(&q.waiters, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-w.ready:
+			// The slot was granted concurrently; hand it on
+			q.releaseLocked()
+		default:
+			heap.Remove(&q.waiters, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot and admits the highest-priority waiter
+func (q *dispatchQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked()
+}
+
+// releaseLocked frees a slot; q.mu must be held
+func (q *dispatchQueue) releaseLocked() {
+	if len(q.waiters) > 0 {
+		w := heap.Pop(&q.waiters).(*waiter)
+		close(w.ready)
+		return
+	}
+	q.active--
+}
+
+// waiterHeap orders waiters by descending priority, then arrival
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return w
+}
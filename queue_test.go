@@ -0,0 +1,150 @@
+package toniebox
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n requests are queued on q
+func waitForWaiters(t *testing.T, q *dispatchQueue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		q.mu.Lock()
+		queued := len(q.waiters)
+		q.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests queued, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestDispatchQueuePriority checks that a free slot goes to the waiter with
+// the highest priority rather than the one that arrived first
+func TestDispatchQueuePriority(t *testing.T) {
+	q := newDispatchQueue(1)
+	if err := q.acquire(context.Background(), PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	enqueue := func(p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.acquire(context.Background(), p); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			q.release()
+		}()
+	}
+	enqueue(PriorityBackground)
+	waitForWaiters(t, q, 1)
+	enqueue(PriorityNormal)
+	waitForWaiters(t, q, 2)
+	enqueue(PriorityInteractive)
+	waitForWaiters(t, q, 3)
+
+	q.release()
+	wg.Wait()
+
+	want := []Priority{PriorityInteractive, PriorityNormal, PriorityBackground}
+	if len(order) != len(want) {
+		t.Fatalf("admitted %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("admitted %v, want %v", order, want)
+		}
+	}
+}
+
+// TestDispatchQueueCancel checks that a cancelled waiter leaves the queue
+// without taking a slot
+func TestDispatchQueueCancel(t *testing.T) {
+	q := newDispatchQueue(1)
+	if err := q.acquire(context.Background(), PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.acquire(ctx, PriorityInteractive) }()
+	waitForWaiters(t, q, 1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("acquire returned %v, want context.Canceled", err)
+	}
+
+	q.release()
+	if err := q.acquire(context.Background(), PriorityBackground); err != nil {
+		t.Fatal(err)
+	}
+	if q.active != 1 || len(q.waiters) != 0 {
+		t.Errorf("queue has %d active and %d waiting, want 1 and 0", q.active, len(q.waiters))
+	}
+}
+
+// TestQueueReleasedDuringRetryDelay checks that a request waiting to be
+// retried does not block other requests sharing its slot
+func TestQueueReleasedDuringRetryDelay(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	failed := make(chan struct{})
+	transport := &stubTransport{handler: func(req *http.Request, call int) (int, string) {
+		mu.Lock()
+		sent = append(sent, req.URL.Path)
+		mu.Unlock()
+		if req.URL.Path == "/v2/me" {
+			if call == 1 {
+				close(failed)
+				return http.StatusServiceUnavailable, `{}`
+			}
+			return http.StatusOK, `{}`
+		}
+		return http.StatusOK, `[]`
+	}}
+	client := newStubClient(transport, &JWTToken{AccessToken: "token"},
+		WithRequestQueue(1), WithRetry(1, time.Second))
+
+	done := make(chan error)
+	go func() {
+		_, err := client.GetMe()
+		done <- err
+	}()
+	<-failed
+
+	start := time.Now()
+	if _, err := client.GetHouseholds(); err != nil {
+		t.Fatalf("GetHouseholds failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetHouseholds waited %v for the retrying request", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("GetMe failed: %v", err)
+	}
+
+	want := []string{"/v2/me", "/v2/households", "/v2/me"}
+	if len(sent) != len(want) {
+		t.Fatalf("sent %v, want %v", sent, want)
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Fatalf("sent %v, want %v", sent, want)
+		}
+	}
+}
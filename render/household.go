@@ -0,0 +1,138 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// HouseholdReport is the data of a household overview report
+type HouseholdReport struct {
+	Household   toniebox.Household
+	Tonies      []toniebox.CreativeTonie
+	Changes     []Change
+	GeneratedAt time.Time
+}
+
+// Change is a notable event listed in the "recent changes" section of a report
+type Change struct {
+	Time        time.Time
+	Tonie       string
+	Description string
+}
+
+// BuildHouseholdReport fetches the Creative-Tonies of household and assembles
+// a report. Recent changes can be added to the returned report by the caller.
+func BuildHouseholdReport(client *toniebox.Client, household *toniebox.Household) (*HouseholdReport, error) {
+	tonies, err := client.GetCreativeTonies(household)
+	if err != nil {
+		return nil, err
+	}
+	return &HouseholdReport{
+		Household:   *household,
+		Tonies:      tonies,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// Usage returns the fraction of the tonie's capacity in use, between 0 and 1
+func Usage(tonie *toniebox.CreativeTonie) float64 {
+	total := tonie.SecondsPresent + tonie.SecondsRemaining
+	if total <= 0 {
+		return 0
+	}
+	return tonie.SecondsPresent / total
+}
+
+// CapacityBar renders usage as a text bar of the given width, e.g. "██████░░░░ 60%"
+func CapacityBar(usage float64, width int) string {
+	filled := int(usage*float64(width) + 0.5)
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + fmt.Sprintf(" %3.0f%%", usage*100)
+}
+
+// HouseholdMarkdown writes the report as Markdown, e.g. for a weekly email
+func HouseholdMarkdown(w io.Writer, report *HouseholdReport) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", report.Household.Name)
+	fmt.Fprintf(&sb, "_Generated %s_\n\n", report.GeneratedAt.Format("2006-01-02 15:04"))
+
+	fmt.Fprintf(&sb, "## Creative-Tonies (%d)\n\n", len(report.Tonies))
+	sb.WriteString("| Tonie | Chapters | Used | Free | Capacity |\n")
+	sb.WriteString("|-------|---------:|-----:|-----:|----------|\n")
+	for i := range report.Tonies {
+		tonie := &report.Tonies[i]
+		fmt.Fprintf(&sb, "| %s | %d | %s | %s | `%s` |\n",
+			strings.ReplaceAll(tonie.Name, "|", `\|`),
+			tonie.ChaptersPresent,
+			FormatDuration(seconds(tonie.SecondsPresent)),
+			FormatDuration(seconds(tonie.SecondsRemaining)),
+			CapacityBar(Usage(tonie), 10))
+	}
+
+	if len(report.Changes) > 0 {
+		sb.WriteString("\n## Recent changes\n\n")
+		for _, change := range report.Changes {
+			fmt.Fprintf(&sb, "- %s **%s**: %s\n", change.Time.Format("2006-01-02 15:04"), change.Tonie, change.Description)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// householdHTML is the template of the HTML household report
+var householdHTML = template.Must(template.New("household").Funcs(template.FuncMap{
+	"duration": func(s float64) string { return FormatDuration(seconds(s)) },
+	"percent":  func(tonie toniebox.CreativeTonie) string { return fmt.Sprintf("%.0f", Usage(&tonie)*100) },
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Household.Name}}</title>
+</head>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #222;">
+<h1>{{.Household.Name}}</h1>
+<p style="color: #666;">Generated {{datetime .GeneratedAt}}</p>
+<h2>Creative-Tonies ({{len .Tonies}})</h2>
+<table cellpadding="4" style="border-collapse: collapse;">
+<tr><th align="left">Tonie</th><th align="right">Chapters</th><th align="right">Used</th><th align="right">Free</th><th align="left">Capacity</th></tr>
+{{- range .Tonies}}
+<tr>
+<td>{{.Name}}</td>
+<td align="right">{{.ChaptersPresent}}</td>
+<td align="right">{{duration .SecondsPresent}}</td>
+<td align="right">{{duration .SecondsRemaining}}</td>
+<td><div style="width: 120px; background: #eee;"><div style="width: {{percent .}}%; background: #d2000f; height: 10px;"></div></div></td>
+</tr>
+{{- end}}
+</table>
+{{- if .Changes}}
+<h2>Recent changes</h2>
+<ul>
+{{- range .Changes}}
+<li>{{datetime .Time}} <strong>{{.Tonie}}</strong>: {{.Description}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// HouseholdHTML writes the report as an HTML page with inline styles, so it
+// renders well in email clients
+func HouseholdHTML(w io.Writer, report *HouseholdReport) error {
+	return householdHTML.Execute(w, report)
+}
+
+// seconds converts fractional seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
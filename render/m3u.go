@@ -0,0 +1,57 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// SourceIndex resolves chapters to the local files they were created from.
+// It is implemented by *library.Index.
+type SourceIndex interface {
+	Lookup(chapter toniebox.Chapter) (string, bool)
+}
+
+// M3U writes an extended M3U playlist of the chapters of tonie that index can
+// resolve to local files, so the same content can be played locally.
+// If baseDir is not empty, paths are written relative to it.
+// Chapters without a known local file are skipped and returned as missing.
+//
+// Example:
+//
+//	index, _ := library.Build("/srv/media/kids")
+//	missing, err := render.M3U(f, tonie, index, "")
+func M3U(w io.Writer, tonie *toniebox.CreativeTonie, index SourceIndex, baseDir string) ([]toniebox.Chapter, error) {
+	var sb strings.Builder
+	var missing []toniebox.Chapter
+
+	sb.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&sb, "#PLAYLIST:%s\n", oneLine(tonie.Name))
+	for _, chapter := range tonie.Chapters {
+		path, ok := index.Lookup(chapter)
+		if !ok {
+			missing = append(missing, chapter)
+			continue
+		}
+		if baseDir != "" {
+			if rel, err := filepath.Rel(baseDir, path); err == nil {
+				path = rel
+			}
+		}
+		fmt.Fprintf(&sb, "#EXTINF:%d,%s\n%s\n", int(math.Round(chapter.Seconds)), oneLine(chapter.Title), filepath.ToSlash(path))
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return missing, err
+	}
+	return missing, nil
+}
+
+// oneLine replaces line breaks, which would corrupt line-based formats
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A6 page size in PDF points
+const (
+	a6Width  = 298
+	a6Height = 420
+)
+
+// Layout of the PDF tracklist
+const (
+	pdfMargin      = 24
+	pdfFontSize    = 9
+	pdfHeadingSize = 13
+	pdfLeading     = 13
+	pdfNumberWidth = 20
+	pdfTimeWidth   = 44
+)
+
+// pdfDoc is a minimal text-only PDF writer using the built-in Helvetica font
+type pdfDoc struct {
+	width, height float64
+	pages         []*bytes.Buffer
+	y             float64
+}
+
+// newPDF creates an empty document with the given page size
+func newPDF(width, height float64) *pdfDoc {
+	doc := &pdfDoc{width: width, height: height}
+	doc.newPage()
+	return doc
+}
+
+// newPage starts a new page
+func (d *pdfDoc) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = d.height - pdfMargin
+}
+
+// text places a single line of text at x on the current line
+func (d *pdfDoc) text(x float64, font string, size float64, s string) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, pdfEscape(s))
+}
+
+// heading writes the title line
+func (d *pdfDoc) heading(s string) {
+	d.y -= pdfHeadingSize
+	d.text(pdfMargin, "F2", pdfHeadingSize, truncate(s, d.width-2*pdfMargin, pdfHeadingSize))
+	d.y -= pdfLeading / 2
+}
+
+// row writes a tracklist row with a number, a title and a right-aligned time
+func (d *pdfDoc) row(number, title, time string) {
+	d.y -= pdfLeading
+	if d.y < pdfMargin {
+		d.newPage()
+		d.y -= pdfLeading
+	}
+	titleWidth := d.width - 2*pdfMargin - pdfNumberWidth - pdfTimeWidth
+	d.text(pdfMargin, "F1", pdfFontSize, number)
+	d.text(pdfMargin+pdfNumberWidth, "F1", pdfFontSize, truncate(title, titleWidth, pdfFontSize))
+	d.text(d.width-pdfMargin-textWidth(time, pdfFontSize), "F1", pdfFontSize, time)
+}
+
+// write serialises the document
+func (d *pdfDoc) write(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4: catalog, page tree, fonts; then a page and content per page
+	firstPage := 5
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			d.width, d.height, firstPage+2*i+1))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfEscape encodes s as a WinAnsi PDF string literal body
+func pdfEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r < 0x20:
+			sb.WriteByte(' ')
+		case r < 0x80:
+			sb.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			// Latin-1 characters (ä, ö, ü, ß, ...) share their WinAnsi code
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
+
+// textWidth approximates the width of s in Helvetica at size
+func textWidth(s string, size float64) float64 {
+	// Average Helvetica glyph width is roughly half the font size
+	return float64(len([]rune(s))) * size * 0.5
+}
+
+// truncate shortens s with an ellipsis so that it fits into width
+func truncate(s string, width, size float64) string {
+	runes := []rune(s)
+	max := int(width / (size * 0.5))
+	if len(runes) <= max || max < 4 {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,142 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"path"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// FeedOptions configures the RSS feed generated for a Creative-Tonie
+type FeedOptions struct {
+	// Link is the website URL of the feed (required by RSS)
+	Link string
+	// Description of the feed; defaults to a generic description
+	Description string
+	// ImageURL is the cover image; defaults to the tonie's ImageURL
+	ImageURL string
+	// PublishedAt is the publication date of the first chapter; following
+	// chapters are one minute apart so podcast apps preserve the order.
+	// Defaults to the current time.
+	PublishedAt time.Time
+	// AudioURL optionally resolves a chapter to a playable URL (e.g. a file
+	// served from the local media server). Chapters without URL are still
+	// listed, but without an enclosure.
+	AudioURL func(chapter toniebox.Chapter) (string, bool)
+}
+
+// rss is the root element of an RSS 2.0 document with iTunes extensions
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	ITunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Image       *rssImage `xml:"itunes:image,omitempty"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title     string        `xml:"title"`
+	GUID      rssGUID       `xml:"guid"`
+	PubDate   string        `xml:"pubDate"`
+	Duration  int           `xml:"itunes:duration"`
+	Episode   int           `xml:"itunes:episode"`
+	Enclosure *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+	// Length is required by RSS; 0 signals an unknown size
+	Length int64 `xml:"length,attr"`
+}
+
+// RSS writes an RSS 2.0 podcast feed mirroring the chapters of tonie, so its
+// contents can be followed in podcast apps.
+//
+// Example:
+//
+//	err := render.RSS(w, tonie, render.FeedOptions{
+//	    Link: "http://media.local/tonies",
+//	    AudioURL: func(ch toniebox.Chapter) (string, bool) {
+//	        path, ok := index.Lookup(ch)
+//	        return "http://media.local/files/" + path, ok
+//	    },
+//	})
+func RSS(w io.Writer, tonie *toniebox.CreativeTonie, opts FeedOptions) error {
+	published := opts.PublishedAt
+	if published.IsZero() {
+		published = time.Now()
+	}
+	description := opts.Description
+	if description == "" {
+		description = fmt.Sprintf("Chapters of the Creative-Tonie %q", tonie.Name)
+	}
+
+	doc := rss{
+		Version: "2.0",
+		ITunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: rssChannel{
+			Title:       tonie.Name,
+			Link:        opts.Link,
+			Description: description,
+		},
+	}
+	image := opts.ImageURL
+	if image == "" {
+		image = tonie.ImageURL
+	}
+	if image != "" {
+		doc.Channel.Image = &rssImage{Href: image}
+	}
+
+	for i, chapter := range tonie.Chapters {
+		item := rssItem{
+			Title:    chapter.Title,
+			GUID:     rssGUID{Value: tonie.ID + "/" + chapter.ID},
+			PubDate:  published.Add(time.Duration(i) * time.Minute).Format(time.RFC1123Z),
+			Duration: int(math.Round(chapter.Seconds)),
+			Episode:  i + 1,
+		}
+		if opts.AudioURL != nil {
+			if url, ok := opts.AudioURL(chapter); ok {
+				contentType := mime.TypeByExtension(path.Ext(url))
+				if contentType == "" {
+					contentType = "audio/mpeg"
+				}
+				item.Enclosure = &rssEnclosure{URL: url, Type: contentType}
+			}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return enc.Flush()
+}
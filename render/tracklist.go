@@ -0,0 +1,104 @@
+// Package render turns Creative-Tonie contents into printable documents,
+// such as a tracklist card to keep with the figurine.
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// TracklistMarkdown writes the tracklist of tonie as a Markdown table
+func TracklistMarkdown(w io.Writer, tonie *toniebox.CreativeTonie) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", tonie.Name)
+	sb.WriteString("| # | Title | Start | Length |\n")
+	sb.WriteString("|--:|-------|------:|-------:|\n")
+	for _, track := range tonie.Tracklist() {
+		title := strings.ReplaceAll(track.Title, "|", `\|`)
+		fmt.Fprintf(&sb, "| %d | %s | %s | %s |\n", track.Number, title, FormatDuration(track.Start), FormatDuration(track.Duration))
+	}
+	fmt.Fprintf(&sb, "\nTotal: %s\n", FormatDuration(totalDuration(tonie)))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// tracklistHTML is the template of the printable HTML card
+var tracklistHTML = template.Must(template.New("tracklist").Funcs(template.FuncMap{
+	"duration": FormatDuration,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+  @page { size: A6; margin: 8mm; }
+  body { font-family: Helvetica, Arial, sans-serif; font-size: 10pt; }
+  h1 { font-size: 14pt; margin: 0 0 4mm; }
+  table { border-collapse: collapse; width: 100%; }
+  td { padding: 1mm 0; border-bottom: 0.2mm solid #ccc; vertical-align: top; }
+  td.num, td.time { text-align: right; white-space: nowrap; color: #555; }
+  td.num { padding-right: 2mm; }
+  td.time { padding-left: 2mm; }
+  p.total { text-align: right; color: #555; margin-top: 2mm; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<table>
+{{- range .Tracks}}
+<tr><td class="num">{{.Number}}</td><td>{{.Title}}</td><td class="time">{{duration .Start}}</td></tr>
+{{- end}}
+</table>
+<p class="total">Total {{duration .Total}}</p>
+</body>
+</html>
+`))
+
+// TracklistHTML writes the tracklist of tonie as a self-contained HTML page
+// sized for printing as an A6 card
+func TracklistHTML(w io.Writer, tonie *toniebox.CreativeTonie) error {
+	return tracklistHTML.Execute(w, struct {
+		Name   string
+		Tracks []toniebox.Track
+		Total  time.Duration
+	}{
+		Name:   tonie.Name,
+		Tracks: tonie.Tracklist(),
+		Total:  totalDuration(tonie),
+	})
+}
+
+// TracklistPDF writes the tracklist of tonie as an A6 PDF document
+func TracklistPDF(w io.Writer, tonie *toniebox.CreativeTonie) error {
+	doc := newPDF(a6Width, a6Height)
+	doc.heading(tonie.Name)
+	for _, track := range tonie.Tracklist() {
+		doc.row(fmt.Sprintf("%d.", track.Number), track.Title, FormatDuration(track.Start))
+	}
+	doc.row("", "", "Total "+FormatDuration(totalDuration(tonie)))
+	return doc.write(w)
+}
+
+// FormatDuration formats d as m:ss, or h:mm:ss for durations of an hour or more
+func FormatDuration(d time.Duration) string {
+	total := int(d.Round(time.Second).Seconds())
+	hours, minutes, seconds := total/3600, total/60%60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
+
+// totalDuration returns the summed duration of all chapters of tonie
+func totalDuration(tonie *toniebox.CreativeTonie) time.Duration {
+	var total time.Duration
+	for _, track := range tonie.Tracklist() {
+		total += track.Duration
+	}
+	return total
+}
@@ -0,0 +1,199 @@
+package toniebox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// apiRequest describes a single HTTP call. Every request of the client, from
+// the login to the S3 upload, is built and sent by buildRequest and execute,
+// so authentication, the dispatch queue, retries, metrics and error parsing
+// apply to all of them alike.
+type apiRequest struct {
+	ctx    context.Context
+	method string
+	url    string
+	// op classifies the request for priorities, retries and metrics
+	op Operation
+	// name describes the call in errors, e.g. "login" or "S3 upload";
+	// defaults to "request"
+	name string
+
+	// body is sent as is; upload is used instead for pooled upload bodies
+	body        []byte
+	upload      *pooledBody
+	contentType string
+	header      http.Header
+
+	// anonymous requests do not carry the access token. The login has none
+	// yet and the S3 upload must not leak it to another host.
+	anonymous bool
+	// accept lists the expected status codes; defaults to 200 OK
+	accept []int
+	// retrySafe allows retrying a POST or PATCH after network errors and
+	// server errors, because repeating it has no further effect
+	retrySafe bool
+	// sessionChecked skips the check of the token's expiry, which the
+	// caller has done already
+	sessionChecked bool
+	// closing requests are sent by Client.Close after the client is closed;
+	// they are not rejected or tracked by its lifecycle
+	closing bool
+}
+
+// describe returns the name of the request used in errors
+func (r *apiRequest) describe() string {
+	if r.name == "" {
+		return "request"
+	}
+	return r.name
+}
+
+// accepts reports whether status is one of the expected status codes
+func (r *apiRequest) accepts(status int) bool {
+	if len(r.accept) == 0 {
+		return status == http.StatusOK
+	}
+	for _, code := range r.accept {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRequest creates the HTTP request for r. Bodies can be rewound, so the
+// request can be retried.
+func (rh *requestHandler) buildRequest(r *apiRequest) (*http.Request, error) {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var body io.Reader
+	switch {
+	case r.upload != nil:
+		body = r.upload.reader()
+	case r.body != nil:
+		body = bytes.NewReader(r.body)
+	}
+
+	var req *http.Request
+	var err error
+	if r.anonymous {
+		req, err = http.NewRequestWithContext(ctx, r.method, r.url, body)
+	} else {
+		req, err = rh.newAuthenticatedRequest(ctx, r.method, r.url, body)
+	}
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+
+	if r.upload != nil {
+		r.upload.attach(req)
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// execute sends r and returns the response if its status is expected.
+// Any other status is returned as an *APIError. The caller must close the
+// body of the response.
+func (rh *requestHandler) execute(r *apiRequest) (*http.Response, error) {
+	parent := r.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, end := parent, func() {}
+	if !r.closing {
+		var err error
+		if ctx, end, err = rh.life.begin(parent); err != nil {
+			return nil, fmt.Errorf("%s failed: %w", r.describe(), err)
+		}
+	}
+	if err := rh.checkPinsEnforced(); err != nil {
+		end()
+		return nil, fmt.Errorf("%s failed: %w", r.describe(), err)
+	}
+	scoped := *r
+	scoped.ctx = ctx
+	if r.retrySafe {
+		scoped.ctx = context.WithValue(ctx, retrySafeKey{}, true)
+	}
+
+	if !r.anonymous && !r.sessionChecked {
+		if err := rh.checkSession(ctx); err != nil {
+			end()
+			return nil, fmt.Errorf("%s failed: %w", r.describe(), err)
+		}
+	}
+
+	for refreshed := false; ; refreshed = true {
+		session := rh.auth.Load()
+		req, err := rh.buildRequest(&scoped)
+		if err != nil {
+			end()
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := rh.do(req, r.op)
+		if err != nil {
+			end()
+			return nil, fmt.Errorf("%s failed: %w", r.describe(), err)
+		}
+		if r.accepts(resp.StatusCode) {
+			// The request counts as in flight until its body is closed
+			resp.Body = &releasingBody{ReadCloser: resp.Body, end: end}
+			return resp, nil
+		}
+
+		var apiErr error
+		switch {
+		case resp.StatusCode != http.StatusUnauthorized || r.anonymous:
+			apiErr = newAPIError(r.describe(), resp)
+		case refreshed || !rh.autoRefresh:
+			apiErr = sessionError(r.describe(), resp)
+		default:
+			// The token was rejected: renew it once and try again
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if err := rh.refreshSession(ctx, session); err != nil {
+				end()
+				return nil, fmt.Errorf("%s failed: %w", r.describe(), err)
+			}
+			continue
+		}
+		resp.Body.Close()
+		end()
+		return nil, apiErr
+	}
+}
+
+// executeJSON sends r and, if result is not nil, decodes the JSON response
+// into it
+func (rh *requestHandler) executeJSON(r *apiRequest, result interface{}) error {
+	resp, err := rh.execute(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
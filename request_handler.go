@@ -2,34 +2,92 @@ package toniebox
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
-	"os"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/mikeboe/toniebox-api-go/audio"
 )
 
 // requestHandler handles all HTTP requests to the Toniebox API
 type requestHandler struct {
-	client   *http.Client
-	jwtToken *JWTToken
+	client *http.Client
+	auth   atomic.Pointer[authState]
+
+	clientID    string
+	scopes      []string
+	autoRefresh bool
+	refreshMu   sync.Mutex
+
+	cache Cache
+
+	queue      *dispatchQueue
+	priorities map[Operation]Priority
+
+	retry       retryPolicy
+	retryBudget *RetryBudget
+
+	history HistoryRecorder
+
+	imagesMu sync.Mutex
+	images   map[string]cachedImage
+
+	verifiedMu sync.Mutex
+	verified   bool
+
+	metrics Metrics
+	warn    *warnings
+
+	maxChapters int
+	limits      atomic.Pointer[Limits]
+
+	maxUploadSize       int64
+	allowedExtensions   []string
+	allowedContentTypes []string
+
+	uploads UploadRecorder
+
+	hooks []Hooks
+
+	// pinned is set by WithCertificatePins; pinnedTransport is the transport
+	// that verifies the pins
+	pinned          bool
+	pinnedTransport *http.Transport
+
+	life lifecycle
+}
+
+// cachedImage is an image downloaded earlier together with its ETag
+type cachedImage struct {
+	etag string
+	data []byte
 }
 
 // newRequestHandler creates a new request handler with default settings
-func newRequestHandler() *requestHandler {
-	return &requestHandler{
+func newRequestHandler(opts ...Option) *requestHandler {
+	rh := &requestHandler{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
+	rh.life.init()
+	for _, opt := range opts {
+		opt(rh)
+	}
+	return rh
 }
 
 // newRequestHandlerWithProxy creates a new request handler with proxy settings
-func newRequestHandlerWithProxy(proxyURL string) (*requestHandler, error) {
+func newRequestHandlerWithProxy(proxyURL string, opts ...Option) (*requestHandler, error) {
 	proxy, err := url.Parse(proxyURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid proxy URL: %w", err)
@@ -39,100 +97,178 @@ func newRequestHandlerWithProxy(proxyURL string) (*requestHandler, error) {
 		Proxy: http.ProxyURL(proxy),
 	}
 
-	return &requestHandler{
+	rh := &requestHandler{
 		client: &http.Client{
 			Timeout:   30 * time.Second,
 			Transport: transport,
 		},
-	}, nil
+	}
+	rh.life.init()
+	for _, opt := range opts {
+		opt(rh)
+	}
+	return rh, nil
 }
 
 // login authenticates the user and stores the JWT token
-func (rh *requestHandler) login(loginData *Login) (*JWTToken, error) {
+func (rh *requestHandler) login(ctx context.Context, loginData *Login) (*JWTToken, error) {
 	data := url.Values{}
 	data.Set("grant_type", grantTypePassword)
-	data.Set("client_id", clientID)
-	data.Set("scope", scopeOpenID)
+	data.Set("client_id", rh.oauthClientID())
+	data.Set("scope", rh.oauthScope())
 	data.Set("username", loginData.Email)
 	data.Set("password", loginData.Password)
 
-	req, err := http.NewRequest("POST", openIDConnect, strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create login request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", contentTypeForm)
-
-	resp, err := rh.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("login request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("login failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
 	var token JWTToken
-	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
-		return nil, fmt.Errorf("failed to decode token: %w", err)
+	err := rh.executeJSON(&apiRequest{
+		ctx:         ctx,
+		method:      "POST",
+		url:         openIDConnect,
+		op:          OperationLogin,
+		name:        "login",
+		body:        []byte(data.Encode()),
+		contentType: contentTypeForm,
+		anonymous:   true,
+		retrySafe:   true,
+	}, &token)
+	if err != nil {
+		return nil, err
 	}
 
-	rh.jwtToken = &token
+	rh.setToken(&token)
 	return &token, nil
 }
 
 // getMe retrieves personal information about the authenticated user
-func (rh *requestHandler) getMe() (*Me, error) {
+func (rh *requestHandler) getMe(ctx context.Context) (*Me, error) {
 	var result Me
-	if err := rh.executeGetRequest(me, &result); err != nil {
+	if err := rh.executeGetRequest(ctx, me, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
 
 // getHouseholds retrieves all households the user belongs to
-func (rh *requestHandler) getHouseholds() ([]Household, error) {
+func (rh *requestHandler) getHouseholds(ctx context.Context) ([]Household, error) {
 	var result []Household
-	if err := rh.executeGetRequest(households, &result); err != nil {
-		return nil, err
+	if err := rh.executeGetRequest(ctx, households, &result); err != nil {
+		if rh.cache == nil || !isUnreachable(err) {
+			return nil, err
+		}
+		cached, fetchedAt, cacheErr := rh.cache.Households()
+		if cacheErr != nil {
+			return nil, err
+		}
+		rh.bindHouseholds(cached)
+		return cached, &StaleError{FetchedAt: fetchedAt, Err: err}
+	}
+
+	rh.bindHouseholds(result)
+	if rh.cache != nil {
+		rh.cache.PutHouseholds(result, time.Now())
 	}
 	return result, nil
 }
 
 // getCreativeTonies retrieves all Creative-Tonies in a household
-func (rh *requestHandler) getCreativeTonies(household *Household) ([]CreativeTonie, error) {
+func (rh *requestHandler) getCreativeTonies(ctx context.Context, household *Household) ([]CreativeTonie, error) {
 	url := fmt.Sprintf(creativeTonies, household.ID)
-	var result []CreativeTonie
-	if err := rh.executeGetRequest(url, &result); err != nil {
+	result, err := rh.getCreativeToniesFrom(ctx, url)
+	if err != nil {
+		if rh.cache == nil || !isUnreachable(err) {
+			return nil, err
+		}
+		cached, fetchedAt, cacheErr := rh.cache.CreativeTonies(household.ID)
+		if cacheErr != nil {
+			return nil, err
+		}
+		rh.bindTonies(cached, household)
+		for i := range cached {
+			cached[i].stale = true
+		}
+		return cached, &StaleError{FetchedAt: fetchedAt, Err: err}
+	}
+
+	rh.bindTonies(result, household)
+	for i := range result {
+		rh.warnCapacity(&result[i])
+	}
+	if rh.cache != nil {
+		rh.cache.PutCreativeTonies(household.ID, result, time.Now())
+	}
+	return result, nil
+}
+
+// getCreativeToniesFrom fetches and decodes a list of Creative-Tonies
+func (rh *requestHandler) getCreativeToniesFrom(ctx context.Context, url string) ([]CreativeTonie, error) {
+	resp, err := rh.get(ctx, url)
+	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	// Set household reference and request handler for each tonie
-	for i := range result {
-		result[i].household = household
-		result[i].requestHandler = rh
+	tonies, err := decodeCreativeTonies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return tonies, nil
+}
 
-	return result, nil
+// bindHouseholds sets the request handler for each household
+func (rh *requestHandler) bindHouseholds(households []Household) {
+	for i := range households {
+		households[i].requestHandler = rh
+	}
+}
+
+// bindTonies sets the household reference and request handler for each tonie
+func (rh *requestHandler) bindTonies(tonies []CreativeTonie, household *Household) {
+	for i := range tonies {
+		tonies[i].household = household
+		tonies[i].requestHandler = rh
+		tonies[i].committedChapters = tonies[i].Chapters
+	}
 }
 
 // refreshTonie retrieves the latest state of a Creative-Tonie
-func (rh *requestHandler) refreshTonie(tonie *CreativeTonie) (*CreativeTonie, error) {
+func (rh *requestHandler) refreshTonie(ctx context.Context, tonie *CreativeTonie) (*CreativeTonie, error) {
 	url := fmt.Sprintf(creativeTonie, tonie.household.ID, tonie.ID)
-	var result CreativeTonie
-	if err := rh.executeGetRequest(url, &result); err != nil {
+	resp, err := rh.get(ctx, url)
+	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+
+	var result CreativeTonie
+	if err := decodeCreativeTonie(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
 	result.household = tonie.household
 	result.requestHandler = rh
+	result.committedChapters = result.Chapters
+	rh.warnCapacity(&result)
 	return &result, nil
 }
 
 // commitTonie saves changes to a Creative-Tonie
-func (rh *requestHandler) commitTonie(tonie *CreativeTonie) error {
+func (rh *requestHandler) commitTonie(ctx context.Context, tonie *CreativeTonie) error {
+	if err := tonie.checkFresh(); err != nil {
+		return err
+	}
+	if err := tonie.Validate(); err != nil {
+		return err
+	}
+	tonie.normalize()
+
+	var changes []HistoryEvent
+	if len(rh.hooks) > 0 {
+		changes = chapterChanges(tonie, tonie.committedChapters, tonie.Chapters, time.Now())
+		if err := rh.beforeCommit(tonie, changes); err != nil {
+			return err
+		}
+	}
+
 	url := fmt.Sprintf(creativeTonie, tonie.household.ID, tonie.ID)
 
 	body, err := json.Marshal(tonie)
@@ -140,168 +276,305 @@ func (rh *requestHandler) commitTonie(tonie *CreativeTonie) error {
 		return fmt.Errorf("failed to marshal tonie: %w", err)
 	}
 
-	return rh.executePatchRequest(url, body)
+	if err := rh.executeReplaceRequest(ctx, url, body); err != nil {
+		return err
+	}
+
+	rh.recordHistory(tonie)
+	tonie.committedChapters = append([]Chapter(nil), tonie.Chapters...)
+	rh.afterCommit(tonie, changes)
+	return nil
 }
 
-// uploadFile uploads a file to a Creative-Tonie
-func (rh *requestHandler) uploadFile(tonie *CreativeTonie, filePath, title string) error {
-	// Step 1: Request upload credentials from Toniebox API
-	emptyBody := []byte(`{"headers":{}}`)
+// downloadImage fetches the image at imageURL and writes it to w. Images are
+// cached in memory by ETag and revalidated with a conditional request.
+func (rh *requestHandler) downloadImage(ctx context.Context, imageURL string, w io.Writer) error {
+	if imageURL == "" {
+		return fmt.Errorf("no image URL")
+	}
+
+	r := &apiRequest{ctx: ctx, method: "GET", url: imageURL, op: OperationRead, name: "image download"}
+	rh.imagesMu.Lock()
+	cached, ok := rh.images[imageURL]
+	rh.imagesMu.Unlock()
+	if ok {
+		r.header = http.Header{"If-None-Match": {cached.etag}}
+		r.accept = []int{http.StatusOK, http.StatusNotModified}
+	}
+
+	resp, err := rh.execute(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		_, err = w.Write(cached.data)
+		return err
+	}
 
-	req, err := http.NewRequest("POST", fileUpload, bytes.NewReader(emptyBody))
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}
+
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to create upload request: %w", err)
+		return fmt.Errorf("failed to read image: %w", err)
 	}
+	rh.imagesMu.Lock()
+	if rh.images == nil {
+		rh.images = make(map[string]cachedImage)
+	}
+	rh.images[imageURL] = cachedImage{etag: etag, data: data}
+	rh.imagesMu.Unlock()
 
-	req.Header.Set("Content-Type", contentTypeJSON)
-	req.Header.Set("Authorization", "Bearer "+rh.jwtToken.AccessToken)
+	_, err = w.Write(data)
+	return err
+}
 
-	resp, err := rh.client.Do(req)
+// updateChapter changes a single chapter based on the latest server state and
+// sends only the chapters array, leaving all other tonie fields untouched
+func (rh *requestHandler) updateChapter(ctx context.Context, tonie *CreativeTonie, chapterID string, fields ChapterFields) error {
+	if err := tonie.checkFresh(); err != nil {
+		return err
+	}
+	latest, err := rh.refreshTonie(ctx, tonie)
 	if err != nil {
-		return fmt.Errorf("upload request failed: %w", err)
+		return fmt.Errorf("failed to fetch latest chapters: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload request failed with status %d: %s", resp.StatusCode, string(body))
+	position := latest.ChapterPosition(chapterID)
+	if position < 0 {
+		return fmt.Errorf("chapter %s not found", chapterID)
+	}
+	// The edit goes through the same checks as a commit of latest
+	latest.committedChapters = cloneChapters(latest.Chapters)
+	fields.apply(&latest.Chapters[position])
+	if err := latest.Validate(); err != nil {
+		return err
 	}
 
-	var amazonBean AmazonBean
-	if err := json.NewDecoder(resp.Body).Decode(&amazonBean); err != nil {
-		return fmt.Errorf("failed to decode amazon response: %w", err)
+	var changes []HistoryEvent
+	if len(rh.hooks) > 0 {
+		changes = chapterChanges(latest, latest.committedChapters, latest.Chapters, time.Now())
+		if err := rh.beforeCommit(latest, changes); err != nil {
+			return err
+		}
 	}
 
-	// Step 2: Upload file to Amazon S3
-	file, err := os.Open(filePath)
+	body, err := json.Marshal(struct {
+		Chapters []Chapter `json:"chapters"`
+	}{latest.Chapters})
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to marshal chapters: %w", err)
 	}
-	defer file.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	url := fmt.Sprintf(creativeTonie, tonie.household.ID, tonie.ID)
+	if err := rh.executeReplaceRequest(ctx, url, body); err != nil {
+		return err
+	}
+
+	rh.recordHistory(latest)
+	rh.afterCommit(latest, changes)
+	return nil
+}
+
+// uploadFile uploads the audio data read from r to a Creative-Tonie and
+// inserts the new chapter at opts.Position
+func (rh *requestHandler) uploadFile(ctx context.Context, tonie *CreativeTonie, r io.Reader, title string, opts UploadOptions) (string, error) {
+	if err := tonie.checkFresh(); err != nil {
+		return "", err
+	}
+	if err := tonie.checkChapterLimit(); err != nil {
+		return "", err
+	}
+	size := readerSize(r)
+	if err := rh.checkFileSize(size); err != nil {
+		return "", err
+	}
+
+	name := uploadName(r, opts)
+	contentType := opts.ContentType
+	if contentType == "" {
+		head := make([]byte, 12)
+		n, err := io.ReadFull(r, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		head = head[:n]
+		contentType = audio.DetectContentType(head, name)
+		r = io.MultiReader(bytes.NewReader(head), r)
+	}
+	if err := rh.checkUploadType(name, contentType); err != nil {
+		return "", err
+	}
+	r = rh.limitUpload(r)
+
+	if err := rh.checkUploadAllowed(ctx); err != nil {
+		return "", err
+	}
+	if err := rh.beforeUpload(tonie, title); err != nil {
+		return "", err
+	}
+
+	// Step 1: Request upload credentials from Toniebox API
+	amazonBean, err := rh.requestUploadSlot(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Step 2: Upload file to Amazon S3
+	body := newPooledBody(size)
+	defer body.release()
+	writer := multipart.NewWriter(body.buf)
 
 	// Add form fields
 	fields := amazonBean.Request.Fields
 	if err := writer.WriteField("key", fields.Key); err != nil {
-		return fmt.Errorf("failed to write key field: %w", err)
+		return "", fmt.Errorf("failed to write key field: %w", err)
 	}
 	if err := writer.WriteField("x-amz-algorithm", fields.XAmzAlgorithm); err != nil {
-		return fmt.Errorf("failed to write x-amz-algorithm field: %w", err)
+		return "", fmt.Errorf("failed to write x-amz-algorithm field: %w", err)
 	}
 	if err := writer.WriteField("x-amz-credential", fields.XAmzCredential); err != nil {
-		return fmt.Errorf("failed to write x-amz-credential field: %w", err)
+		return "", fmt.Errorf("failed to write x-amz-credential field: %w", err)
 	}
 	if err := writer.WriteField("x-amz-date", fields.XAmzDate); err != nil {
-		return fmt.Errorf("failed to write x-amz-date field: %w", err)
+		return "", fmt.Errorf("failed to write x-amz-date field: %w", err)
 	}
 	if err := writer.WriteField("policy", fields.Policy); err != nil {
-		return fmt.Errorf("failed to write policy field: %w", err)
+		return "", fmt.Errorf("failed to write policy field: %w", err)
 	}
 	if err := writer.WriteField("x-amz-signature", fields.XAmzSignature); err != nil {
-		return fmt.Errorf("failed to write x-amz-signature field: %w", err)
+		return "", fmt.Errorf("failed to write x-amz-signature field: %w", err)
 	}
 	if err := writer.WriteField("x-amz-security-token", fields.XAmzSecurityToken); err != nil {
-		return fmt.Errorf("failed to write x-amz-security-token field: %w", err)
+		return "", fmt.Errorf("failed to write x-amz-security-token field: %w", err)
 	}
 
 	// Add file
-	part, err := writer.CreateFormFile("file", fields.Key)
+	filename := opts.Filename
+	if filename == "" {
+		filename = fields.Key
+	}
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(filename)))
+	partHeader.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(partHeader)
 	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
+		return "", fmt.Errorf("failed to create form file: %w", err)
 	}
 
-	if _, err := io.Copy(part, file); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+	if _, err := copyPooled(part, r); err != nil {
+		return "", fmt.Errorf("failed to copy file: %w", err)
 	}
 
 	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close writer: %w", err)
+		return "", fmt.Errorf("failed to close writer: %w", err)
 	}
 
 	// Upload to S3
-	s3Req, err := http.NewRequest("POST", fileUploadAmazon, body)
-	if err != nil {
-		return fmt.Errorf("failed to create S3 request: %w", err)
-	}
-
-	s3Req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	s3Resp, err := rh.client.Do(s3Req)
+	err = rh.executeJSON(&apiRequest{
+		ctx:         ctx,
+		method:      "POST",
+		url:         fileUploadAmazon,
+		op:          OperationUpload,
+		name:        "S3 upload",
+		upload:      body,
+		contentType: writer.FormDataContentType(),
+		anonymous:   true,
+		accept:      []int{http.StatusOK, http.StatusNoContent},
+		// The upload key is fixed, so a repeated upload replaces the file
+		retrySafe: true,
+	}, nil)
 	if err != nil {
-		return fmt.Errorf("S3 upload failed: %w", err)
-	}
-	defer s3Resp.Body.Close()
-
-	if s3Resp.StatusCode != http.StatusNoContent && s3Resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(s3Resp.Body)
-		return fmt.Errorf("S3 upload failed with status %d: %s", s3Resp.StatusCode, string(body))
+		return "", err
 	}
 
 	// Step 3: Add chapter to tonie
-	newChapter := Chapter{
-		ID:    fields.Key,
-		File:  amazonBean.FileID,
-		Title: title,
-	}
-
-	tonie.Chapters = append(tonie.Chapters, newChapter)
-
-	return nil
+	return rh.addUploadedChapter(tonie, amazonBean, title, opts.Position).ID, nil
 }
 
-// executeGetRequest performs a GET request with authentication
-func (rh *requestHandler) executeGetRequest(url string, result interface{}) error {
-	req, err := http.NewRequest("GET", url, nil)
+// ping performs a cheap authenticated request and measures its latency
+func (rh *requestHandler) ping(ctx context.Context) (*PingResult, error) {
+	// An expired session is sent as is, so that the API reports it as
+	// rejected and the ping still measures the API
+	if err := rh.checkSession(ctx); err != nil && !errors.Is(err, ErrSessionExpired) {
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := rh.execute(&apiRequest{
+		ctx:            ctx,
+		method:         "GET",
+		url:            me,
+		op:             OperationRead,
+		name:           "ping",
+		accept:         []int{http.StatusOK, http.StatusUnauthorized, http.StatusForbidden},
+		sessionChecked: true,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if rh.jwtToken != nil {
-		req.Header.Set("Authorization", "Bearer "+rh.jwtToken.AccessToken)
-	}
-
-	resp, err := rh.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
+	latency := time.Since(start)
+	io.Copy(io.Discard, resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	return &PingResult{
+		Latency:       latency,
+		Authenticated: resp.StatusCode == http.StatusOK,
+		StatusCode:    resp.StatusCode,
+	}, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
-	}
+// executeGetRequest performs a GET request with authentication
+func (rh *requestHandler) executeGetRequest(ctx context.Context, url string, result interface{}) error {
+	return rh.executeJSON(&apiRequest{ctx: ctx, method: "GET", url: url, op: OperationRead}, result)
+}
 
-	return nil
+// get performs a GET request with authentication and checks the status
+func (rh *requestHandler) get(ctx context.Context, url string) (*http.Response, error) {
+	return rh.execute(&apiRequest{ctx: ctx, method: "GET", url: url, op: OperationRead})
 }
 
 // executePatchRequest performs a PATCH request with authentication
-func (rh *requestHandler) executePatchRequest(url string, body []byte) error {
-	req, err := http.NewRequest("PATCH", url, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", contentTypeJSON)
-	if rh.jwtToken != nil {
-		req.Header.Set("Authorization", "Bearer "+rh.jwtToken.AccessToken)
-	}
+func (rh *requestHandler) executePatchRequest(ctx context.Context, url string, body []byte) error {
+	return rh.executeSendRequest(ctx, "PATCH", url, body, nil)
+}
 
-	resp, err := rh.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+// executeReplaceRequest performs a PATCH request that replaces the state
+// of a resource, such as the chapter list of a Creative-Tonie. Repeating it
+// has no further effect, so it is retried like a GET request.
+func (rh *requestHandler) executeReplaceRequest(ctx context.Context, url string, body []byte) error {
+	return rh.executeJSON(&apiRequest{
+		ctx:         ctx,
+		method:      "PATCH",
+		url:         url,
+		op:          OperationCommit,
+		body:        body,
+		contentType: contentTypeJSON,
+		accept:      []int{http.StatusOK, http.StatusCreated, http.StatusNoContent},
+		retrySafe:   true,
+	}, nil)
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+// executePostRequest performs a POST request with authentication
+func (rh *requestHandler) executePostRequest(ctx context.Context, url string, body []byte) error {
+	return rh.executeSendRequest(ctx, "POST", url, body, nil)
+}
 
-	return nil
+// executeSendRequest sends body with the given method and, if result is not
+// nil, decodes the JSON response into it
+func (rh *requestHandler) executeSendRequest(ctx context.Context, method, url string, body []byte, result interface{}) error {
+	return rh.executeJSON(&apiRequest{
+		ctx:         ctx,
+		method:      method,
+		url:         url,
+		op:          OperationCommit,
+		body:        body,
+		contentType: contentTypeJSON,
+		accept:      []int{http.StatusOK, http.StatusCreated, http.StatusNoContent},
+	}, result)
 }
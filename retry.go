@@ -0,0 +1,224 @@
+package toniebox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned when a RetryBudget has been used up.
+// Once exhausted, all further requests charged to the budget fail immediately.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// maxRetryDelay caps the exponential backoff between retries
+const maxRetryDelay = 30 * time.Second
+
+// retryPolicy configures automatic retries of transient failures
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// WithRetry enables automatic retries of transient failures (network errors,
+// HTTP 429 and 5xx responses) with exponential backoff starting at baseDelay;
+// a zero baseDelay retries immediately. Retry-After headers are honored, up
+// to 30 seconds. Requests whose body cannot be replayed are never retried.
+// POST and PATCH requests that create something, e.g. an upload slot, are
+// only retried on HTTP 429, since the server may already
+// have acted on them when a network or server error occurs.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithRetry(3, 500*time.Millisecond))
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(rh *requestHandler) {
+		rh.retry = retryPolicy{maxRetries: maxRetries, baseDelay: baseDelay}
+	}
+}
+
+// WithRetryBudget charges all retries of the client to budget.
+// A budget attached to a request context via ContextWithRetryBudget takes precedence.
+func WithRetryBudget(budget *RetryBudget) Option {
+	return func(rh *requestHandler) {
+		rh.retryBudget = budget
+	}
+}
+
+// RetryBudget limits the total number of retries and the elapsed time of a
+// job (such as a sync or batch upload) across all of its operations, so that
+// a systematically failing job aborts early instead of retrying every request.
+// A RetryBudget is safe for concurrent use.
+type RetryBudget struct {
+	mu         sync.Mutex
+	maxRetries int
+	maxElapsed time.Duration
+	start      time.Time
+	used       int
+}
+
+// NewRetryBudget creates a budget allowing at most maxRetries retries within
+// maxElapsed from now. A zero value for either limit disables that limit.
+//
+// Example:
+//
+//	budget := toniebox.NewRetryBudget(20, 30*time.Minute)
+//	ctx := toniebox.ContextWithRetryBudget(context.Background(), budget)
+func NewRetryBudget(maxRetries int, maxElapsed time.Duration) *RetryBudget {
+	return &RetryBudget{
+		maxRetries: maxRetries,
+		maxElapsed: maxElapsed,
+		start:      time.Now(),
+	}
+}
+
+// Used returns the number of retries consumed so far
+func (b *RetryBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Exhausted reports whether the budget has been used up
+func (b *RetryBudget) Exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhaustedLocked()
+}
+
+// take consumes one retry, or returns ErrRetryBudgetExhausted
+func (b *RetryBudget) take() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhaustedLocked() {
+		return ErrRetryBudgetExhausted
+	}
+	b.used++
+	return nil
+}
+
+// exhaustedLocked reports whether the budget is used up; b.mu must be held
+func (b *RetryBudget) exhaustedLocked() bool {
+	if b.maxRetries > 0 && b.used >= b.maxRetries {
+		return true
+	}
+	return b.maxElapsed > 0 && time.Since(b.start) > b.maxElapsed
+}
+
+// retryBudgetKey is the context key for per-job retry budgets
+type retryBudgetKey struct{}
+
+// ContextWithRetryBudget returns a context whose requests are charged to budget
+func ContextWithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// budgetFor returns the retry budget applicable to a request issued with ctx
+func (rh *requestHandler) budgetFor(ctx context.Context) *RetryBudget {
+	if b, ok := ctx.Value(retryBudgetKey{}).(*RetryBudget); ok {
+		return b
+	}
+	return rh.retryBudget
+}
+
+// sendWithRetry sends req and retries transient failures according to the
+// retry policy and budget
+func (rh *requestHandler) sendWithRetry(req *http.Request, op Operation) (*http.Response, error) {
+	budget := rh.budgetFor(req.Context())
+	if budget != nil && budget.Exhausted() {
+		return nil, ErrRetryBudgetExhausted
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := rh.sendQueued(req, op)
+		if attempt >= rh.retry.maxRetries || !isRetryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay := rh.retryDelay(attempt, resp)
+		lastErr := err
+		if resp != nil {
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if budget != nil {
+			if err := budget.take(); err != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", ErrRetryBudgetExhausted, lastErr)
+			}
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// retrySafeKey marks the context of a non-idempotent request that may be
+// retried nonetheless, see apiRequest.retrySafe
+type retrySafeKey struct{}
+
+// isRetryable reports whether a request may be retried after the given outcome
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCertificatePinMismatch) {
+			return false
+		}
+		return isIdempotent(req)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// The request was rejected without being processed
+		return true
+	}
+	return resp.StatusCode >= 500 && isIdempotent(req)
+}
+
+// isIdempotent reports whether repeating req has no further effect
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// retryDelay computes the backoff before the next attempt, honoring
+// Retry-After. Both are capped at maxRetryDelay.
+func (rh *requestHandler) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			if seconds > int(maxRetryDelay/time.Second) {
+				return maxRetryDelay
+			}
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	if rh.retry.baseDelay <= 0 {
+		return 0
+	}
+	delay := rh.retry.baseDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		// A negative delay means the shift overflowed
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,128 @@
+package toniebox
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubTransport answers requests with the handler and counts them by
+// method and path
+type stubTransport struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	handler func(req *http.Request, call int) (int, string)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := req.Method + " " + req.URL.Path
+	t.mu.Lock()
+	if t.calls == nil {
+		t.calls = make(map[string]int)
+	}
+	t.calls[key]++
+	call := t.calls[key]
+	t.mu.Unlock()
+
+	status, body := t.handler(req, call)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// count returns the number of requests sent to method and path
+func (t *stubTransport) count(method, path string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls[method+" "+path]
+}
+
+// newStubClient returns a client that is logged in with token and sends all
+// requests to transport
+func newStubClient(transport http.RoundTripper, token *JWTToken, opts ...Option) *Client {
+	client := NewClient(append(opts, WithTransport(transport))...)
+	client.SetToken(token)
+	return client
+}
+
+// TestRetryIdempotentRequest checks that reads are retried on server errors
+func TestRetryIdempotentRequest(t *testing.T) {
+	transport := &stubTransport{handler: func(req *http.Request, call int) (int, string) {
+		if call < 3 {
+			return http.StatusServiceUnavailable, `{}`
+		}
+		return http.StatusOK, `{"email":"parent@example.com"}`
+	}}
+	client := newStubClient(transport, &JWTToken{AccessToken: "token"}, WithRetry(3, 0))
+
+	me, err := client.GetMe()
+	if err != nil {
+		t.Fatalf("GetMe failed: %v", err)
+	}
+	if me.Email != "parent@example.com" {
+		t.Errorf("GetMe returned %q, want parent@example.com", me.Email)
+	}
+	if n := transport.count("GET", "/v2/me"); n != 3 {
+		t.Errorf("GET /v2/me sent %d times, want 3", n)
+	}
+}
+
+// TestRetryNonIdempotentRequest checks that requests creating something are
+// not repeated after a server error, which may have been applied already,
+// but are repeated after a rate limit, which rejected them unprocessed
+func TestRetryNonIdempotentRequest(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		want   int
+	}{
+		{http.StatusInternalServerError, 1},
+		{http.StatusBadGateway, 1},
+		{http.StatusTooManyRequests, 2},
+	} {
+		transport := &stubTransport{handler: func(req *http.Request, call int) (int, string) {
+			if req.URL.Path == "/v2/file" && call == 1 {
+				return tt.status, `{}`
+			}
+			return http.StatusOK, `{}`
+		}}
+		client := newStubClient(transport, &JWTToken{AccessToken: "token"}, WithRetry(3, 0))
+
+		_, err := client.RequestUploadSlot()
+		if n := transport.count("POST", "/v2/file"); n != tt.want {
+			t.Errorf("status %d: POST /v2/file sent %d times, want %d", tt.status, n, tt.want)
+		}
+		if (err == nil) != (tt.want > 1) {
+			t.Errorf("status %d: RequestUploadSlot returned %v", tt.status, err)
+		}
+	}
+}
+
+// TestRetryDisabled checks that nothing is retried without WithRetry
+func TestRetryDisabled(t *testing.T) {
+	transport := &stubTransport{handler: func(req *http.Request, call int) (int, string) {
+		return http.StatusServiceUnavailable, `{}`
+	}}
+	client := newStubClient(transport, &JWTToken{AccessToken: "token"})
+
+	if _, err := client.GetMe(); err == nil {
+		t.Fatal("GetMe succeeded, want error")
+	}
+	if n := transport.count("GET", "/v2/me"); n != 1 {
+		t.Errorf("GET /v2/me sent %d times, want 1", n)
+	}
+}
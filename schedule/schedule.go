@@ -0,0 +1,294 @@
+// Package schedule spreads large upload jobs over time. A Scheduler uploads
+// at a limited rate and only within configured hours, and persists its
+// progress so that an interrupted job continues where it stopped, e.g. on the
+// next night.
+//
+// Example:
+//
+//	st, _ := store.Open("toniebox.db")
+//	sched := &schedule.Scheduler{
+//	    Client:   client,
+//	    Progress: st,
+//	    Interval: 2 * time.Minute,
+//	    Windows:  window.Windows{{Start: 1 * time.Hour, End: 6 * time.Hour}},
+//	}
+//	report, err := sched.Run(ctx, job)
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/eventlog"
+	"github.com/mikeboe/toniebox-api-go/kvstore"
+	"github.com/mikeboe/toniebox-api-go/notify"
+	"github.com/mikeboe/toniebox-api-go/window"
+)
+
+// Task uploads one file to one Creative-Tonie
+type Task struct {
+	HouseholdID string `json:"householdId"`
+	TonieID     string `json:"tonieId"`
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+}
+
+// key identifies the task in the persisted progress
+func (t Task) key() string {
+	return t.TonieID + "\x00" + t.Path
+}
+
+// Job is a named set of tasks. The ID must stay the same across runs so that
+// progress can be resumed.
+type Job struct {
+	ID    string `json:"id"`
+	Tasks []Task `json:"tasks"`
+}
+
+// ProgressStore persists job progress, see kvstore for the available backends
+type ProgressStore = kvstore.Store
+
+// Report summarizes a scheduler run
+type Report struct {
+	Uploaded  int
+	Failed    int
+	Remaining int
+}
+
+// Done reports whether all tasks of the job have completed
+func (r *Report) Done() bool {
+	return r.Remaining == 0
+}
+
+// Scheduler runs jobs within rate limits and allowed hours
+type Scheduler struct {
+	Client *toniebox.Client
+	// Progress persists completed tasks; without it progress is lost
+	// when the process exits
+	Progress ProgressStore
+	// Interval is the minimum time between two uploads
+	Interval time.Duration
+	// MaxPerRun limits the number of uploads per run; zero means no limit
+	MaxPerRun int
+	// Windows restrict uploads to certain hours; nil allows any time
+	Windows window.Windows
+	// Notifier is informed when a run finishes
+	Notifier notify.Notifier
+	// Events receives a structured event for every upload and run; nil disables it
+	Events eventlog.Logger
+}
+
+// Run uploads the pending tasks of job. Tasks completed in earlier runs are
+// skipped. Each upload is committed right away and recorded as done.
+//
+// If the scheduler is outside its windows, Run waits until one opens.
+// Run returns when all tasks are done, MaxPerRun is reached, the window
+// closes, ctx is cancelled or the client is closed. Failed tasks are retried on the next run;
+// their errors are returned as a *toniebox.MultiError.
+func (s *Scheduler) Run(ctx context.Context, job Job) (*Report, error) {
+	// Closing the client stops the run as well
+	ctx, cancel := s.Client.Context(ctx)
+	defer cancel()
+
+	startedAt := time.Now()
+	s.event(eventlog.LevelInfo, "run_started", job.ID, nil)
+	report, err := s.run(ctx, job)
+
+	summary := fmt.Sprintf("%d uploaded, %d failed, %d remaining", report.Uploaded, report.Failed, report.Remaining)
+	fields := map[string]interface{}{
+		"uploaded":    report.Uploaded,
+		"failed":      report.Failed,
+		"remaining":   report.Remaining,
+		"duration_ms": time.Since(startedAt).Milliseconds(),
+	}
+	level := eventlog.LevelInfo
+	if err != nil {
+		level = eventlog.LevelError
+		fields["error"] = err.Error()
+	}
+	s.event(level, "run_finished", job.ID, fields)
+
+	if s.Notifier != nil {
+		_ = s.Notifier.Notify(ctx, notify.NewEvent("schedule "+job.ID, startedAt, summary, err))
+	}
+	return report, err
+}
+
+// run implements Run without notification
+func (s *Scheduler) run(ctx context.Context, job Job) (*Report, error) {
+	report := &Report{Remaining: len(job.Tasks)}
+	done, err := s.loadProgress(job.ID)
+	if err != nil {
+		return report, err
+	}
+
+	var pending []Task
+	for _, task := range job.Tasks {
+		if !done[task.key()] {
+			pending = append(pending, task)
+		}
+	}
+	report.Remaining = len(pending)
+
+	if !s.Windows.Contains(time.Now()) {
+		s.event(eventlog.LevelInfo, "window_wait", job.ID, map[string]interface{}{
+			"until": s.Windows.Next(time.Now()).Format(time.RFC3339),
+		})
+	}
+	if err := s.Windows.Wait(ctx); err != nil {
+		return report, err
+	}
+
+	tonies := make(map[string]*toniebox.CreativeTonie)
+	var errs []error
+	var lastUpload time.Time
+	for _, task := range pending {
+		if s.MaxPerRun > 0 && report.Uploaded+report.Failed >= s.MaxPerRun {
+			break
+		}
+		if !lastUpload.IsZero() {
+			if err := sleepUntil(ctx, lastUpload.Add(s.Interval)); err != nil {
+				return report, joinErrors(append(errs, err))
+			}
+		}
+		if !s.Windows.Contains(time.Now()) {
+			break
+		}
+
+		lastUpload = time.Now()
+		fields := map[string]interface{}{
+			"tonie_id": task.TonieID,
+			"path":     task.Path,
+			"title":    task.Title,
+		}
+		if err := s.upload(tonies, task); err != nil {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("%s: %w", task.Path, err))
+			fields["error"] = err.Error()
+			s.event(eventlog.LevelWarn, "upload_failed", job.ID, fields)
+			continue
+		}
+		fields["duration_ms"] = time.Since(lastUpload).Milliseconds()
+		s.event(eventlog.LevelInfo, "upload", job.ID, fields)
+
+		report.Uploaded++
+		report.Remaining--
+		done[task.key()] = true
+		if err := s.saveProgress(job.ID, done); err != nil {
+			return report, joinErrors(append(errs, err))
+		}
+	}
+	return report, joinErrors(errs)
+}
+
+// event records a structured event if an event log is configured
+func (s *Scheduler) event(level eventlog.Level, event, jobID string, fields map[string]interface{}) {
+	if s.Events == nil {
+		return
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["job"] = jobID
+	s.Events.Log(eventlog.Entry{Level: level, Source: "schedule", Event: event, Fields: fields})
+}
+
+// upload uploads and commits a single task
+func (s *Scheduler) upload(tonies map[string]*toniebox.CreativeTonie, task Task) error {
+	tonie, ok := tonies[task.TonieID]
+	if !ok {
+		var err error
+		tonie, err = s.Client.Household(task.HouseholdID).Tonie(task.TonieID)
+		if err != nil {
+			return err
+		}
+		tonies[task.TonieID] = tonie
+	}
+
+	if err := tonie.UploadFile(task.Title, task.Path); err != nil {
+		return err
+	}
+	if err := tonie.Commit(); err != nil {
+		// Drop the uncommitted chapter so later tasks start from a clean state
+		tonie.Chapters = tonie.Chapters[:len(tonie.Chapters)-1]
+		return err
+	}
+	return nil
+}
+
+// progressKey returns the store key of a job's progress
+func progressKey(jobID string) string {
+	return "schedule/" + jobID
+}
+
+// loadProgress returns the keys of the tasks completed in earlier runs
+func (s *Scheduler) loadProgress(jobID string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	if s.Progress == nil {
+		return done, nil
+	}
+
+	value, _, err := s.Progress.Meta(progressKey(jobID))
+	if errors.Is(err, toniebox.ErrCacheMiss) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load progress: %w", err)
+	}
+	var keys []string
+	if err := json.Unmarshal([]byte(value), &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode progress: %w", err)
+	}
+	for _, key := range keys {
+		done[key] = true
+	}
+	return done, nil
+}
+
+// saveProgress persists the keys of the completed tasks
+func (s *Scheduler) saveProgress(jobID string, done map[string]bool) error {
+	if s.Progress == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(done))
+	for key := range done {
+		keys = append(keys, key)
+	}
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to encode progress: %w", err)
+	}
+	if err := s.Progress.SetMeta(progressKey(jobID), string(data)); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// sleepUntil blocks until t or until ctx is done
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// joinErrors returns errs as a *toniebox.MultiError, or nil if empty
+func joinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &toniebox.MultiError{Errors: errs}
+}
@@ -0,0 +1,175 @@
+package toniebox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrSessionExpired is returned by every authenticated call when the access
+// token has expired or was rejected by the API (HTTP 401). Log in again, or
+// create the client with WithAutoRefresh to renew the token automatically.
+// If the API rejected the token, the error also wraps the *APIError.
+//
+// Example:
+//
+//	households, err := client.GetHouseholds()
+//	if errors.Is(err, toniebox.ErrSessionExpired) {
+//	    _, err = client.Login(username, password)
+//	}
+var ErrSessionExpired = errors.New("session expired")
+
+// expirySkew renews tokens a little before they expire, so that they do
+// not expire while a request is on its way
+const expirySkew = 30 * time.Second
+
+// WithAutoRefresh renews the access token with its refresh token when it
+// has expired or is rejected by the API, instead of failing with
+// ErrSessionExpired. Only if the renewal fails is ErrSessionExpired returned.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithAutoRefresh())
+func WithAutoRefresh() Option {
+	return func(rh *requestHandler) {
+		rh.autoRefresh = true
+	}
+}
+
+// expired reports whether the token of state has expired. Tokens without
+// a lifetime never expire locally; the API decides.
+func (s *authState) expired(now time.Time) bool {
+	return !s.expiresAt.IsZero() && !now.Before(s.expiresAt.Add(-expirySkew))
+}
+
+// checkSession makes sure that the token is still valid before a request is
+// sent, renewing it if configured. Without a token there is nothing to check.
+func (rh *requestHandler) checkSession(ctx context.Context) error {
+	state := rh.auth.Load()
+	if state == nil || !state.expired(time.Now()) {
+		return nil
+	}
+	if !rh.autoRefresh {
+		return ErrSessionExpired
+	}
+	return rh.refreshSession(ctx, state)
+}
+
+// refreshSession renews the token of stale with its refresh token. If the
+// token was replaced in the meantime, e.g. by a concurrent refresh, nothing
+// is done.
+func (rh *requestHandler) refreshSession(ctx context.Context, stale *authState) error {
+	rh.refreshMu.Lock()
+	defer rh.refreshMu.Unlock()
+	if current := rh.auth.Load(); current != stale {
+		return nil
+	}
+	if stale == nil || stale.token.RefreshToken == "" {
+		return ErrSessionExpired
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", grantTypeRefreshToken)
+	data.Set("client_id", rh.oauthClientID())
+	data.Set("refresh_token", stale.token.RefreshToken)
+
+	var token JWTToken
+	err := rh.executeJSON(&apiRequest{
+		ctx:         ctx,
+		method:      "POST",
+		url:         openIDConnect,
+		op:          OperationLogin,
+		name:        "token refresh",
+		body:        []byte(data.Encode()),
+		contentType: contentTypeForm,
+		anonymous:   true,
+		retrySafe:   true,
+	}, &token)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSessionExpired, err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = stale.token.RefreshToken
+	}
+	if token.IDToken == "" {
+		token.IDToken = stale.token.IDToken
+	}
+	rh.storeToken(&token)
+	return nil
+}
+
+// Disconnect logs this client out: the refresh token is revoked at the login
+// server, so that it cannot be used to obtain new access tokens, and the
+// token is forgotten. Other devices stay logged in. On shared machines,
+// call Disconnect instead of just dropping the client.
+//
+// The token is forgotten even if the revocation fails, in which case the
+// error is returned. Disconnecting a client that is not logged in does
+// nothing.
+//
+// Example:
+//
+//	if err := client.Disconnect(); err != nil {
+//	    log.Printf("logout: %v", err)
+//	}
+func (c *Client) Disconnect() error {
+	return c.DisconnectContext(context.Background())
+}
+
+// DisconnectContext is like Disconnect, but the revocation is aborted when
+// ctx is done. The token is forgotten in any case.
+func (c *Client) DisconnectContext(ctx context.Context) error {
+	return c.requestHandler.disconnect(ctx, false)
+}
+
+// disconnect forgets the token and revokes it. Close sets closing to revoke
+// the token of the closed client.
+func (rh *requestHandler) disconnect(ctx context.Context, closing bool) error {
+	token := rh.token()
+	if token == nil {
+		return nil
+	}
+	rh.setToken(nil)
+	return rh.revokeToken(ctx, token, closing)
+}
+
+// revokeToken revokes the refresh token of token, or its access token if
+// there is no refresh token
+func (rh *requestHandler) revokeToken(ctx context.Context, token *JWTToken, closing bool) error {
+	data := url.Values{}
+	data.Set("client_id", rh.oauthClientID())
+	if token.RefreshToken != "" {
+		data.Set("token", token.RefreshToken)
+		data.Set("token_type_hint", "refresh_token")
+	} else {
+		data.Set("token", token.AccessToken)
+		data.Set("token_type_hint", "access_token")
+	}
+
+	resp, err := rh.execute(&apiRequest{
+		ctx:         ctx,
+		method:      "POST",
+		url:         openIDRevoke,
+		op:          OperationLogin,
+		name:        "token revocation",
+		body:        []byte(data.Encode()),
+		contentType: contentTypeForm,
+		anonymous:   true,
+		retrySafe:   true,
+		closing:     closing,
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// sessionError returns the error for a 401 response to an authenticated
+// request, consuming the body of resp
+func sessionError(name string, resp *http.Response) error {
+	return fmt.Errorf("%w: %w", ErrSessionExpired, newAPIError(name, resp))
+}
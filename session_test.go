@@ -0,0 +1,91 @@
+package toniebox
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+const tokenPath = "/auth/realms/tonies/protocol/openid-connect/token"
+
+// refreshTransport accepts only the access token "new" and hands it out for
+// the refresh token "refresh-old"
+func refreshTransport(t *testing.T) *stubTransport {
+	return &stubTransport{handler: func(req *http.Request, call int) (int, string) {
+		if req.URL.Path == tokenPath {
+			if err := req.ParseForm(); err != nil {
+				t.Errorf("token request: %v", err)
+			}
+			if got := req.PostForm.Get("refresh_token"); got != "refresh-old" {
+				t.Errorf("token request sent refresh_token %q, want refresh-old", got)
+			}
+			if got := req.PostForm.Get("grant_type"); got != "refresh_token" {
+				t.Errorf("token request sent grant_type %q, want refresh_token", got)
+			}
+			return http.StatusOK, `{"access_token":"new","refresh_token":"refresh-new","token_type":"Bearer"}`
+		}
+		if req.Header.Get("Authorization") != "Bearer new" {
+			return http.StatusUnauthorized, `{}`
+		}
+		return http.StatusOK, `{"email":"parent@example.com"}`
+	}}
+}
+
+// TestSessionRefreshOnUnauthorized checks that a request rejected with 401
+// renews the token once and is sent again with the new token
+func TestSessionRefreshOnUnauthorized(t *testing.T) {
+	transport := refreshTransport(t)
+	client := newStubClient(transport, &JWTToken{AccessToken: "old", RefreshToken: "refresh-old", TokenType: "Bearer"}, WithAutoRefresh())
+
+	me, err := client.GetMe()
+	if err != nil {
+		t.Fatalf("GetMe failed: %v", err)
+	}
+	if me.Email != "parent@example.com" {
+		t.Errorf("GetMe returned %q, want parent@example.com", me.Email)
+	}
+	if n := transport.count("POST", tokenPath); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+	if n := transport.count("GET", "/v2/me"); n != 2 {
+		t.Errorf("GET /v2/me sent %d times, want 2", n)
+	}
+	if token := client.Token(); token.AccessToken != "new" || token.RefreshToken != "refresh-new" {
+		t.Errorf("client token is %+v after refresh", token)
+	}
+}
+
+// TestSessionExpiredWithoutRefresh checks that a 401 surfaces as
+// ErrSessionExpired when auto refresh is off
+func TestSessionExpiredWithoutRefresh(t *testing.T) {
+	transport := refreshTransport(t)
+	client := newStubClient(transport, &JWTToken{AccessToken: "old", RefreshToken: "refresh-old", TokenType: "Bearer"})
+
+	_, err := client.GetMe()
+	if !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("GetMe returned %v, want ErrSessionExpired", err)
+	}
+	if n := transport.count("POST", tokenPath); n != 0 {
+		t.Errorf("token endpoint called %d times, want 0", n)
+	}
+}
+
+// TestSessionRefreshRejected checks that a failed refresh surfaces as
+// ErrSessionExpired instead of retrying with the stale token
+func TestSessionRefreshRejected(t *testing.T) {
+	transport := &stubTransport{handler: func(req *http.Request, call int) (int, string) {
+		if req.URL.Path == tokenPath {
+			return http.StatusBadRequest, `{"error":"invalid_grant"}`
+		}
+		return http.StatusUnauthorized, `{}`
+	}}
+	client := newStubClient(transport, &JWTToken{AccessToken: "old", RefreshToken: "refresh-old", TokenType: "Bearer"}, WithAutoRefresh())
+
+	_, err := client.GetMe()
+	if !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("GetMe returned %v, want ErrSessionExpired", err)
+	}
+	if n := transport.count("GET", "/v2/me"); n != 1 {
+		t.Errorf("GET /v2/me sent %d times, want 1", n)
+	}
+}
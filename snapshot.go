@@ -0,0 +1,91 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// snapshotConcurrency bounds the requests Snapshot has in flight at once
+const snapshotConcurrency = 4
+
+// Snapshot reads the account, all households and their Creative-Tonies into
+// a single State, like ExportState. The requests
+// run concurrently, at most four at a time, so that accounts with several
+// households are hydrated quickly. This makes it the one call to build
+// reports, exports or views of the whole account on.
+//
+// The first failing request aborts the others, and its error is returned.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	state, err := client.Snapshot(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, hs := range state.Households {
+//	    fmt.Printf("%s: %d tonies\n", hs.Household.Name, len(hs.Tonies))
+//	}
+func (c *Client) Snapshot(ctx context.Context) (*State, error) {
+	rh := c.requestHandler
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var first error
+	run := func(n int, fn func(i int) error) error {
+		err := runParallel(n, snapshotConcurrency, func(i int) error {
+			err := fn(i)
+			if err != nil {
+				once.Do(func() {
+					first = err
+					cancel()
+				})
+			}
+			return err
+		})
+		if first != nil {
+			return first
+		}
+		return err
+	}
+
+	state := &State{ExportedAt: time.Now().UTC()}
+	var households []Household
+	err := run(2, func(i int) (err error) {
+		if i == 0 {
+			if state.Me, err = rh.getMe(ctx); err != nil {
+				return fmt.Errorf("failed to export account: %w", err)
+			}
+			return nil
+		}
+		if households, err = rh.getHouseholds(ctx); err != nil {
+			return fmt.Errorf("failed to export households: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(households) == 0 {
+		return state, nil
+	}
+
+	state.Households = make([]HouseholdState, len(households))
+	err = run(len(households), func(i int) (err error) {
+		household := &households[i]
+		hs := &state.Households[i]
+		hs.Household = *household
+		if hs.Tonies, err = rh.getCreativeTonies(ctx, household); err != nil {
+			return fmt.Errorf("failed to export tonies of %s: %w", household.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
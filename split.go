@@ -0,0 +1,90 @@
+package toniebox
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// SplitPart is the share of a file set assigned to one Creative-Tonie
+type SplitPart struct {
+	Tonie    *CreativeTonie
+	Files    []PlannedFile
+	Duration time.Duration
+}
+
+// SplitPlan distributes a file set across several Creative-Tonies
+// ("Disc 1", "Disc 2", ...) in listening order
+type SplitPlan struct {
+	// Parts holds one entry per tonie, in the order the tonies were given.
+	// Parts of tonies that receive no files have an empty Files slice.
+	Parts []SplitPart
+	// Overflow are the files that did not fit on any of the tonies
+	Overflow []PlannedFile
+}
+
+// PlanSplit distributes files across tonies in order, filling each tonie up
+// to its SecondsRemaining and ChaptersRemaining before moving on to the next.
+// Durations are probed with audio.DefaultProber.
+//
+// Example:
+//
+//	files, _ := toniebox.ScanDir("/music/long-album", toniebox.BatchOptions{})
+//	plan, err := toniebox.PlanSplit([]*toniebox.CreativeTonie{disc1, disc2}, files)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if len(plan.Overflow) > 0 {
+//	    log.Fatalf("%d files do not fit", len(plan.Overflow))
+//	}
+//	err = plan.Execute()
+func PlanSplit(tonies []*CreativeTonie, files []BatchFile) (*SplitPlan, error) {
+	return PlanSplitWith(audio.DefaultProber, tonies, files)
+}
+
+// PlanSplitWith is like PlanSplit but uses the given prober
+func PlanSplitWith(prober audio.Prober, tonies []*CreativeTonie, files []BatchFile) (*SplitPlan, error) {
+	remaining, err := probeFiles(prober, files)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SplitPlan{}
+	for _, tonie := range tonies {
+		capacity := planCapacity(remaining, tonie.SecondsRemaining, tonie.ChaptersRemaining)
+		plan.Parts = append(plan.Parts, SplitPart{
+			Tonie:    tonie,
+			Files:    capacity.Fits,
+			Duration: capacity.FitsDuration,
+		})
+		remaining = capacity.Overflow
+	}
+	plan.Overflow = remaining
+	return plan, nil
+}
+
+// Execute uploads the files of every part to its tonie and commits each tonie.
+// Files are run through the given processors before upload.
+// Tonies are processed concurrently. A tonie whose uploads failed is not
+// committed; the failures of all tonies are returned as a *MultiError.
+func (sp *SplitPlan) Execute(processors ...audio.Processor) error {
+	return runParallel(len(sp.Parts), 0, func(i int) error {
+		part := sp.Parts[i]
+		if len(part.Files) == 0 {
+			return nil
+		}
+
+		files := make([]BatchFile, len(part.Files))
+		for i, file := range part.Files {
+			files[i] = file.BatchFile
+		}
+		if err := part.Tonie.UploadBatch(files, processors...); err != nil {
+			return fmt.Errorf("failed to upload to %s: %w", part.Tonie.Name, err)
+		}
+		if err := part.Tonie.Commit(); err != nil {
+			return fmt.Errorf("failed to commit %s: %w", part.Tonie.Name, err)
+		}
+		return nil
+	})
+}
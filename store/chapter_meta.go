@@ -0,0 +1,140 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// ChapterMeta is local-only metadata about a chapter that the Toniecloud does
+// not store, such as where its audio came from
+type ChapterMeta struct {
+	ChapterID  string
+	TonieID    string
+	Title      string
+	SourcePath string
+	// SHA256 is the hex-encoded hash of the source file, used for dedupe
+	SHA256     string
+	UploadedAt time.Time
+	Tags       map[string]string
+}
+
+// PutChapterMeta stores meta, replacing existing metadata of the chapter
+func (s *Store) PutChapterMeta(meta ChapterMeta) error {
+	tags, err := json.Marshal(meta.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO chapter_meta (chapter_id, tonie_id, title, source_path, sha256, uploaded_at, tags) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chapter_id) DO UPDATE SET tonie_id = excluded.tonie_id, title = excluded.title, source_path = excluded.source_path,
+			sha256 = excluded.sha256, uploaded_at = excluded.uploaded_at, tags = excluded.tags`,
+		meta.ChapterID, meta.TonieID, meta.Title, meta.SourcePath, meta.SHA256, meta.UploadedAt.UnixNano(), string(tags))
+	if err != nil {
+		return fmt.Errorf("failed to store chapter metadata: %w", err)
+	}
+	return nil
+}
+
+// SetChapterTag sets a single tag on the metadata of a chapter.
+// Returns toniebox.ErrCacheMiss if the chapter has no metadata.
+func (s *Store) SetChapterTag(chapterID, key, value string) error {
+	meta, err := s.ChapterMeta(chapterID)
+	if err != nil {
+		return err
+	}
+	if meta.Tags == nil {
+		meta.Tags = make(map[string]string)
+	}
+	meta.Tags[key] = value
+	return s.PutChapterMeta(*meta)
+}
+
+// ChapterMeta returns the metadata of a chapter, or toniebox.ErrCacheMiss
+func (s *Store) ChapterMeta(chapterID string) (*ChapterMeta, error) {
+	metas, err := s.queryChapterMeta(`WHERE chapter_id = ?`, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(metas) == 0 {
+		return nil, toniebox.ErrCacheMiss
+	}
+	return &metas[0], nil
+}
+
+// TonieChapterMeta returns the metadata of all chapters uploaded to a tonie,
+// oldest upload first
+func (s *Store) TonieChapterMeta(tonieID string) ([]ChapterMeta, error) {
+	return s.queryChapterMeta(`WHERE tonie_id = ? ORDER BY uploaded_at`, tonieID)
+}
+
+// ChapterMetaByHash returns the metadata of all chapters uploaded from a file
+// with the given SHA-256 hash, e.g. to skip uploading duplicates
+func (s *Store) ChapterMetaByHash(sha256 string) ([]ChapterMeta, error) {
+	return s.queryChapterMeta(`WHERE sha256 = ? ORDER BY uploaded_at`, sha256)
+}
+
+// RecordUpload implements toniebox.UploadRecorder. The source file is hashed
+// so that later uploads of the same content can be detected.
+func (s *Store) RecordUpload(record toniebox.UploadRecord) error {
+	hash, err := HashFile(record.SourcePath)
+	if err != nil {
+		return err
+	}
+	return s.PutChapterMeta(ChapterMeta{
+		ChapterID:  record.ChapterID,
+		TonieID:    record.TonieID,
+		Title:      record.Title,
+		SourcePath: record.SourcePath,
+		SHA256:     hash,
+		UploadedAt: record.Time,
+	})
+}
+
+// HashFile returns the hex-encoded SHA-256 hash of the file at path
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// queryChapterMeta returns the chapter metadata matching the given clause
+func (s *Store) queryChapterMeta(clause string, args ...interface{}) ([]ChapterMeta, error) {
+	rows, err := s.db.Query(`SELECT chapter_id, tonie_id, title, source_path, sha256, uploaded_at, tags FROM chapter_meta `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chapter metadata: %w", err)
+	}
+	defer rows.Close()
+
+	var result []ChapterMeta
+	for rows.Next() {
+		var meta ChapterMeta
+		var uploadedAt int64
+		var tags string
+		if err := rows.Scan(&meta.ChapterID, &meta.TonieID, &meta.Title, &meta.SourcePath, &meta.SHA256, &uploadedAt, &tags); err != nil {
+			return nil, fmt.Errorf("failed to scan chapter metadata: %w", err)
+		}
+		meta.UploadedAt = time.Unix(0, uploadedAt)
+		if err := json.Unmarshal([]byte(tags), &meta.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode tags: %w", err)
+		}
+		result = append(result, meta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chapter metadata: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// MonthlyCount is the number of history events in a calendar month
+type MonthlyCount struct {
+	// Month is formatted as "2006-01"
+	Month string
+	Count int
+}
+
+// TonieChurn is the number of uploads and deletions of a tonie
+type TonieChurn struct {
+	TonieID   string
+	TonieName string
+	Uploads   int
+	Deletes   int
+}
+
+// Changes returns the total number of uploads and deletions
+func (tc TonieChurn) Changes() int {
+	return tc.Uploads + tc.Deletes
+}
+
+// RecordHistory implements toniebox.HistoryRecorder
+func (s *Store) RecordHistory(events []toniebox.HistoryEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, e := range events {
+		if _, err := tx.Exec(`INSERT INTO history (time, kind, household_id, tonie_id, tonie_name, chapter_id, chapter_title, seconds) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.Time.UnixNano(), string(e.Kind), e.HouseholdID, e.TonieID, e.TonieName, e.ChapterID, e.ChapterTitle, e.Seconds); err != nil {
+			return fmt.Errorf("failed to store history event: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// History returns the events since the given time, oldest first.
+// If tonieID is not empty, only events of that tonie are returned.
+func (s *Store) History(tonieID string, since time.Time) ([]toniebox.HistoryEvent, error) {
+	query := `SELECT time, kind, household_id, tonie_id, tonie_name, chapter_id, chapter_title, seconds FROM history WHERE time >= ?`
+	args := []interface{}{since.UnixNano()}
+	if tonieID != "" {
+		query += ` AND tonie_id = ?`
+		args = append(args, tonieID)
+	}
+	query += ` ORDER BY time`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var result []toniebox.HistoryEvent
+	for rows.Next() {
+		var e toniebox.HistoryEvent
+		var at int64
+		var kind string
+		if err := rows.Scan(&at, &kind, &e.HouseholdID, &e.TonieID, &e.TonieName, &e.ChapterID, &e.ChapterTitle, &e.Seconds); err != nil {
+			return nil, fmt.Errorf("failed to scan history event: %w", err)
+		}
+		e.Time = time.Unix(0, at)
+		e.Kind = toniebox.HistoryKind(kind)
+		result = append(result, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return result, nil
+}
+
+// CountPerMonth returns the number of events of the given kind per calendar
+// month (in loc) since the given time, oldest month first
+func (s *Store) CountPerMonth(kind toniebox.HistoryKind, since time.Time, loc *time.Location) ([]MonthlyCount, error) {
+	events, err := s.History("", since)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []MonthlyCount
+	for _, e := range events {
+		if e.Kind != kind {
+			continue
+		}
+		month := e.Time.In(loc).Format("2006-01")
+		if n := len(result); n > 0 && result[n-1].Month == month {
+			result[n-1].Count++
+			continue
+		}
+		result = append(result, MonthlyCount{Month: month, Count: 1})
+	}
+	return result, nil
+}
+
+// MostChangedTonies returns up to limit tonies ordered by their number of
+// uploads and deletions since the given time
+func (s *Store) MostChangedTonies(since time.Time, limit int) ([]TonieChurn, error) {
+	rows, err := s.db.Query(`
+		SELECT tonie_id,
+		       (SELECT h2.tonie_name FROM history h2 WHERE h2.tonie_id = h.tonie_id ORDER BY h2.time DESC LIMIT 1),
+		       SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END)
+		FROM history h
+		WHERE time >= ?
+		GROUP BY tonie_id
+		ORDER BY COUNT(*) DESC, tonie_id
+		LIMIT ?`,
+		string(toniebox.HistoryUpload), string(toniebox.HistoryDelete), since.UnixNano(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query churn: %w", err)
+	}
+	defer rows.Close()
+
+	var result []TonieChurn
+	for rows.Next() {
+		var tc TonieChurn
+		var name sql.NullString
+		if err := rows.Scan(&tc.TonieID, &name, &tc.Uploads, &tc.Deletes); err != nil {
+			return nil, fmt.Errorf("failed to scan churn: %w", err)
+		}
+		tc.TonieName = name.String
+		result = append(result, tc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read churn: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,323 @@
+// Package store provides an optional SQLite-backed persistent store for
+// households, Creative-Tonies, chapters, local chapter metadata and sync
+// metadata.
+//
+// A Store implements toniebox.Cache, so it can be passed to
+// toniebox.WithStaleCache to survive process restarts and API outages:
+//
+//	st, err := store.Open("toniebox.db")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer st.Close()
+//	client := toniebox.NewClient(toniebox.WithStaleCache(st))
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+
+	_ "modernc.org/sqlite"
+)
+
+// schema creates all tables used by the store
+const schema = `
+CREATE TABLE IF NOT EXISTS fetches (
+	scope      TEXT PRIMARY KEY,
+	fetched_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS households (
+	id       TEXT PRIMARY KEY,
+	position INTEGER NOT NULL,
+	name     TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS tonies (
+	id           TEXT PRIMARY KEY,
+	household_id TEXT NOT NULL,
+	position     INTEGER NOT NULL,
+	name         TEXT NOT NULL,
+	data         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS tonies_household ON tonies (household_id);
+CREATE TABLE IF NOT EXISTS chapters (
+	tonie_id    TEXT NOT NULL,
+	position    INTEGER NOT NULL,
+	id          TEXT NOT NULL,
+	file        TEXT NOT NULL,
+	title       TEXT NOT NULL,
+	seconds     REAL NOT NULL,
+	transcoding INTEGER NOT NULL,
+	PRIMARY KEY (tonie_id, position)
+);
+CREATE TABLE IF NOT EXISTS history (
+	time          INTEGER NOT NULL,
+	kind          TEXT NOT NULL,
+	household_id  TEXT NOT NULL,
+	tonie_id      TEXT NOT NULL,
+	tonie_name    TEXT NOT NULL,
+	chapter_id    TEXT NOT NULL,
+	chapter_title TEXT NOT NULL,
+	seconds       REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS history_time ON history (time);
+CREATE INDEX IF NOT EXISTS history_tonie ON history (tonie_id, time);
+CREATE TABLE IF NOT EXISTS chapter_meta (
+	chapter_id  TEXT PRIMARY KEY,
+	tonie_id    TEXT NOT NULL,
+	title       TEXT NOT NULL,
+	source_path TEXT NOT NULL,
+	sha256      TEXT NOT NULL,
+	uploaded_at INTEGER NOT NULL,
+	tags        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chapter_meta_tonie ON chapter_meta (tonie_id);
+CREATE INDEX IF NOT EXISTS chapter_meta_sha256 ON chapter_meta (sha256);
+CREATE TABLE IF NOT EXISTS sync_meta (
+	key        TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+`
+
+// householdsScope is the fetches scope for the household list
+const householdsScope = "households"
+
+// Store is a persistent SQLite-backed store
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (or creates) the SQLite database at path and ensures the schema exists
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	// SQLite does not support concurrent writers
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// DB returns the underlying database handle for custom queries
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// PutHouseholds implements toniebox.Cache
+func (s *Store) PutHouseholds(households []toniebox.Household, fetchedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM households`); err != nil {
+		return fmt.Errorf("failed to clear households: %w", err)
+	}
+	for i, household := range households {
+		data, err := household.CanonicalJSON()
+		if err != nil {
+			return fmt.Errorf("failed to marshal household: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO households (id, position, name, data) VALUES (?, ?, ?, ?)`,
+			household.ID, i, household.Name, string(data)); err != nil {
+			return fmt.Errorf("failed to store household: %w", err)
+		}
+	}
+	if err := putFetch(tx, householdsScope, fetchedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Households implements toniebox.Cache
+func (s *Store) Households() ([]toniebox.Household, time.Time, error) {
+	fetchedAt, err := s.fetchedAt(householdsScope)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rows, err := s.db.Query(`SELECT data FROM households ORDER BY position`)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to query households: %w", err)
+	}
+	defer rows.Close()
+
+	var result []toniebox.Household
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to scan household: %w", err)
+		}
+		var household toniebox.Household
+		if err := json.Unmarshal([]byte(data), &household); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to decode household: %w", err)
+		}
+		result = append(result, household)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read households: %w", err)
+	}
+	return result, fetchedAt, nil
+}
+
+// PutCreativeTonies implements toniebox.Cache
+func (s *Store) PutCreativeTonies(householdID string, tonies []toniebox.CreativeTonie, fetchedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM chapters WHERE tonie_id IN (SELECT id FROM tonies WHERE household_id = ?)`, householdID); err != nil {
+		return fmt.Errorf("failed to clear chapters: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM tonies WHERE household_id = ?`, householdID); err != nil {
+		return fmt.Errorf("failed to clear tonies: %w", err)
+	}
+	for i := range tonies {
+		tonie := &tonies[i]
+		data, err := tonie.CanonicalJSON()
+		if err != nil {
+			return fmt.Errorf("failed to marshal tonie: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO tonies (id, household_id, position, name, data) VALUES (?, ?, ?, ?, ?)`,
+			tonie.ID, householdID, i, tonie.Name, string(data)); err != nil {
+			return fmt.Errorf("failed to store tonie: %w", err)
+		}
+		for j, chapter := range tonie.Chapters {
+			if _, err := tx.Exec(`INSERT INTO chapters (tonie_id, position, id, file, title, seconds, transcoding) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				tonie.ID, j, chapter.ID, chapter.File, chapter.Title, chapter.Seconds, chapter.Transcoding); err != nil {
+				return fmt.Errorf("failed to store chapter: %w", err)
+			}
+		}
+	}
+	if err := putFetch(tx, tonieScope(householdID), fetchedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreativeTonies implements toniebox.Cache
+func (s *Store) CreativeTonies(householdID string) ([]toniebox.CreativeTonie, time.Time, error) {
+	fetchedAt, err := s.fetchedAt(tonieScope(householdID))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rows, err := s.db.Query(`SELECT data FROM tonies WHERE household_id = ? ORDER BY position`, householdID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to query tonies: %w", err)
+	}
+	defer rows.Close()
+
+	var result []toniebox.CreativeTonie
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to scan tonie: %w", err)
+		}
+		var tonie toniebox.CreativeTonie
+		if err := json.Unmarshal([]byte(data), &tonie); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to decode tonie: %w", err)
+		}
+		result = append(result, tonie)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read tonies: %w", err)
+	}
+	return result, fetchedAt, nil
+}
+
+// Chapters returns the cached chapters of a Creative-Tonie in playback order
+func (s *Store) Chapters(tonieID string) ([]toniebox.Chapter, error) {
+	rows, err := s.db.Query(`SELECT id, file, title, seconds, transcoding FROM chapters WHERE tonie_id = ? ORDER BY position`, tonieID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chapters: %w", err)
+	}
+	defer rows.Close()
+
+	var result []toniebox.Chapter
+	for rows.Next() {
+		var chapter toniebox.Chapter
+		if err := rows.Scan(&chapter.ID, &chapter.File, &chapter.Title, &chapter.Seconds, &chapter.Transcoding); err != nil {
+			return nil, fmt.Errorf("failed to scan chapter: %w", err)
+		}
+		result = append(result, chapter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chapters: %w", err)
+	}
+	return result, nil
+}
+
+// SetMeta stores a sync metadata value under key
+func (s *Store) SetMeta(key, value string) error {
+	_, err := s.db.Exec(`INSERT INTO sync_meta (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		key, value, time.Now().UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to store metadata: %w", err)
+	}
+	return nil
+}
+
+// Meta returns the sync metadata value stored under key and when it was last
+// updated, or toniebox.ErrCacheMiss if the key is unknown
+func (s *Store) Meta(key string) (string, time.Time, error) {
+	var value string
+	var updatedAt int64
+	err := s.db.QueryRow(`SELECT value, updated_at FROM sync_meta WHERE key = ?`, key).Scan(&value, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", time.Time{}, toniebox.ErrCacheMiss
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to query metadata: %w", err)
+	}
+	return value, time.Unix(0, updatedAt), nil
+}
+
+// fetchedAt returns when scope was last stored, or toniebox.ErrCacheMiss
+func (s *Store) fetchedAt(scope string) (time.Time, error) {
+	var fetchedAt int64
+	err := s.db.QueryRow(`SELECT fetched_at FROM fetches WHERE scope = ?`, scope).Scan(&fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, toniebox.ErrCacheMiss
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query fetch time: %w", err)
+	}
+	return time.Unix(0, fetchedAt), nil
+}
+
+// putFetch records when scope was stored
+func putFetch(tx *sql.Tx, scope string, fetchedAt time.Time) error {
+	_, err := tx.Exec(`INSERT INTO fetches (scope, fetched_at) VALUES (?, ?)
+		ON CONFLICT (scope) DO UPDATE SET fetched_at = excluded.fetched_at`,
+		scope, fetchedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to store fetch time: %w", err)
+	}
+	return nil
+}
+
+// tonieScope returns the fetches scope for the tonies of a household
+func tonieScope(householdID string) string {
+	return "tonies:" + householdID
+}
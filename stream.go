@@ -0,0 +1,94 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mikeboe/toniebox-api-go/audio"
+)
+
+// DefaultStreamChapterDuration is the chapter length used by UploadStream
+// when StreamOptions.ChapterDuration is not set
+const DefaultStreamChapterDuration = 15 * time.Minute
+
+// streamUpload are the upload options of the chapters of UploadStream
+var streamUpload = UploadOptions{ContentType: "audio/mpeg"}
+
+// StreamOptions configures how UploadStream splits a recording into chapters
+type StreamOptions struct {
+	// ChapterDuration is the maximum duration of a chapter. Defaults to
+	// DefaultStreamChapterDuration.
+	ChapterDuration time.Duration
+	// Title returns the title of the n-th chapter (1-based). Defaults to
+	// "<title> (Part n)".
+	Title func(n int) string
+}
+
+// chapterTitle returns the title of the n-th chapter of a stream titled title
+func (o StreamOptions) chapterTitle(title string, n int) string {
+	if o.Title != nil {
+		return o.Title(n)
+	}
+	return fmt.Sprintf("%s (Part %d)", title, n)
+}
+
+// UploadStream uploads a continuous MP3 recording, e.g. a three-hour
+// storytelling session, as consecutive chapters of at most
+// opts.ChapterDuration each. The stream is cut on MP3 frame boundaries
+// while it is read, so it may come from a pipe or a network connection and
+// is never held in memory as a whole.
+// Note: You must call Commit() after this to persist the changes.
+//
+// If an upload fails, the chapters uploaded before stay in Chapters, so
+// they can be committed or discarded with Refresh.
+// Returns an error wrapping audio.ErrUnsupportedFormat if r does not
+// contain MP3 audio.
+//
+// Example:
+//
+//	err := tonie.UploadStream("Storytelling", recording, toniebox.StreamOptions{
+//	    ChapterDuration: 20 * time.Minute,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = tonie.Commit()
+func (ct *CreativeTonie) UploadStream(title string, r io.Reader, opts StreamOptions) error {
+	return ct.UploadStreamContext(context.Background(), title, r, opts)
+}
+
+// UploadStreamContext is like UploadStream, but the upload is aborted when
+// ctx is done. The parts uploaded before stay in Chapters.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	err := tonie.UploadStreamContext(ctx, "Storytelling", recording, toniebox.StreamOptions{})
+func (ct *CreativeTonie) UploadStreamContext(ctx context.Context, title string, r io.Reader, opts StreamOptions) error {
+	if ct.requestHandler == nil {
+		return fmt.Errorf("tonie not properly initialized")
+	}
+
+	chapterDuration := opts.ChapterDuration
+	if chapterDuration <= 0 {
+		chapterDuration = DefaultStreamChapterDuration
+	}
+
+	chunker := audio.NewMP3Chunker(r, chapterDuration)
+	for n := 1; ; n++ {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read part %d: %w", n, err)
+		}
+		if _, err := ct.requestHandler.uploadFile(ctx, ct, chunk, opts.chapterTitle(title, n), streamUpload); err != nil {
+			return fmt.Errorf("failed to upload part %d: %w", n, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package toniebox
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TitleData is the data available to chapter title templates
+type TitleData struct {
+	// Index is the 1-based position of the file within the batch
+	Index int
+	// TrackNo is the track-number tag, or Index if the file has none
+	TrackNo int
+	// DiscNo is the disc-number tag, or 0 if unknown
+	DiscNo int
+	// Title is the title tag, or the file name without extension if the file has none
+	Title  string
+	Artist string
+	Album  string
+	// Filename is the base name of the file including its extension
+	Filename string
+}
+
+// titleFuncs are the helper functions available in title templates
+var titleFuncs = template.FuncMap{
+	"trimext": func(name string) string {
+		return strings.TrimSuffix(name, filepath.Ext(name))
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	"pad": func(width, n int) string {
+		return fmt.Sprintf("%0*d", width, n)
+	},
+}
+
+// ParseTitleTemplate parses a chapter title template such as
+// "{{.TrackNo}} – {{.Title}}" or "{{.Filename | trimext}}".
+// Besides the standard template functions, trimext, upper, lower, trim,
+// replace and pad are available.
+func ParseTitleTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("title").Funcs(titleFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ApplyTitleTemplate renders the chapter title of every file with tmpl and
+// stores it in BatchFile.Title
+//
+// Example:
+//
+//	tmpl, err := toniebox.ParseTitleTemplate("{{pad 2 .TrackNo}} – {{.Title}}")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = toniebox.ApplyTitleTemplate(files, tmpl)
+func ApplyTitleTemplate(files []BatchFile, tmpl *template.Template) error {
+	for i := range files {
+		file := &files[i]
+		data := TitleData{
+			Index:    i + 1,
+			TrackNo:  file.Tags.TrackNumber,
+			DiscNo:   file.Tags.DiscNumber,
+			Title:    file.Tags.Title,
+			Artist:   file.Tags.Artist,
+			Album:    file.Tags.Album,
+			Filename: filepath.Base(file.Path),
+		}
+		if data.TrackNo == 0 {
+			data.TrackNo = data.Index
+		}
+		if data.Title == "" {
+			data.Title = file.Name()
+		}
+
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return fmt.Errorf("failed to render title for %s: %w", file.Path, err)
+		}
+		file.Title = strings.TrimSpace(sb.String())
+	}
+	return nil
+}
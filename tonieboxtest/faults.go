@@ -0,0 +1,204 @@
+package tonieboxtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrConnectionReset is returned by RoundTrip for requests hit by a
+// FaultReset, mimicking a connection dropped by the peer
+var ErrConnectionReset = errors.New("tonieboxtest: connection reset by peer")
+
+// FaultKind identifies the failure injected by a Fault
+type FaultKind string
+
+const (
+	// FaultLatency delays the response by Fault.Latency
+	FaultLatency FaultKind = "latency"
+	// FaultRateLimit answers with 429 Too Many Requests and a Retry-After
+	// header of Fault.RetryAfter
+	FaultRateLimit FaultKind = "rate-limit"
+	// FaultReset drops the connection after reading half of the request
+	// body, e.g. in the middle of an upload
+	FaultReset FaultKind = "reset"
+	// FaultTokenExpiry invalidates the current access token, so the request
+	// and all later ones fail with 401 until the client logs in again
+	FaultTokenExpiry FaultKind = "token-expiry"
+)
+
+// Target restricts a Fault to one kind of request
+type Target string
+
+const (
+	// TargetAny matches all requests
+	TargetAny Target = ""
+	// TargetLogin matches token requests
+	TargetLogin Target = "login"
+	// TargetAPI matches Toniecloud API requests
+	TargetAPI Target = "api"
+	// TargetUpload matches S3 file uploads
+	TargetUpload Target = "upload"
+)
+
+// Fault is a failure injected into the requests of a Server. Faults are
+// deterministic: they hit the matching requests by count, so a test always
+// sees the same sequence of failures.
+//
+// Example, a burst of three 429 responses after the first upload:
+//
+//	server.Inject(tonieboxtest.Fault{Kind: tonieboxtest.FaultRateLimit, Target: tonieboxtest.TargetUpload, After: 1, Times: 3})
+type Fault struct {
+	Kind   FaultKind
+	Target Target
+	// Method and PathPrefix further restrict the matching requests, e.g.
+	// "PATCH" and "/v2/households"; empty matches any
+	Method     string
+	PathPrefix string
+	// After skips the first After matching requests
+	After int
+	// Times is the number of requests hit after that; zero means one, a
+	// negative value means all following requests
+	Times int
+	// Latency is the delay of FaultLatency
+	Latency time.Duration
+	// RetryAfter is announced by FaultRateLimit, in whole seconds
+	RetryAfter time.Duration
+
+	seen int
+}
+
+// matches reports whether the fault applies to r and counts the request
+func (f *Fault) matches(r *http.Request, target Target) bool {
+	if f.Target != TargetAny && f.Target != target {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	if f.PathPrefix != "" && !strings.HasPrefix(r.URL.Path, f.PathPrefix) {
+		return false
+	}
+
+	f.seen++
+	if f.seen <= f.After {
+		return false
+	}
+	times := f.Times
+	if times == 0 {
+		times = 1
+	}
+	return times < 0 || f.seen-f.After <= times
+}
+
+// Inject adds faults to the server. They are evaluated in order for every
+// request; several faults may hit the same request, e.g. latency followed
+// by a rate limit.
+func (s *Server) Inject(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range faults {
+		f := f
+		s.faults = append(s.faults, &f)
+	}
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// ExpireToken invalidates the current access token right away, as if it had
+// expired. Requests fail with 401 until the client logs in again or
+// renews its token (see toniebox.WithAutoRefresh).
+func (s *Server) ExpireToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenGeneration++
+}
+
+// Requests returns the number of requests received so far, including those
+// answered by faults
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// faultOutcome is the result of applying the faults to a request
+type faultOutcome int
+
+const (
+	// proceed serves the request normally
+	proceed faultOutcome = iota
+	// answered means a fault has written the response
+	answered
+	// reset means the connection must be dropped
+	reset
+)
+
+// injectFaults applies the faults matching r. Latency is waited out here;
+// the other faults either answer the request or ask for a reset.
+func (s *Server) injectFaults(w http.ResponseWriter, r *http.Request) faultOutcome {
+	target := requestTarget(r)
+
+	s.mu.Lock()
+	s.requests++
+	var hit []Fault
+	for _, f := range s.faults {
+		if f.matches(r, target) {
+			hit = append(hit, *f)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, f := range hit {
+		switch f.Kind {
+		case FaultLatency:
+			timer := time.NewTimer(f.Latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+			}
+		case FaultRateLimit:
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.RetryAfter/time.Second)))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded (injected)")
+			return answered
+		case FaultReset:
+			if r.Body != nil {
+				// Read half of the body, as if the connection broke mid-transfer
+				limit := r.ContentLength / 2
+				if limit <= 0 {
+					limit = 1
+				}
+				io.CopyN(io.Discard, r.Body, limit)
+			}
+			return reset
+		case FaultTokenExpiry:
+			s.ExpireToken()
+		default:
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("unknown fault kind %q", f.Kind))
+			return answered
+		}
+	}
+	return proceed
+}
+
+// requestTarget classifies r for matching faults
+func requestTarget(r *http.Request) Target {
+	switch {
+	case strings.HasSuffix(strings.Trim(r.URL.Path, "/"), "protocol/openid-connect/token"):
+		return TargetLogin
+	case strings.Contains(r.URL.Host, "s3.amazonaws.com"):
+		return TargetUpload
+	default:
+		return TargetAPI
+	}
+}
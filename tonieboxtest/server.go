@@ -0,0 +1,470 @@
+// Package tonieboxtest provides an in-process fake of the Toniecloud API.
+//
+// The fake serves the endpoints used by the toniebox package from an
+// in-memory State, typically a snapshot taken with Client.ExportState.
+// Requests never leave the process, so the full upload/apply pipeline can be
+// rehearsed against a copy of a real account before touching production:
+//
+//	snapshot, _ := client.ExportState()
+//	server := tonieboxtest.NewServer(snapshot)
+//	rehearsal := server.Client()
+//	// ... run the migration against rehearsal ...
+//	after := server.State()
+//
+// Failures such as latency, rate limiting, dropped connections and expired
+// tokens can be injected with Inject to exercise retry and resume logic.
+package tonieboxtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	toniebox "github.com/mikeboe/toniebox-api-go"
+)
+
+// Default capacity of a Creative-Tonie when the seed state does not say otherwise
+const (
+	DefaultChapterCapacity = toniebox.DefaultMaxChapters
+	DefaultSecondsCapacity = 90 * 60
+)
+
+// DefaultMaxBytes is the upload size limit reported by the fake
+const DefaultMaxBytes = 1 << 30
+
+// bytesPerSecond is used to estimate the duration of uploaded audio (128 kbit/s)
+const bytesPerSecond = 128 * 1000 / 8
+
+// Server is an in-process fake of the Toniecloud API. It implements
+// http.Handler and http.RoundTripper and is safe for concurrent use.
+type Server struct {
+	mu      sync.Mutex
+	state   toniebox.State
+	uploads map[string]int64
+	nextID  int
+
+	faults   []*Fault
+	requests int
+	// tokenGeneration is bumped when tokens expire; only the token of the
+	// current generation is accepted
+	tokenGeneration int
+	// logins numbers the refresh tokens; revoked holds those revoked
+	logins  int
+	revoked map[string]bool
+}
+
+// NewServer returns a fake seeded with a copy of state. A nil state yields
+// an account with a single, empty household.
+func NewServer(state *toniebox.State) *Server {
+	s := &Server{uploads: make(map[string]int64), revoked: make(map[string]bool)}
+	if state != nil {
+		// A JSON round trip deep-copies the state and drops internal fields
+		data, err := json.Marshal(state)
+		if err == nil {
+			err = json.Unmarshal(data, &s.state)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("tonieboxtest: failed to copy state: %v", err))
+		}
+	} else {
+		s.state.Households = []toniebox.HouseholdState{{
+			Household: toniebox.Household{ID: "household-1", Name: "Home", Access: toniebox.AccessOwner},
+		}}
+	}
+	if s.state.Me == nil {
+		s.state.Me = &toniebox.Me{Email: "test@example.com", UUID: "user-1", Verified: true, AcceptedTermsOfUse: true}
+	}
+	return s
+}
+
+// Client returns a client that talks to the fake and is already logged in.
+// Further options are applied before the fake transport is installed.
+func (s *Server) Client(opts ...toniebox.Option) *toniebox.Client {
+	client := toniebox.NewClient(append(opts, toniebox.WithTransport(s))...)
+	client.SetToken(s.token(""))
+	return client
+}
+
+// token returns a token of the current generation. Without refreshToken, a
+// new login is started with a new refresh token.
+func (s *Server) token(refreshToken string) *toniebox.JWTToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if refreshToken == "" {
+		s.logins++
+		refreshToken = fmt.Sprintf("tonieboxtest-refresh-%d", s.logins)
+	}
+	return &toniebox.JWTToken{
+		AccessToken:  fmt.Sprintf("tonieboxtest-%d", s.tokenGeneration),
+		ExpiresIn:    3600,
+		RefreshToken: refreshToken,
+		IDToken:      s.idToken(),
+		TokenType:    "Bearer",
+		Scope:        "openid",
+	}
+}
+
+// idToken returns an unsigned ID token for the account of the fake; s.mu
+// must be held
+func (s *Server) idToken() string {
+	me := s.state.Me
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	payload, _ := json.Marshal(map[string]any{
+		"sub":            me.UUID,
+		"email":          me.Email,
+		"email_verified": me.Verified,
+		"name":           strings.TrimSpace(me.FirstName + " " + me.LastName),
+		"given_name":     me.FirstName,
+		"family_name":    me.LastName,
+	})
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+// validToken reports whether the request carries a current token; s.mu must be held
+func (s *Server) validToken(r *http.Request) bool {
+	return r.Header.Get("Authorization") == fmt.Sprintf("Bearer tonieboxtest-%d", s.tokenGeneration)
+}
+
+// State returns a copy of the current state of the fake, e.g. to compare it
+// with the seed after a rehearsal
+func (s *Server) State() *toniebox.State {
+	s.mu.Lock()
+	data, err := json.Marshal(&s.state)
+	s.mu.Unlock()
+
+	var state toniebox.State
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("tonieboxtest: failed to copy state: %v", err))
+	}
+	state.ExportedAt = time.Now().UTC()
+	return &state
+}
+
+// RoundTrip implements http.RoundTripper by serving req in-process.
+// Requests hit by a FaultReset fail with ErrConnectionReset. Like a real
+// transport, requests whose context is done fail with its error, e.g. when
+// cancelled during injected latency.
+func (s *Server) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	rec := httptest.NewRecorder()
+	outcome := s.injectFaults(rec, req)
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	switch outcome {
+	case reset:
+		return nil, ErrConnectionReset
+	case proceed:
+		s.serve(rec, req)
+	}
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// ServeHTTP implements http.Handler. Requests are routed by path; the host
+// is only used to tell S3 uploads from API calls. Requests hit by a
+// FaultReset are aborted with http.ErrAbortHandler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+	switch s.injectFaults(w, r) {
+	case reset:
+		panic(http.ErrAbortHandler)
+	case proceed:
+		s.serve(w, r)
+	}
+}
+
+// serve answers a request that was not hit by a fault
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "protocol/openid-connect/token"):
+		s.handleToken(w, r)
+		return
+	case strings.HasSuffix(path, "protocol/openid-connect/revoke"):
+		s.handleRevoke(w, r)
+		return
+	case strings.Contains(r.URL.Host, "s3.amazonaws.com"):
+		s.handleS3(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.validToken(r) {
+		writeError(w, http.StatusUnauthorized, "invalid or expired bearer token")
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "v2/me":
+		s.handleMe(w, r)
+	case path == "v2/file" && r.Method == http.MethodPost:
+		s.handleFile(w)
+	case path == "v2/config" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, toniebox.Limits{
+			MaxChapters: DefaultChapterCapacity,
+			MaxSeconds:  DefaultSecondsCapacity,
+			MaxBytes:    DefaultMaxBytes,
+		})
+	case path == "v2/households" && r.Method == http.MethodGet:
+		households := make([]toniebox.Household, len(s.state.Households))
+		for i, hs := range s.state.Households {
+			households[i] = hs.Household
+		}
+		writeJSON(w, http.StatusOK, households)
+	case len(parts) >= 4 && parts[0] == "v2" && parts[1] == "households":
+		hs := s.household(parts[2])
+		if hs == nil {
+			writeError(w, http.StatusNotFound, "household not found")
+			return
+		}
+		s.handleHousehold(w, r, hs, parts[3:])
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint in tonieboxtest")
+	}
+}
+
+// handleToken issues a token for any credentials or refresh token that has
+// not been revoked
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var refreshToken string
+	if r.PostForm.Get("grant_type") == "refresh_token" {
+		refreshToken = r.PostForm.Get("refresh_token")
+		if refreshToken == "" || s.Revoked(refreshToken) {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, s.token(refreshToken))
+}
+
+// handleRevoke revokes a refresh token
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mu.Lock()
+	s.revoked[r.PostForm.Get("token")] = true
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// Revoked reports whether refreshToken was revoked, e.g. by
+// Client.Disconnect
+func (s *Server) Revoked(refreshToken string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revoked[refreshToken]
+}
+
+// handleMe serves the account
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.state.Me)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleFile hands out S3 upload credentials for a new file
+func (s *Server) handleFile(w http.ResponseWriter) {
+	key := s.newID("file")
+	s.uploads[key] = -1
+	writeJSON(w, http.StatusOK, toniebox.AmazonBean{
+		FileID: key,
+		Request: toniebox.RequestBean{
+			URL:    "https://bxn-toniecloud-prod-upload.s3.amazonaws.com/",
+			Fields: toniebox.FieldsBean{Key: key},
+		},
+	})
+}
+
+// handleS3 accepts a multipart upload for a key handed out by handleFile
+func (s *Server) handleS3(w http.ResponseWriter, r *http.Request) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || r.Method != http.MethodPost {
+		writeError(w, http.StatusBadRequest, "expected multipart POST")
+		return
+	}
+
+	var key string
+	var size int64
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		switch part.FormName() {
+		case "key":
+			data, _ := io.ReadAll(part)
+			key = string(data)
+		case "file":
+			size, _ = io.Copy(io.Discard, part)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.uploads[key]; !ok {
+		writeError(w, http.StatusForbidden, "unknown upload key")
+		return
+	}
+	s.uploads[key] = size
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleHousehold serves the resources below /v2/households/{id}
+func (s *Server) handleHousehold(w http.ResponseWriter, r *http.Request, hs *toniebox.HouseholdState, parts []string) {
+	switch {
+	case parts[0] == "creativetonies" && len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, hs.Tonies)
+	case parts[0] == "creativetonies" && len(parts) == 2:
+		tonie := findTonie(hs, parts[1])
+		if tonie == nil {
+			writeError(w, http.StatusNotFound, "creative tonie not found")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, tonie)
+		case http.MethodPatch:
+			s.patchTonie(w, r, tonie)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint in tonieboxtest")
+	}
+}
+
+// patchTonie applies a name and/or chapter update and recomputes the
+// tonie's aggregates. Uploaded files are assumed to be transcoded instantly.
+func (s *Server) patchTonie(w http.ResponseWriter, r *http.Request, tonie *toniebox.CreativeTonie) {
+	var patch struct {
+		Name     *string             `json:"name"`
+		Chapters *[]toniebox.Chapter `json:"chapters"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	chapters := tonie.Chapters
+	if patch.Chapters != nil {
+		chapters = make([]toniebox.Chapter, len(*patch.Chapters))
+		for i, ch := range *patch.Chapters {
+			if size, ok := s.uploads[ch.File]; ok && size >= 0 && ch.Seconds == 0 {
+				ch.Seconds = float64(size) / bytesPerSecond
+			}
+			ch.Transcoding = false
+			chapters[i] = ch
+		}
+	}
+
+	chapterCapacity := tonie.ChaptersPresent + tonie.ChaptersRemaining
+	if chapterCapacity == 0 {
+		chapterCapacity = DefaultChapterCapacity
+	}
+	secondsCapacity := tonie.SecondsPresent + tonie.SecondsRemaining
+	if secondsCapacity == 0 {
+		secondsCapacity = DefaultSecondsCapacity
+	}
+	var seconds float64
+	for _, ch := range chapters {
+		seconds += ch.Seconds
+	}
+	if len(chapters) > chapterCapacity {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("%d chapters exceed the capacity of %d", len(chapters), chapterCapacity))
+		return
+	}
+	if seconds > secondsCapacity {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("%.0f seconds exceed the capacity of %.0f", seconds, secondsCapacity))
+		return
+	}
+
+	if patch.Name != nil {
+		tonie.Name = *patch.Name
+	}
+	tonie.Chapters = chapters
+	tonie.ChaptersPresent = len(chapters)
+	tonie.ChaptersRemaining = chapterCapacity - len(chapters)
+	tonie.SecondsPresent = seconds
+	tonie.SecondsRemaining = secondsCapacity - seconds
+	writeJSON(w, http.StatusOK, tonie)
+}
+
+// household returns the household with the given ID
+func (s *Server) household(id string) *toniebox.HouseholdState {
+	for i := range s.state.Households {
+		if s.state.Households[i].Household.ID == id {
+			return &s.state.Households[i]
+		}
+	}
+	return nil
+}
+
+// findTonie returns the Creative-Tonie with the given ID
+func findTonie(hs *toniebox.HouseholdState, id string) *toniebox.CreativeTonie {
+	for i := range hs.Tonies {
+		if hs.Tonies[i].ID == id {
+			return &hs.Tonies[i]
+		}
+	}
+	return nil
+}
+
+// newID returns a new unique ID with the given prefix
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", prefix, s.nextID)
+}
+
+// writeJSON writes v as JSON with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an API-style JSON error
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,47 @@
+package toniebox
+
+import (
+	"time"
+)
+
+// Track is a chapter together with its position on the figurine
+type Track struct {
+	// Number is the 1-based chapter number
+	Number    int
+	ChapterID string
+	Title     string
+	// Start is the offset of the chapter from the beginning of the tonie
+	Start time.Duration
+	// Duration is the length of the chapter
+	Duration time.Duration
+}
+
+// End returns the offset at which the chapter ends
+func (t Track) End() time.Duration {
+	return t.Start + t.Duration
+}
+
+// Tracklist computes the start offset of every chapter on this Creative-Tonie
+// from the cumulative chapter durations.
+//
+// Example:
+//
+//	for _, track := range tonie.Tracklist() {
+//	    fmt.Printf("%2d. %s starts at minute %d\n", track.Number, track.Title, int(track.Start.Minutes()))
+//	}
+func (ct *CreativeTonie) Tracklist() []Track {
+	tracks := make([]Track, 0, len(ct.Chapters))
+	var offset time.Duration
+	for i, chapter := range ct.Chapters {
+		duration := time.Duration(chapter.Seconds * float64(time.Second))
+		tracks = append(tracks, Track{
+			Number:    i + 1,
+			ChapterID: chapter.ID,
+			Title:     chapter.Title,
+			Start:     offset,
+			Duration:  duration,
+		})
+		offset += duration
+	}
+	return tracks
+}
@@ -0,0 +1,55 @@
+package toniebox
+
+// TranscodingError is an error code reported by the Toniebox cloud in
+// CreativeTonie.TranscodingErrors when an uploaded file could not be converted
+type TranscodingError string
+
+// Known transcoding error codes
+const (
+	// TranscodingUnsupportedCodec means the audio format is not supported
+	TranscodingUnsupportedCodec TranscodingError = "unsupportedCodec"
+	// TranscodingTooLong means the file exceeds the remaining tonie capacity
+	TranscodingTooLong TranscodingError = "tooLong"
+	// TranscodingCorruptFile means the file could not be decoded
+	TranscodingCorruptFile TranscodingError = "corruptFile"
+)
+
+// transcodingErrorInfo holds the description and remediation hint of a known code
+var transcodingErrorInfo = map[TranscodingError]struct {
+	description string
+	hint        string
+}{
+	TranscodingUnsupportedCodec: {
+		description: "the audio format is not supported",
+		hint:        "convert the file to MP3, M4A, OGG, FLAC or WAV and upload it again",
+	},
+	TranscodingTooLong: {
+		description: "the file is longer than the remaining capacity",
+		hint:        "delete chapters to free up space or split the file into shorter parts",
+	},
+	TranscodingCorruptFile: {
+		description: "the file is damaged and could not be decoded",
+		hint:        "check that the file plays locally, then re-export or re-download it",
+	},
+}
+
+// Known reports whether e is one of the known transcoding error codes
+func (e TranscodingError) Known() bool {
+	_, ok := transcodingErrorInfo[e]
+	return ok
+}
+
+// Description returns a human-readable description of the error.
+// Unknown codes are returned unchanged.
+func (e TranscodingError) Description() string {
+	if info, ok := transcodingErrorInfo[e]; ok {
+		return info.description
+	}
+	return string(e)
+}
+
+// Remediation returns a hint on how to resolve the error, or an empty string
+// for unknown codes
+func (e TranscodingError) Remediation() string {
+	return transcodingErrorInfo[e].hint
+}
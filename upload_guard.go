@@ -0,0 +1,170 @@
+package toniebox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrFileTypeNotAllowed is returned when a file does not match the types
+// allowed with WithAllowedUploadTypes
+var ErrFileTypeNotAllowed = errors.New("file type not allowed")
+
+// WithMaxUploadSize rejects files larger than n bytes with ErrFileTooLarge
+// before anything is uploaded, e.g. so that a misconfigured sync folder
+// cannot push a video file into the upload pipeline. The size of files and
+// in-memory readers is checked up front; other readers are aborted once
+// they exceed the limit. The limit reported by GetLimits applies as well.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithMaxUploadSize(512 << 20))
+func WithMaxUploadSize(n int64) Option {
+	return func(rh *requestHandler) {
+		rh.maxUploadSize = n
+	}
+}
+
+// WithAllowedUploadTypes restricts uploads to the given file types, which
+// are extensions such as ".mp3" or MIME types such as "audio/mpeg" or
+// "audio/*". Other files are rejected with ErrFileTypeNotAllowed.
+// If extensions are given, the file name must have one of them; readers
+// without a file name (see UploadOptions.Filename) are not checked for it.
+// If MIME types are given, the content type of the upload, detected from
+// the data unless set in UploadOptions, must match one of them.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithAllowedUploadTypes(".mp3", ".m4a", "audio/*"))
+func WithAllowedUploadTypes(types ...string) Option {
+	return func(rh *requestHandler) {
+		rh.allowedExtensions, rh.allowedContentTypes = nil, nil
+		for _, t := range types {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if strings.Contains(t, "/") {
+				rh.allowedContentTypes = append(rh.allowedContentTypes, t)
+			} else if t != "" {
+				if !strings.HasPrefix(t, ".") {
+					t = "." + t
+				}
+				rh.allowedExtensions = append(rh.allowedExtensions, t)
+			}
+		}
+	}
+}
+
+// maxUploadBytes returns the smallest of the configured and the reported
+// upload size limit, or 0 if there is none
+func (rh *requestHandler) maxUploadBytes() int64 {
+	limit := rh.maxUploadSize
+	if reported := rh.serviceLimits().MaxBytes; reported > 0 && (limit <= 0 || reported < limit) {
+		limit = reported
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// checkFileSize returns ErrFileTooLarge if a file of size bytes exceeds the
+// upload size limit. Unknown sizes are not checked.
+func (rh *requestHandler) checkFileSize(size int64) error {
+	if limit := rh.maxUploadBytes(); limit > 0 && size > limit {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d", ErrFileTooLarge, size, limit)
+	}
+	return nil
+}
+
+// limitUpload returns r, failing with ErrFileTooLarge once more bytes than
+// the upload size limit are read
+func (rh *requestHandler) limitUpload(r io.Reader) io.Reader {
+	limit := rh.maxUploadBytes()
+	if limit <= 0 {
+		return r
+	}
+	return &sizeLimitedReader{r: r, remaining: limit, limit: limit}
+}
+
+// sizeLimitedReader fails with ErrFileTooLarge once more than limit bytes
+// have been read
+type sizeLimitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+// Read implements io.Reader
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, l.limit)
+	}
+	// Read one byte more than allowed to tell a file of exactly limit bytes
+	// from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, l.limit)
+	}
+	return n, err
+}
+
+// uploadName returns the name of the uploaded file, or "" if unknown
+func uploadName(r io.Reader, opts UploadOptions) string {
+	if opts.Filename != "" {
+		return opts.Filename
+	}
+	if file, ok := r.(*os.File); ok {
+		return file.Name()
+	}
+	return ""
+}
+
+// checkUploadType returns ErrFileTypeNotAllowed if the upload does not
+// match the allowed types
+func (rh *requestHandler) checkUploadType(name, contentType string) error {
+	if len(rh.allowedExtensions) > 0 && name != "" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if !containsString(rh.allowedExtensions, ext) {
+			return fmt.Errorf("%w: %q", ErrFileTypeNotAllowed, filepath.Base(name))
+		}
+	}
+	if len(rh.allowedContentTypes) > 0 && !contentTypeAllowed(rh.allowedContentTypes, contentType) {
+		return fmt.Errorf("%w: content type %s", ErrFileTypeNotAllowed, contentType)
+	}
+	return nil
+}
+
+// contentTypeAllowed reports whether contentType matches one of patterns,
+// which may end in "/*"
+func contentTypeAllowed(patterns []string, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(contentType, prefix) {
+				return true
+			}
+		} else if pattern == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// UploadOptions controls how an audio file is sent to the S3 bucket of the
+// Toniecloud
+type UploadOptions struct {
+	// Filename is the file name stored with the upload. Defaults to the
+	// upload key assigned by the API. Some transcoder paths rely on its
+	// extension, e.g. "story.m4a".
+	Filename string
+	// ContentType is the MIME type of the audio, e.g. "audio/mpeg". By
+	// default it is detected from the data, or from the extension of
+	// Filename (see audio.DetectContentType).
+	ContentType string
+	// Position is where the new chapter is inserted. The zero value
+	// PositionLast appends it after all existing chapters.
+	Position Position
+}
+
+// Position is the place at which an upload inserts its chapter
+type Position int
+
+const (
+	// PositionLast appends the new chapter after all existing chapters
+	PositionLast Position = 0
+	// PositionFirst inserts the new chapter at the top, e.g. for
+	// newest-first podcast feeds
+	PositionFirst Position = 1
+)
+
+// PositionAt inserts the new chapter at the zero-based index, as returned by
+// ChapterPosition. An index past the end appends.
+//
+// Example:
+//
+//	_, err := tonie.UploadFileWith("Part 2", "/path/to/part2.mp3", toniebox.UploadOptions{
+//	    Position: toniebox.PositionAt(1),
+//	})
+func PositionAt(index int) Position {
+	if index < 0 {
+		return PositionLast
+	}
+	return Position(index + 1)
+}
+
+// UploadFileWith uploads an audio file like UploadFile with the given
+// options and returns a copy of the new chapter, so that callers can refer
+// to it by ID instead of relying on where it was inserted.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Example:
+//
+//	chapter, err := tonie.UploadFileWith("Episode 42", "/podcasts/42.m4a", toniebox.UploadOptions{
+//	    Filename: "42.m4a",
+//	    Position: toniebox.PositionFirst,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Uploaded chapter %s\n", chapter.ID)
+func (ct *CreativeTonie) UploadFileWith(title, filePath string, opts UploadOptions) (*Chapter, error) {
+	return ct.UploadFileWithContext(context.Background(), title, filePath, opts)
+}
+
+// UploadFileWithContext is like UploadFileWith, but the upload is aborted
+// when ctx is done.
+// Note: You must call Commit() after this to persist the changes.
+func (ct *CreativeTonie) UploadFileWithContext(ctx context.Context, title, filePath string, opts UploadOptions) (*Chapter, error) {
+	chapterID, err := ct.uploadPath(ctx, title, filePath, filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ct.uploadedChapter(chapterID)
+}
+
+// UploadReaderWith uploads audio data read from r like UploadReader with
+// the given options and returns a copy of the new chapter.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Example:
+//
+//	chapter, err := tonie.UploadReaderWith("My Story", r, toniebox.UploadOptions{
+//	    Filename:    "story.mp3",
+//	    ContentType: "audio/mpeg",
+//	})
+func (ct *CreativeTonie) UploadReaderWith(title string, r io.Reader, opts UploadOptions) (*Chapter, error) {
+	return ct.UploadReaderWithContext(context.Background(), title, r, opts)
+}
+
+// UploadReaderWithContext is like UploadReaderWith, but the upload is
+// aborted when ctx is done.
+// Note: You must call Commit() after this to persist the changes.
+func (ct *CreativeTonie) UploadReaderWithContext(ctx context.Context, title string, r io.Reader, opts UploadOptions) (*Chapter, error) {
+	if ct.requestHandler == nil {
+		return nil, fmt.Errorf("tonie not properly initialized")
+	}
+	chapterID, err := ct.requestHandler.uploadFile(ctx, ct, r, title, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ct.uploadedChapter(chapterID)
+}
+
+// uploadPath uploads the file at path and records sourcePath as the origin
+// of the new chapter, whose ID is returned. They differ when path is a
+// processed copy of the source.
+func (ct *CreativeTonie) uploadPath(ctx context.Context, title, path, sourcePath string, opts UploadOptions) (string, error) {
+	if ct.requestHandler == nil {
+		return "", fmt.Errorf("tonie not properly initialized")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	chapterID, err := ct.requestHandler.uploadFile(ctx, ct, file, title, opts)
+	if err != nil {
+		return "", err
+	}
+	ct.requestHandler.recordUpload(ct, chapterID, title, sourcePath)
+	return chapterID, nil
+}
+
+// uploadedChapter returns a copy of the chapter just uploaded
+func (ct *CreativeTonie) uploadedChapter(chapterID string) (*Chapter, error) {
+	i := ct.ChapterPosition(chapterID)
+	if i < 0 {
+		return nil, fmt.Errorf("uploaded chapter %s not found", chapterID)
+	}
+	chapter := ct.Chapters[i]
+	return &chapter, nil
+}
+
+// insertChapter inserts chapter at position, appending if it is past the end
+func (ct *CreativeTonie) insertChapter(chapter Chapter, position Position) {
+	i := int(position) - 1
+	if i < 0 || i >= len(ct.Chapters) {
+		ct.Chapters = append(ct.Chapters, chapter)
+		return
+	}
+	chapters := make([]Chapter, 0, len(ct.Chapters)+1)
+	chapters = append(chapters, ct.Chapters[:i]...)
+	chapters = append(chapters, chapter)
+	ct.Chapters = append(chapters, ct.Chapters[i:]...)
+}
+
+// quoteEscaper escapes a file name for the Content-Disposition header
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
@@ -0,0 +1,50 @@
+package toniebox
+
+import "time"
+
+// UploadRecord describes a chapter created by UploadFile or UploadFileWith
+type UploadRecord struct {
+	Time        time.Time
+	HouseholdID string
+	TonieID     string
+	ChapterID   string
+	Title       string
+	// SourcePath is the local file the chapter was uploaded from
+	SourcePath string
+}
+
+// UploadRecorder persists local-only information about uploaded chapters,
+// such as their source file. Records are written right after the upload, so
+// they may refer to chapters that are never committed.
+type UploadRecorder interface {
+	RecordUpload(record UploadRecord) error
+}
+
+// WithUploadRecorder reports every file upload to recorder, e.g. the local
+// state store, to answer "where did this chapter come from?" later on.
+// Failures to record are ignored so that they never fail an upload.
+//
+// Example:
+//
+//	st, _ := store.Open("toniebox.db")
+//	client := toniebox.NewClient(toniebox.WithUploadRecorder(st))
+func WithUploadRecorder(recorder UploadRecorder) Option {
+	return func(rh *requestHandler) {
+		rh.uploads = recorder
+	}
+}
+
+// recordUpload reports a chapter of tonie as uploaded from path
+func (rh *requestHandler) recordUpload(tonie *CreativeTonie, chapterID, title, path string) {
+	if rh.uploads == nil {
+		return
+	}
+	rh.uploads.RecordUpload(UploadRecord{
+		Time:        time.Now(),
+		HouseholdID: tonie.HouseholdID,
+		TonieID:     tonie.ID,
+		ChapterID:   chapterID,
+		Title:       title,
+		SourcePath:  path,
+	})
+}
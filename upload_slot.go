@@ -0,0 +1,99 @@
+package toniebox
+
+import (
+	"context"
+	"fmt"
+)
+
+// RequestUploadSlot requests credentials for uploading a single audio file
+// to the S3 bucket of the Toniecloud. It is the first step of UploadFile and
+// lets advanced callers upload the file themselves, e.g. with their own S3
+// client or by handing the presigned form over to another service.
+// Once the upload succeeded, register the file with AddUploadedChapter.
+//
+// Returns ErrVerificationRequired if the account may not upload yet.
+//
+// Example:
+//
+//	slot, err := client.RequestUploadSlot()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	// POST the file to slot.Request.URL with slot.Request.Fields ...
+//	err = tonie.AddUploadedChapter("My Story", slot)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = tonie.Commit()
+func (c *Client) RequestUploadSlot() (*AmazonBean, error) {
+	return c.RequestUploadSlotContext(context.Background())
+}
+
+// RequestUploadSlotContext is like RequestUploadSlot, but the requests are
+// aborted when ctx is done.
+func (c *Client) RequestUploadSlotContext(ctx context.Context) (*AmazonBean, error) {
+	if err := c.requestHandler.checkUploadAllowed(ctx); err != nil {
+		return nil, err
+	}
+	return c.requestHandler.requestUploadSlot(ctx)
+}
+
+// AddUploadedChapter adds a file uploaded through a slot from
+// RequestUploadSlot to this Creative-Tonie as a new chapter. Nothing is
+// uploaded; the AfterUpload hooks run as for UploadFile.
+// Note: You must call Commit() after this to persist the changes.
+//
+// Example:
+//
+//	err := tonie.AddUploadedChapter("My Story", slot)
+func (ct *CreativeTonie) AddUploadedChapter(title string, slot *AmazonBean) error {
+	return ct.AddUploadedChapterAt(title, slot, PositionLast)
+}
+
+// AddUploadedChapterAt adds an uploaded file like AddUploadedChapter but
+// inserts the new chapter at the given position, see UploadOptions.Position.
+// Note: You must call Commit() after this to persist the changes.
+func (ct *CreativeTonie) AddUploadedChapterAt(title string, slot *AmazonBean, position Position) error {
+	if ct.requestHandler == nil {
+		return fmt.Errorf("tonie not properly initialized")
+	}
+	if slot == nil || slot.FileID == "" || slot.Request.Fields.Key == "" {
+		return fmt.Errorf("upload slot has no file")
+	}
+	if err := ct.checkChapterLimit(); err != nil {
+		return err
+	}
+	ct.requestHandler.addUploadedChapter(ct, slot, title, position)
+	return nil
+}
+
+// requestUploadSlot requests upload credentials from the Toniebox API
+func (rh *requestHandler) requestUploadSlot(ctx context.Context) (*AmazonBean, error) {
+	var slot AmazonBean
+	err := rh.executeJSON(&apiRequest{
+		ctx:         ctx,
+		method:      "POST",
+		url:         fileUpload,
+		op:          OperationUpload,
+		name:        "upload request",
+		body:        []byte(`{"headers":{}}`),
+		contentType: contentTypeJSON,
+	}, &slot)
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// addUploadedChapter inserts the chapter for an uploaded file at position
+// and returns it
+func (rh *requestHandler) addUploadedChapter(tonie *CreativeTonie, slot *AmazonBean, title string, position Position) Chapter {
+	chapter := Chapter{
+		ID:    slot.Request.Fields.Key,
+		File:  slot.FileID,
+		Title: title,
+	}
+	tonie.insertChapter(chapter, position)
+	rh.afterUpload(tonie, chapter)
+	return chapter
+}
@@ -0,0 +1,71 @@
+package toniebox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is returned by Commit when the local state of a
+// Creative-Tonie is inconsistent. Nothing is sent to the API in that case.
+type ValidationError struct {
+	TonieID  string
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid state of tonie %s: %s", e.TonieID, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks the chapters of this Creative-Tonie for inconsistencies
+// that would corrupt it on Commit: empty or duplicate chapter IDs, chapters
+// without a file, and more chapters or recording time than the tonie can
+// hold.
+// It returns a *ValidationError describing all problems found.
+func (ct *CreativeTonie) Validate() error {
+	var problems []string
+	seen := make(map[string]int, len(ct.Chapters))
+	for i, chapter := range ct.Chapters {
+		switch first, dup := seen[chapter.ID]; {
+		case chapter.ID == "":
+			problems = append(problems, fmt.Sprintf("chapter %d has no ID", i+1))
+		case dup:
+			problems = append(problems, fmt.Sprintf("chapters %d and %d share ID %s", first+1, i+1, chapter.ID))
+		default:
+			seen[chapter.ID] = i
+		}
+		if chapter.File == "" {
+			problems = append(problems, fmt.Sprintf("chapter %d (%q) has no file", i+1, chapter.Title))
+		}
+	}
+
+	// ChaptersPresent and ChaptersRemaining describe the last known server
+	// state; together they are the tonie's chapter capacity
+	if capacity := ct.ChaptersPresent + ct.ChaptersRemaining; capacity > 0 && len(ct.Chapters) > capacity {
+		problems = append(problems, fmt.Sprintf("%d chapters exceed the capacity of %d", len(ct.Chapters), capacity))
+	} else if limit := ct.MaxChapters(); len(ct.Chapters) > limit {
+		problems = append(problems, fmt.Sprintf("%d chapters exceed the limit of %d", len(ct.Chapters), limit))
+	}
+	if limit := ct.requestHandler.serviceLimits().MaxSeconds; limit > 0 {
+		var seconds float64
+		for _, chapter := range ct.Chapters {
+			seconds += chapter.Seconds
+		}
+		if seconds > float64(limit) {
+			problems = append(problems, fmt.Sprintf("%.0f seconds exceed the limit of %d", seconds, limit))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{TonieID: ct.ID, Problems: problems}
+	}
+	return nil
+}
+
+// normalize prepares the chapters for sending: an empty chapter list is sent
+// as [] rather than null
+func (ct *CreativeTonie) normalize() {
+	if ct.Chapters == nil {
+		ct.Chapters = []Chapter{}
+	}
+}
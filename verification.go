@@ -0,0 +1,74 @@
+package toniebox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVerificationRequired is returned by uploads when the account must verify
+// its email address before it may upload content, see WaitForVerification.
+var ErrVerificationRequired = errors.New("email verification required to upload")
+
+// UploadAllowed reports whether the account may upload content
+func (m *Me) UploadAllowed() bool {
+	return m.Verified || !m.RequiresVerificationToUpload
+}
+
+// WaitForVerification polls the account every interval until its email
+// address has been verified or ctx is done. The client must be logged in.
+// Afterwards uploads no longer fail with ErrVerificationRequired.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+//	defer cancel()
+//	err := client.WaitForVerification(ctx, 15*time.Second)
+func (c *Client) WaitForVerification(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		account, err := c.GetMeContext(ctx)
+		if err != nil {
+			return err
+		}
+		if account.Verified {
+			c.requestHandler.resetVerification()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkUploadAllowed returns ErrVerificationRequired if the account may not
+// upload yet. A successful check is remembered until the token changes.
+func (rh *requestHandler) checkUploadAllowed(ctx context.Context) error {
+	rh.verifiedMu.Lock()
+	defer rh.verifiedMu.Unlock()
+	if rh.verified {
+		return nil
+	}
+
+	me, err := rh.getMe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check verification status: %w", err)
+	}
+	if !me.UploadAllowed() {
+		return ErrVerificationRequired
+	}
+	rh.verified = true
+	return nil
+}
+
+// resetVerification forgets the result of checkUploadAllowed, e.g. after the
+// account changed
+func (rh *requestHandler) resetVerification() {
+	rh.verifiedMu.Lock()
+	rh.verified = false
+	rh.verifiedMu.Unlock()
+}
@@ -0,0 +1,225 @@
+package toniebox
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WarningKind classifies a Warning
+type WarningKind string
+
+const (
+	// WarningDeprecated is reported when the API marks an endpoint as
+	// deprecated or scheduled for removal (Deprecation, Sunset or Warning
+	// response headers)
+	WarningDeprecated WarningKind = "deprecated"
+	// WarningSlowResponse is reported when a round trip took longer than the
+	// slow response threshold
+	WarningSlowResponse WarningKind = "slow_response"
+	// WarningNearCapacity is reported when a Creative-Tonie is almost full
+	WarningNearCapacity WarningKind = "near_capacity"
+)
+
+const (
+	// DefaultSlowResponseThreshold is the duration after which a round trip
+	// is reported as slow
+	DefaultSlowResponseThreshold = 10 * time.Second
+	// DefaultNearCapacity is the share of free recording time or chapters
+	// below which a Creative-Tonie is reported as almost full
+	DefaultNearCapacity = 0.1
+)
+
+// Warning describes a non-fatal condition: the operation succeeded, but
+// something deserves attention
+type Warning struct {
+	Kind WarningKind
+	// Message is a human-readable description
+	Message string
+	// Time is when the condition was observed
+	Time time.Time
+	// Operation and URL identify the request, if the warning concerns one
+	Operation Operation
+	URL       string
+	// TonieID identifies the Creative-Tonie, if the warning concerns one
+	TonieID string
+}
+
+// String implements fmt.Stringer
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+}
+
+// WarningHandler receives the warnings of a client. HandleWarning is called
+// synchronously from the goroutine that made the request, so it should
+// return quickly.
+type WarningHandler interface {
+	HandleWarning(w Warning)
+}
+
+// WarningFunc adapts a function to the WarningHandler interface
+type WarningFunc func(w Warning)
+
+// HandleWarning implements WarningHandler
+func (f WarningFunc) HandleWarning(w Warning) {
+	f(w)
+}
+
+// warnings holds the warning configuration of a client
+type warnings struct {
+	handler      WarningHandler
+	slowResponse time.Duration
+	nearCapacity float64
+
+	// deprecations remembers the deprecation notices already reported, so
+	// that every notice is reported only once per endpoint
+	deprecations sync.Map
+}
+
+// WithWarnings reports non-fatal conditions to handler: deprecation notices
+// of the API, slow responses and almost full Creative-Tonies. Long-running
+// automation can log them or forward them to a monitoring system instead of
+// only noticing once something fails.
+//
+// Example:
+//
+//	client := toniebox.NewClient(toniebox.WithWarnings(toniebox.WarningFunc(func(w toniebox.Warning) {
+//	    log.Printf("warning: %s", w)
+//	})))
+//
+// To consume warnings from a channel, forward them without blocking:
+//
+//	ch := make(chan toniebox.Warning, 16)
+//	client := toniebox.NewClient(toniebox.WithWarnings(toniebox.WarningFunc(func(w toniebox.Warning) {
+//	    select {
+//	    case ch <- w:
+//	    default:
+//	    }
+//	})))
+func WithWarnings(handler WarningHandler) Option {
+	return func(rh *requestHandler) {
+		rh.warnings().handler = handler
+	}
+}
+
+// WithWarningThresholds sets when warnings are reported: round trips taking
+// longer than slowResponse and Creative-Tonies with less than nearCapacity
+// (a share between 0 and 1) of their recording time or chapters left.
+// Zero values keep the defaults; negative values disable the warning.
+//
+// Example:
+//
+//	client := toniebox.NewClient(
+//	    toniebox.WithWarnings(handler),
+//	    toniebox.WithWarningThresholds(3*time.Second, 0.2),
+//	)
+func WithWarningThresholds(slowResponse time.Duration, nearCapacity float64) Option {
+	return func(rh *requestHandler) {
+		w := rh.warnings()
+		if slowResponse != 0 {
+			w.slowResponse = slowResponse
+		}
+		if nearCapacity != 0 {
+			w.nearCapacity = nearCapacity
+		}
+	}
+}
+
+// warnings returns the warning configuration, creating it with the defaults
+// on first use
+func (rh *requestHandler) warnings() *warnings {
+	if rh.warn == nil {
+		rh.warn = &warnings{
+			slowResponse: DefaultSlowResponseThreshold,
+			nearCapacity: DefaultNearCapacity,
+		}
+	}
+	return rh.warn
+}
+
+// warningsEnabled reports whether a warning handler is registered
+func (rh *requestHandler) warningsEnabled() bool {
+	return rh.warn != nil && rh.warn.handler != nil
+}
+
+// warnResponse reports deprecation notices and slow round trips
+func (rh *requestHandler) warnResponse(req *http.Request, op Operation, resp *http.Response, duration time.Duration) {
+	if !rh.warningsEnabled() {
+		return
+	}
+	w := rh.warn
+	url := req.URL.Redacted()
+
+	if w.slowResponse > 0 && duration > w.slowResponse {
+		w.handler.HandleWarning(Warning{
+			Kind:      WarningSlowResponse,
+			Message:   fmt.Sprintf("%s %s took %v", req.Method, req.URL.Host+req.URL.Path, duration.Round(time.Millisecond)),
+			Time:      time.Now(),
+			Operation: op,
+			URL:       url,
+		})
+	}
+
+	if resp == nil {
+		return
+	}
+	if notice := deprecationNotice(resp.Header); notice != "" {
+		key := req.Method + " " + req.URL.Host + req.URL.Path + " " + notice
+		if _, seen := w.deprecations.LoadOrStore(key, struct{}{}); !seen {
+			w.handler.HandleWarning(Warning{
+				Kind:      WarningDeprecated,
+				Message:   fmt.Sprintf("%s %s is deprecated: %s", req.Method, req.URL.Host+req.URL.Path, notice),
+				Time:      time.Now(),
+				Operation: op,
+				URL:       url,
+			})
+		}
+	}
+}
+
+// deprecationNotice summarizes the deprecation headers of a response, or
+// returns "" if there are none
+func deprecationNotice(header http.Header) string {
+	var parts []string
+	if v := header.Get("Deprecation"); v != "" {
+		parts = append(parts, "deprecation "+v)
+	}
+	if v := header.Get("Sunset"); v != "" {
+		parts = append(parts, "sunset "+v)
+	}
+	for _, v := range header.Values("Warning") {
+		// 299 is the code for persistent miscellaneous warnings, which APIs
+		// use for deprecations
+		if strings.HasPrefix(v, "299 ") {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// warnCapacity reports tonie if it is almost full
+func (rh *requestHandler) warnCapacity(tonie *CreativeTonie) {
+	if !rh.warningsEnabled() || rh.warn.nearCapacity <= 0 {
+		return
+	}
+	var reasons []string
+	seconds := tonie.SecondsPresent + tonie.SecondsRemaining
+	if seconds > 0 && tonie.SecondsRemaining < seconds*rh.warn.nearCapacity {
+		reasons = append(reasons, fmt.Sprintf("%.0f of %.0f minutes left", tonie.SecondsRemaining/60, seconds/60))
+	}
+	chapters := tonie.ChaptersPresent + tonie.ChaptersRemaining
+	if chapters > 0 && float64(tonie.ChaptersRemaining) < float64(chapters)*rh.warn.nearCapacity {
+		reasons = append(reasons, fmt.Sprintf("%d of %d chapters left", tonie.ChaptersRemaining, chapters))
+	}
+	if len(reasons) == 0 {
+		return
+	}
+	rh.warn.handler.HandleWarning(Warning{
+		Kind:    WarningNearCapacity,
+		Message: fmt.Sprintf("tonie %q is almost full: %s", tonie.Name, strings.Join(reasons, ", ")),
+		Time:    time.Now(),
+		TonieID: tonie.ID,
+	})
+}
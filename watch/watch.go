@@ -0,0 +1,381 @@
+// Package watch uploads audio files dropped into local folders to
+// Creative-Tonies as soon as they are complete, e.g. a shared "Dropbox
+// folder" for grandma's recordings.
+//
+// Each watched folder is mapped to a tonie. New files are uploaded once no
+// file system events arrived for the debounce period and their size and
+// modification time stayed unchanged for the stability period, so files that
+// are still being copied are never uploaded half-written:
+//
+//	w := &watch.Watcher{
+//	    Client: client,
+//	    Mappings: []watch.Mapping{
+//	        {Dir: "/srv/dropbox/grandma", HouseholdID: householdID, TonieID: tonieID},
+//	    },
+//	}
+//	err := w.Run(ctx)
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	toniebox "github.com/mikeboe/toniebox-api-go"
+	"github.com/mikeboe/toniebox-api-go/dirsync"
+	"github.com/mikeboe/toniebox-api-go/eventlog"
+	"github.com/mikeboe/toniebox-api-go/window"
+)
+
+// Default timings of a Watcher
+const (
+	DefaultDebounce  = 2 * time.Second
+	DefaultStableFor = 5 * time.Second
+)
+
+// Mapping maps a local folder to a Creative-Tonie
+type Mapping struct {
+	// Dir is the watched folder (non-recursively)
+	Dir         string `json:"dir"`
+	HouseholdID string `json:"householdId"`
+	TonieID     string `json:"tonieId"`
+	// Extensions restricts the files uploaded; defaults to
+	// toniebox.DefaultAudioExtensions
+	Extensions []string `json:"extensions,omitempty"`
+	// Prefix is prepended to the chapter titles, which are derived from the
+	// file names
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// CapacityThreshold defines when a Creative-Tonie counts as almost full.
+// Zero fields are not checked.
+type CapacityThreshold struct {
+	// Remaining is the minimum recording time that should be left
+	Remaining time.Duration `json:"remaining,omitempty"`
+	// Chapters is the minimum number of chapters that should be left
+	Chapters int `json:"chapters,omitempty"`
+}
+
+// enabled reports whether any threshold is set
+func (t CapacityThreshold) enabled() bool {
+	return t.Remaining > 0 || t.Chapters > 0
+}
+
+// below reports whether tonie has less capacity left than t
+func (t CapacityThreshold) below(tonie *toniebox.CreativeTonie) bool {
+	remaining := time.Duration(tonie.SecondsRemaining * float64(time.Second))
+	return (t.Remaining > 0 && remaining < t.Remaining) ||
+		(t.Chapters > 0 && tonie.ChaptersRemaining < t.Chapters)
+}
+
+// Watcher uploads new files in mapped folders. Files ignored by a folder's
+// .tonieignore file are skipped, as are files whose chapter title already
+// exists on the tonie.
+type Watcher struct {
+	Client   *toniebox.Client
+	Mappings []Mapping
+	// Debounce is the quiet period after the last event for a file;
+	// defaults to DefaultDebounce
+	Debounce time.Duration
+	// StableFor is how long size and modification time of a file must stay
+	// unchanged before it is uploaded; defaults to DefaultStableFor
+	StableFor time.Duration
+	// Windows restrict uploads to certain hours; nil allows any time
+	Windows window.Windows
+	// Events receives a structured event for every upload; nil disables it
+	Events eventlog.Logger
+	// OnUpload is called after each upload attempt, e.g. for notifications
+	OnUpload func(mapping Mapping, path string, err error)
+	// LowCapacity is checked after every upload; if the tonie dropped below
+	// it, a "low_capacity" event is recorded and OnLowCapacity is called,
+	// so the household can make room before the next upload fails. The
+	// zero value disables the check.
+	LowCapacity CapacityThreshold
+	// OnLowCapacity is called when a tonie drops below LowCapacity. It is
+	// called again for the same tonie only after it had enough capacity in
+	// between.
+	OnLowCapacity func(mapping Mapping, tonie *toniebox.CreativeTonie)
+
+	// lowCapacity holds the IDs of the tonies below LowCapacity; it is only
+	// used by the uploader goroutine
+	lowCapacity map[string]bool
+}
+
+// pendingFile is a file waiting to become stable
+type pendingFile struct {
+	mapping     *Mapping
+	lastEvent   time.Time
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+}
+
+// job is a stable file handed to the uploader
+type job struct {
+	mapping *Mapping
+	path    string
+}
+
+// Run watches the mapped folders until ctx is done or the client is closed.
+// Only files created or modified while Run is active are uploaded; existing
+// files are left alone.
+// Upload failures are reported via Events and OnUpload and do not stop Run.
+func (w *Watcher) Run(ctx context.Context) error {
+	// Closing the client stops the watcher as well
+	ctx, cancel := w.Client.Context(ctx)
+	defer cancel()
+
+	debounce, stableFor := w.Debounce, w.StableFor
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	if stableFor <= 0 {
+		stableFor = DefaultStableFor
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	mappings := make(map[string]*Mapping, len(w.Mappings))
+	for i := range w.Mappings {
+		m := &w.Mappings[i]
+		dir, err := filepath.Abs(m.Dir)
+		if err != nil {
+			return err
+		}
+		if err := fsw.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", m.Dir, err)
+		}
+		mappings[dir] = m
+	}
+
+	// Uploads run one at a time in their own goroutine so that events keep
+	// being processed while a large file is uploaded
+	w.lowCapacity = make(map[string]bool)
+	jobs := make(chan job)
+	finished := make(chan string)
+	go func() {
+		for j := range jobs {
+			w.upload(ctx, j.mapping, j.path)
+			select {
+			case finished <- j.path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer close(jobs)
+
+	pending := make(map[string]*pendingFile)
+	uploading := make(map[string]bool)
+	ticker := time.NewTicker(tickInterval(debounce, stableFor))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.event(eventlog.LevelWarn, "watch_error", nil, "", map[string]interface{}{"error": err.Error()})
+
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) {
+				continue
+			}
+			m := mappings[filepath.Dir(ev.Name)]
+			if m == nil || !w.wanted(m, ev.Name) {
+				continue
+			}
+			if p, ok := pending[ev.Name]; ok {
+				p.lastEvent = time.Now()
+			} else {
+				pending[ev.Name] = &pendingFile{mapping: m, lastEvent: time.Now()}
+			}
+
+		case path := <-finished:
+			delete(uploading, path)
+
+		case now := <-ticker.C:
+			for path, p := range pending {
+				if uploading[path] || now.Sub(p.lastEvent) < debounce {
+					continue
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					// The file was removed or renamed away
+					delete(pending, path)
+					continue
+				}
+				if p.stableSince.IsZero() || info.Size() != p.size || !info.ModTime().Equal(p.modTime) {
+					p.size, p.modTime, p.stableSince = info.Size(), info.ModTime(), now
+					continue
+				}
+				if now.Sub(p.stableSince) < stableFor {
+					continue
+				}
+
+				select {
+				case jobs <- job{mapping: p.mapping, path: path}:
+					uploading[path] = true
+					delete(pending, path)
+				default:
+					// The uploader is busy; try again on the next tick
+				}
+			}
+		}
+	}
+}
+
+// tickInterval returns how often pending files are checked
+func tickInterval(debounce, stableFor time.Duration) time.Duration {
+	interval := debounce
+	if stableFor < interval {
+		interval = stableFor
+	}
+	interval /= 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	return interval
+}
+
+// wanted reports whether the file at path should be uploaded for m
+func (w *Watcher) wanted(m *Mapping, path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	extensions := m.Extensions
+	if len(extensions) == 0 {
+		extensions = toniebox.DefaultAudioExtensions
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	matched := false
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+
+	// The ignore file is read on every event so that edits take effect
+	// without restarting the watcher
+	ignore, err := dirsync.LoadIgnore(m.Dir)
+	if err != nil {
+		w.event(eventlog.LevelWarn, "watch_error", m, path, map[string]interface{}{"error": err.Error()})
+		return false
+	}
+	return !ignore.Ignored(name)
+}
+
+// upload uploads the file at path to the tonie of m and commits it
+func (w *Watcher) upload(ctx context.Context, m *Mapping, path string) {
+	title := m.Prefix + toniebox.BatchFile{Path: path}.ChapterTitle()
+	started := time.Now()
+	tonie, err := w.uploadFile(ctx, m, path, title)
+
+	fields := map[string]interface{}{"title": title, "duration_ms": time.Since(started).Milliseconds()}
+	switch {
+	case errors.Is(err, errExists):
+		w.event(eventlog.LevelInfo, "upload_skipped", m, path, fields)
+		return
+	case err != nil:
+		fields["error"] = err.Error()
+		w.event(eventlog.LevelError, "upload_failed", m, path, fields)
+	default:
+		w.event(eventlog.LevelInfo, "upload", m, path, fields)
+	}
+	if w.OnUpload != nil {
+		w.OnUpload(*m, path, err)
+	}
+	if err == nil {
+		w.checkCapacity(m, tonie)
+	}
+}
+
+// checkCapacity reports tonie once it dropped below LowCapacity
+func (w *Watcher) checkCapacity(m *Mapping, tonie *toniebox.CreativeTonie) {
+	if !w.LowCapacity.enabled() {
+		return
+	}
+	// The remaining capacity is computed by the API on commit
+	if err := tonie.Refresh(); err != nil {
+		w.event(eventlog.LevelWarn, "watch_error", m, "", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if !w.LowCapacity.below(tonie) {
+		delete(w.lowCapacity, tonie.ID)
+		return
+	}
+	if w.lowCapacity[tonie.ID] {
+		return
+	}
+	w.lowCapacity[tonie.ID] = true
+
+	w.event(eventlog.LevelWarn, "low_capacity", m, "", map[string]interface{}{
+		"seconds_remaining":  tonie.SecondsRemaining,
+		"chapters_remaining": tonie.ChaptersRemaining,
+	})
+	if w.OnLowCapacity != nil {
+		w.OnLowCapacity(*m, tonie)
+	}
+}
+
+// errExists signals that the tonie already has a chapter with the file's title
+var errExists = errors.New("chapter already exists")
+
+// uploadFile performs the upload of a stable file and returns the tonie
+func (w *Watcher) uploadFile(ctx context.Context, m *Mapping, path, title string) (*toniebox.CreativeTonie, error) {
+	if err := w.Windows.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	// Fetch the tonie right before uploading so that changes made
+	// elsewhere in the meantime are not overwritten
+	tonie, err := w.Client.Household(m.HouseholdID).Tonie(m.TonieID)
+	if err != nil {
+		return nil, err
+	}
+	if tonie.FindChapterByTitle(title) != nil {
+		return nil, errExists
+	}
+	if err := tonie.UploadFileContext(ctx, title, path); err != nil {
+		return nil, err
+	}
+	// Once uploaded, the chapter is committed even if the watcher is
+	// stopping, so that the upload is not lost
+	return tonie, tonie.Commit()
+}
+
+// event records a structured event if an event log is configured
+func (w *Watcher) event(level eventlog.Level, event string, m *Mapping, path string, fields map[string]interface{}) {
+	if w.Events == nil {
+		return
+	}
+	if m != nil {
+		fields["tonie_id"] = m.TonieID
+		fields["dir"] = m.Dir
+	}
+	if path != "" {
+		fields["path"] = path
+	}
+	w.Events.Log(eventlog.Entry{Level: level, Source: "watch", Event: event, Fields: fields})
+}
@@ -0,0 +1,169 @@
+// Package window describes daily time windows, such as quiet hours during
+// which large transfers are allowed without congesting the home network
+// during the day. Long-running subsystems (the apply engine, the upload
+// scheduler) wait for an allowed window before transferring data.
+//
+// Example:
+//
+//	allowed, err := window.Parse("22:00-06:00")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	engine := &apply.Engine{Windows: allowed}
+package window
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time window given as offsets from local midnight.
+// If End is before Start, the window spans midnight (e.g. 22:00 to 06:00).
+// A window with equal Start and End covers the whole day.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether t lies within the window
+func (w Window) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return offset >= w.Start && offset < w.End
+	default:
+		return offset >= w.Start || offset < w.End
+	}
+}
+
+// Next returns t if it lies within the window, otherwise the next time the
+// window opens
+func (w Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	open := midnight(t).Add(w.Start)
+	if !open.After(t) {
+		open = midnight(t.AddDate(0, 0, 1)).Add(w.Start)
+	}
+	return open
+}
+
+// String formats the window as "HH:MM-HH:MM"
+func (w Window) String() string {
+	return formatOffset(w.Start) + "-" + formatOffset(w.End)
+}
+
+// Windows is a set of allowed windows. A nil or empty set allows any time.
+type Windows []Window
+
+// Contains reports whether t lies within any of the windows
+func (ws Windows) Contains(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	for _, w := range ws {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns t if it lies within any of the windows, otherwise the earliest
+// time one of them opens
+func (ws Windows) Next(t time.Time) time.Time {
+	if ws.Contains(t) {
+		return t
+	}
+	next := ws[0].Next(t)
+	for _, w := range ws[1:] {
+		if n := w.Next(t); n.Before(next) {
+			next = n
+		}
+	}
+	return next
+}
+
+// Wait blocks until the current time lies within one of the windows or ctx
+// is done
+func (ws Windows) Wait(ctx context.Context) error {
+	d := time.Until(ws.Next(time.Now()))
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// String formats the windows as a comma-separated list, see Parse
+func (ws Windows) String() string {
+	parts := make([]string, len(ws))
+	for i, w := range ws {
+		parts[i] = w.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse parses a comma-separated list of windows in the form "HH:MM-HH:MM",
+// e.g. "22:00-06:00" or "12:00-13:30,22:00-06:00". An empty string yields
+// nil, which allows any time.
+func Parse(s string) (Windows, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var ws Windows
+	for _, part := range strings.Split(s, ",") {
+		start, end, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid window %q: expected HH:MM-HH:MM", part)
+		}
+		startOffset, err := parseOffset(start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q: %w", part, err)
+		}
+		endOffset, err := parseOffset(end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q: %w", part, err)
+		}
+		ws = append(ws, Window{Start: startOffset, End: endOffset})
+	}
+	return ws, nil
+}
+
+// parseOffset parses "HH:MM" into an offset from midnight
+func parseOffset(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// formatOffset formats an offset from midnight as "HH:MM"
+func formatOffset(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// midnight returns local midnight of the day of t
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// sinceMidnight returns the time elapsed since local midnight of t
+func sinceMidnight(t time.Time) time.Duration {
+	return t.Sub(midnight(t))
+}